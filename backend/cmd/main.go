@@ -14,7 +14,10 @@ import (
 	"chaladshare_backend/internal/config"
 	"chaladshare_backend/internal/connect"
 	"chaladshare_backend/internal/connectdb"
+	"chaladshare_backend/internal/convert"
+	"chaladshare_backend/internal/imaging"
 	"chaladshare_backend/internal/middleware"
+	"chaladshare_backend/internal/storage"
 
 	AuthHandler "chaladshare_backend/internal/auth/handlers"
 	AuthRepo "chaladshare_backend/internal/auth/repository"
@@ -32,20 +35,53 @@ import (
 	UserRepo "chaladshare_backend/internal/users/repository"
 	UserService "chaladshare_backend/internal/users/service"
 
+	CommentHandler "chaladshare_backend/internal/comments/handlers"
+	CommentRepo "chaladshare_backend/internal/comments/repository"
+	CommentService "chaladshare_backend/internal/comments/service"
+
+	NotificationHandler "chaladshare_backend/internal/notifications/handlers"
+	NotificationRepo "chaladshare_backend/internal/notifications/repository"
+	NotificationService "chaladshare_backend/internal/notifications/service"
+
+	RealtimeHandler "chaladshare_backend/internal/realtime/handlers"
+	RealtimeService "chaladshare_backend/internal/realtime/service"
+
 	FriendsHandler "chaladshare_backend/internal/friends/handlers"
 	FriendsRepo "chaladshare_backend/internal/friends/repository"
 	FriendsService "chaladshare_backend/internal/friends/service"
 
+	FeatureHandler "chaladshare_backend/internal/docfeatures/handlers"
 	FeatureRepo "chaladshare_backend/internal/docfeatures/repository"
 	FeatureService "chaladshare_backend/internal/docfeatures/service"
 
+	JobModels "chaladshare_backend/internal/jobs/models"
+	JobRepo "chaladshare_backend/internal/jobs/repository"
+	JobService "chaladshare_backend/internal/jobs/service"
+
+	SummaryHandler "chaladshare_backend/internal/summaries/handlers"
+	SummaryRepo "chaladshare_backend/internal/summaries/repository"
+	SummaryService "chaladshare_backend/internal/summaries/service"
+
 	RecommendHandler "chaladshare_backend/internal/recommend/handlers"
 	RecommendRepo "chaladshare_backend/internal/recommend/repository"
 	RecommendService "chaladshare_backend/internal/recommend/service"
+
+	SearchHandler "chaladshare_backend/internal/search/handlers"
+	SearchRepo "chaladshare_backend/internal/search/repository"
+	SearchService "chaladshare_backend/internal/search/service"
 )
 
-func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+// skipPaths = route ที่ต่อค้างยาว (เช่น SSE) ไม่ตัดด้วย timeout
+func TimeoutMiddleware(timeout time.Duration, skipPaths ...string) gin.HandlerFunc {
+	skip := map[string]bool{}
+	for _, p := range skipPaths {
+		skip[p] = true
+	}
 	return func(c *gin.Context) {
+		if skip[c.FullPath()] {
+			c.Next()
+			return
+		}
 		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 		defer cancel()
 		c.Request = c.Request.WithContext(ctx)
@@ -77,18 +113,32 @@ func parseAllowOrigins(raw string) []string {
 func main() {
 	_ = godotenv.Load()
 
-	for _, k := range []string{"SUPABASE_URL", "SUPABASE_SERVICE_ROLE_KEY", "SUPABASE_STORAGE_BUCKET"} {
-		if os.Getenv(k) == "" {
-			log.Println("WARNING:", k, "is empty")
-		}
-	}
-
 	// config
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// storage (local / supabase / s3) เลือกตาม STORAGE_PROVIDER
+	storageRegistry, err := storage.NewRegistryFromEnv(storage.Config{
+		DefaultProvider: cfg.StorageProvider,
+		UploadDir:       cfg.UploadDir,
+		LocalBaseURL:    cfg.LocalStorageBase,
+		LocalSignKey:    cfg.JWTSecret,
+
+		DocumentBucket:   cfg.StorageDocumentBucket,
+		PrivateDocuments: cfg.StoragePrivateDocuments,
+		SignedURLTTL:     time.Duration(cfg.SignedURLTTLSeconds) * time.Second,
+		ProxyDocuments:   cfg.StorageProxyDocuments,
+	})
+	if err != nil {
+		log.Fatalf("Failed to init storage: %v", err)
+	}
+	if _, err := storageRegistry.Default(); err != nil {
+		log.Printf("WARNING: %v (uploads will fail)", err)
+	}
+	log.Printf("storage default=%s available=%v", storageRegistry.DefaultProvider(), storageRegistry.Providers())
+
 	// add test colab
 	if os.Getenv("COLAB_URL") == "" {
 		log.Println("WARNING: COLAB_URL is empty")
@@ -108,12 +158,19 @@ func main() {
 
 	// auth
 	authRepository := AuthRepo.NewAuthRepository(db.GetDB())
-	authService := AuthService.NewAuthService(authRepository, []byte(cfg.JWTSecret), cfg.TokenTTLMinutes)
-	authHandler := AuthHandler.NewAuthHandler(authService, cfg.CookieName, secureCookie)
+	sessionRepository := AuthRepo.NewSessionRepository(db.GetDB())
+	authService := AuthService.NewAuthService(authRepository, sessionRepository, []byte(cfg.JWTSecret), cfg.TokenTTLMinutes,
+		time.Duration(cfg.RefreshTTLDays)*24*time.Hour)
+	authHandler := AuthHandler.NewAuthHandler(authService, cfg.CookieName, cfg.RefreshCookieName, secureCookie)
+
+	// notifications (โมดูลอื่นส่งเหตุการณ์เข้ามาผ่าน Notifier)
+	notificationRepository := NotificationRepo.NewNotificationRepository(db.GetDB())
+	notificationService := NotificationService.NewNotificationService(notificationRepository)
+	notificationHandler := NotificationHandler.NewNotificationHandler(notificationService)
 
 	// friends
 	friendsRepo := FriendsRepo.NewFriendRepository(db.GetDB())
-	friendsService := FriendsService.NewFriendService(friendsRepo)
+	friendsService := FriendsService.NewFriendService(friendsRepo, notificationService)
 	friendsHandler := FriendsHandler.NewFriendHandler(friendsService)
 
 	// AI client (Colab/ngrok)
@@ -122,30 +179,85 @@ func main() {
 		log.Printf("WARNING: cannot init AI client: %v", err)
 		aiClient = nil
 	}
+	aiClient.StartHealthProbe(context.Background())
+
+	// file + summary
+	fileRepository := FileRepo.NewFileRepository(db.GetDB())
+
+	// job queue (document_jobs) แทน goroutine เปล่าตอนอัปโหลด
+	jobRepository := JobRepo.NewJobRepository(db.GetDB())
+	// DOCX / PPTX → PDF ผ่าน LibreOffice (ไม่มี = ส่งข้อความที่ดึงได้ให้ AI แทน)
+	converter := convert.NewConverter(cfg.ConvertOffice)
+	log.Printf("office converter available: %v", converter.OfficeAvailable())
+	jobService := JobService.NewJobService(jobRepository, fileRepository, storageRegistry, converter, JobService.WorkerConfig{
+		Concurrency:  cfg.JobWorkers,
+		PollInterval: time.Duration(cfg.JobPollSeconds) * time.Second,
+		Lease:        time.Duration(cfg.JobLeaseMinutes) * time.Minute,
+		MaxAttempts:  cfg.JobMaxAttempts,
+	})
 
 	featureRepository := FeatureRepo.NewFeatureRepo(db.GetDB())
-	featureService := FeatureService.NewFeatureService(featureRepository, aiClient)
+	featureService := FeatureService.NewFeatureService(featureRepository, aiClient, jobService, notificationService)
 
-	// file
-	fileRepository := FileRepo.NewFileRepository(db.GetDB())
-	fileService := FileService.NewFileService(fileRepository, featureService)
-	fileHandler := FileHandler.NewFileHandler(fileService)
+	summaryRepository := SummaryRepo.NewSummaryRepository(db.GetDB())
+	summaryService := SummaryService.NewSummaryService(summaryRepository, fileRepository, aiClient, jobService)
+
+	jobService.Register(JobModels.JobExtractFeatures, featureService)
+	jobService.Register(JobModels.JobSummarize, summaryService)
+	// ข้อความรายหน้าดึงในเครื่อง (ค้นหาได้แม้ไม่มี Colab)
+	jobService.Register(JobModels.JobExtractText, FeatureService.NewTextService(featureRepository))
+
+	// หน้าปกอัตโนมัติจากหน้าแรกของ PDF (ต้องมี pdftoppm หรือ mutool ในเครื่อง)
+	if renderer := imaging.NewPDFRenderer(cfg.PDFRenderer); renderer.Available() {
+		jobService.Register(JobModels.JobRenderCover, FileService.NewCoverService(fileRepository, storageRegistry, renderer))
+		log.Printf("cover renderer: %s", renderer.Name())
+	} else {
+		log.Printf("cover renderer disabled (PDF_RENDERER=%q)", cfg.PDFRenderer)
+	}
+	if err := jobService.Recover(); err != nil {
+		log.Printf("WARNING: job recovery failed: %v", err)
+	}
+	jobService.Start(context.Background())
+
+	fileService := FileService.NewFileService(fileRepository, featureService, summaryService, jobService, storageRegistry, FileService.UploadLimits{
+		MaxDocumentBytes: int64(cfg.MaxDocumentMB) << 20,
+		MaxImageBytes:    int64(cfg.MaxImageMB) << 20,
+	})
+	summaryHandler := SummaryHandler.NewSummaryHandler(summaryService, fileService)
+	statusHandler := FeatureHandler.NewStatusHandler(featureService, summaryService, jobService, fileService)
 
 	// post like save
 	postRepository := PostRepo.NewPostRepository(db.GetDB())
-	postService := PostService.NewPostService(postRepository, friendsService)
+	postService := PostService.NewPostService(postRepository, friendsService, storageRegistry, notificationService)
+	fileHandler := FileHandler.NewFileHandler(fileService, postService)
+
+	// คะแนน trending ลดตามอายุโพสต์ → คิดใหม่ตามรอบ
+	PostService.NewTrendingService(postRepository,
+		time.Duration(cfg.TrendingRefreshMinutes)*time.Minute,
+		time.Duration(cfg.TrendingWindowDays)*24*time.Hour).Start(context.Background())
+
+	// อัปโหลด PDF ใหญ่แบบแบ่งก้อน
+	uploadSessionService := FileService.NewUploadSessionService(FileRepo.NewUploadSessionRepository(db.GetDB()), fileService,
+		time.Duration(cfg.UploadSessionTTLHours)*time.Hour)
+	uploadSessionService.StartJanitor(context.Background())
+	uploadSessionHandler := FileHandler.NewUploadSessionHandler(uploadSessionService)
 
 	likeRepository := PostRepo.NewLikeRepository(db.GetDB())
-	likeService := PostService.NewLikeService(likeRepository)
+	likeService := PostService.NewLikeService(likeRepository, notificationService)
 
 	saveRepository := PostRepo.NewSaveRepository(db.GetDB())
-	saveService := PostService.NewSaveService(saveRepository)
+	saveService := PostService.NewSaveService(saveRepository, notificationService)
 
 	postHandler := PostHandler.NewPostHandler(postService, likeService, saveService)
 
+	// comments (สิทธิ์เห็นตามโพสต์)
+	commentRepository := CommentRepo.NewCommentRepository(db.GetDB())
+	commentService := CommentService.NewCommentService(commentRepository, postService, notificationService)
+	commentHandler := CommentHandler.NewCommentHandler(commentService)
+
 	// user
 	userRepository := UserRepo.NewUserRepository(db.GetDB())
-	userService := UserService.NewUserService(userRepository)
+	userService := UserService.NewUserService(userRepository, storageRegistry)
 	userHandler := UserHandler.NewUserHandler(userService, postService, friendsService)
 
 	// recommend
@@ -153,6 +265,16 @@ func main() {
 	recommendService := RecommendService.NewRecommendService(recommendRepo)
 	recommendHandler := RecommendHandler.NewRecommendHandler(recommendService)
 
+	// search (embed คำค้นผ่าน AI แล้วหาเอกสารที่ใกล้เคียง)
+	searchRepo := SearchRepo.NewSearchRepo(db.GetDB())
+	searchService := SearchService.NewSearchService(searchRepo, aiClient)
+	searchHandler := SearchHandler.NewSearchHandler(searchService)
+
+	// realtime: trigger ใน DB ส่ง NOTIFY → ทุก instance กระจายให้ client ทาง SSE
+	realtimeHub := RealtimeService.NewHub()
+	RealtimeService.NewListener(cfg.GetConnectionString(), realtimeHub).Start(context.Background())
+	eventHandler := RealtimeHandler.NewEventHandler(realtimeHub, postService)
+
 	go func() {
 		for {
 			time.Sleep(10 * time.Second)
@@ -184,31 +306,39 @@ func main() {
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     allowOrigins,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", "Range", "Upload-Offset"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Range", "Content-Disposition", "ETag", "Location", "Upload-Offset", "Upload-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	r.Use(TimeoutMiddleware(180 * time.Second))
+	// /events เป็น SSE ต่อค้างได้นาน ไม่ตัดทิ้งทุก 3 นาที
+	r.Use(TimeoutMiddleware(180*time.Second, "/api/v1/events"))
 
 	r.MaxMultipartMemory = 100 << 20
-	uploadDir := os.Getenv("UPLOAD_DIR")
-	if uploadDir == "" {
-		uploadDir = "/tmp/uploads"
-	}
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		log.Printf("WARNING: cannot create upload dir (%s): %v", uploadDir, err)
+	// local storage เสิร์ฟไฟล์จาก UPLOAD_DIR (สร้าง dir ไว้แล้วตอน init storage)
+	// เอกสาร private ต้องตรวจ signature ก่อน เลยใช้ static ตรงๆ ไม่ได้
+	if local, ok := storageRegistry.Local(); ok && storageRegistry.PrivateDocuments() {
+		r.GET("/uploads/*filepath", func(c *gin.Context) {
+			local.ServeObject(c.Writer, c.Request, c.Param("filepath"))
+		})
+	} else {
+		r.Static("/uploads", cfg.UploadDir)
 	}
-	r.Static("/uploads", uploadDir)
 
 	r.GET("/health", func(c *gin.Context) {
+		// AI ล่มไม่ทำให้ backend unhealthy แค่รายงานสถานะ breaker
+		ai := gin.H{"state": "disabled"}
+		if aiClient != nil {
+			ai = gin.H{"state": "enabled", "breaker": aiClient.BreakerStatus()}
+		}
+
 		if err := connectdb.CheckDBConnection(db.GetDB()); err != nil {
-			c.JSON(503, gin.H{"detail": "Database connection failed"})
+			c.JSON(503, gin.H{"detail": "Database connection failed", "ai": ai})
 			return
 		}
-		c.JSON(200, gin.H{"status": "healthy", "database": "connected"})
+		c.JSON(200, gin.H{"status": "healthy", "database": "connected", "ai": ai})
 	})
 
 	v1 := r.Group("/api/v1")
@@ -218,6 +348,7 @@ func main() {
 	{
 		authRoutes.POST("/register", authHandler.Register)
 		authRoutes.POST("/login", authHandler.Login)
+		authRoutes.POST("/refresh", authHandler.Refresh)
 		authRoutes.POST("/logout", authHandler.Logout)
 
 		authRoutes.GET("/users", authHandler.GetAllUsers)
@@ -226,8 +357,10 @@ func main() {
 
 	// Protected (ต้องมี JWT)
 	protected := v1.Group("/")
-	protected.Use(middleware.JWT([]byte(cfg.JWTSecret), cfg.CookieName))
+	protected.Use(middleware.JWT([]byte(cfg.JWTSecret), cfg.CookieName, authService))
 	{
+		protected.POST("/auth/logout-all", authHandler.LogoutAll)
+
 		posts := protected.Group("/posts")
 		{
 			posts.GET("", postHandler.GetAllPosts)
@@ -240,13 +373,32 @@ func main() {
 			posts.POST("/:id/like", postHandler.ToggleLike)
 			posts.POST("/:id/save", postHandler.ToggleSave)
 			posts.GET("/save", postHandler.GetSavedPosts)
+
+			posts.GET("/:id/comments", commentHandler.ListComments)
+			posts.POST("/:id/comments", commentHandler.CreateComment)
+			posts.GET("/:id/comments/:comment_id/replies", commentHandler.ListReplies)
+			posts.PUT("/:id/comments/:comment_id", commentHandler.UpdateComment)
+			posts.DELETE("/:id/comments/:comment_id", commentHandler.DeleteComment)
 		}
 
 		files := protected.Group("/files")
 		{
 			files.POST("/doc", fileHandler.UploadFile)
+			files.POST("/uploads", uploadSessionHandler.Create)
+			files.HEAD("/uploads/:upload_id", uploadSessionHandler.Head)
+			files.GET("/uploads/:upload_id", uploadSessionHandler.Get)
+			files.PATCH("/uploads/:upload_id", uploadSessionHandler.Patch)
+			files.POST("/uploads/:upload_id/complete", uploadSessionHandler.Complete)
+			files.DELETE("/uploads/:upload_id", uploadSessionHandler.Abort)
 			files.GET("/user/:id", fileHandler.GetFilesByUserID)
-			files.GET("/:document_id/summary", fileHandler.GetSummaryByDocumentID)
+			files.GET("/:document_id/content", fileHandler.GetFileContent)
+			files.GET("/:document_id/versions", fileHandler.ListVersions)
+			files.POST("/:document_id/versions", fileHandler.UploadVersion)
+			files.POST("/:document_id/restore", fileHandler.RestoreVersion)
+			files.GET("/:document_id/summary", summaryHandler.GetSummary)
+			files.POST("/:document_id/summary", summaryHandler.GenerateSummary)
+			files.GET("/:document_id/status", statusHandler.GetStatus)
+			files.POST("/:document_id/reprocess", statusHandler.Reprocess)
 			files.DELETE("/:document_id", fileHandler.DeleteFile)
 
 			files.POST("/cover", fileHandler.UploadCover)
@@ -285,6 +437,23 @@ func main() {
 		{
 			recommend.GET("", recommendHandler.GetRecommend)
 		}
+
+		search := protected.Group("/search")
+		{
+			search.GET("/semantic", searchHandler.Semantic)
+		}
+
+		protected.GET("/events", eventHandler.Stream)
+
+		notifications := protected.Group("/notifications")
+		{
+			notifications.GET("", notificationHandler.List)
+			notifications.GET("/unread-count", notificationHandler.UnreadCount)
+			notifications.POST("/read-all", notificationHandler.MarkAllRead)
+			notifications.POST("/:id/read", notificationHandler.MarkRead)
+			notifications.GET("/preferences", notificationHandler.GetPreferences)
+			notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
+		}
 	}
 
 	port := os.Getenv("PORT")
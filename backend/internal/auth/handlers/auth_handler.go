@@ -117,23 +117,31 @@
 package handler
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"chaladshare_backend/internal/auth/models"
 	"chaladshare_backend/internal/auth/service"
+	"chaladshare_backend/internal/middleware"
 )
 
+// refresh token ส่งแค่ไป /auth (refresh / logout) ไม่ติดไปกับทุก request
+const refreshCookiePath = "/api/v1/auth"
+
 type AuthHandler struct {
-	authService service.AuthService
-	cookieName  string
-	secure      bool
+	authService       service.AuthService
+	cookieName        string
+	refreshCookieName string
+	secure            bool
 }
 
-func NewAuthHandler(authService service.AuthService, cookieName string, secure bool) *AuthHandler {
-	return &AuthHandler{authService: authService, cookieName: cookieName, secure: secure}
+func NewAuthHandler(authService service.AuthService, cookieName, refreshCookieName string, secure bool) *AuthHandler {
+	return &AuthHandler{authService: authService, cookieName: cookieName, refreshCookieName: refreshCookieName, secure: secure}
 }
 
 // ✅ สำคัญ: ข้ามโดเมน (Vercel) ต้อง SameSite=None และ Secure=true (ตอน prod)
@@ -160,6 +168,36 @@ func (h *AuthHandler) clearAuthCookie(c *gin.Context) {
 	})
 }
 
+func (h *AuthHandler) setRefreshCookie(c *gin.Context, token string, expiresAt time.Time) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     h.refreshCookieName,
+		Value:    token,
+		Path:     refreshCookiePath,
+		Expires:  expiresAt,
+		MaxAge:   int(time.Until(expiresAt).Seconds()),
+		HttpOnly: true,
+		Secure:   h.secure,
+		SameSite: http.SameSiteNoneMode,
+	})
+}
+
+func (h *AuthHandler) clearRefreshCookie(c *gin.Context) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     h.refreshCookieName,
+		Value:    "",
+		Path:     refreshCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secure,
+		SameSite: http.SameSiteNoneMode,
+	})
+}
+
+func (h *AuthHandler) setSessionCookies(c *gin.Context, pair *models.TokenPair) {
+	h.setAuthCookie(c, pair.AccessToken)
+	h.setRefreshCookie(c, pair.RefreshToken, pair.RefreshExpiresAt)
+}
+
 // Get all user
 func (h *AuthHandler) GetAllUsers(c *gin.Context) {
 	users, err := h.authService.GetAllUsers()
@@ -201,14 +239,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.IssueToken(user.ID)
+	pair, err := h.authService.StartSession(user.ID, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "issue token failed"})
 		return
 	}
 
 	// ✅ set cookie
-	h.setAuthCookie(c, token)
+	h.setSessionCookies(c, pair)
 
 	resp := models.AuthResponse{
 		ID: user.ID, Email: user.Email, Username: user.Username,
@@ -235,14 +273,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.IssueToken(user.ID)
+	pair, err := h.authService.StartSession(user.ID, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "issue token failed"})
 		return
 	}
 
 	// ✅ set cookie
-	h.setAuthCookie(c, token)
+	h.setSessionCookies(c, pair)
 
 	resp := models.AuthResponse{
 		ID: user.ID, Email: user.Email, Username: user.Username,
@@ -251,8 +289,57 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Login successful", "user": resp})
 }
 
+// POST /auth/refresh: หมุน refresh token + ออก access token ใหม่
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	token, _ := c.Cookie(h.refreshCookieName)
+	pair, err := h.authService.Refresh(token)
+	if err != nil {
+		// อีกแท็บเพิ่งได้ cookie ใหม่ไป → ห้ามล้าง cookie ไม่งั้นแท็บนั้นหลุด login ด้วย
+		if errors.Is(err, models.ErrRefreshTokenRotated) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, models.ErrInvalidRefreshToken) || errors.Is(err, models.ErrRefreshTokenReused) {
+			h.clearAuthCookie(c)
+			h.clearRefreshCookie(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "refresh token failed"})
+		return
+	}
+
+	h.setSessionCookies(c, pair)
+	c.JSON(http.StatusOK, gin.H{"message": "token refreshed"})
+}
+
+// revoke session ของเครื่องนี้ แล้วล้าง cookie (revoke ไม่สำเร็จก็ยังล้าง cookie)
 func (h *AuthHandler) Logout(c *gin.Context) {
+	token, _ := c.Cookie(h.refreshCookieName)
+	if err := h.authService.Logout(token); err != nil {
+		log.Printf("[AUTH] revoke session err=%v", err)
+	}
+
 	// ✅ clear cookie
 	h.clearAuthCookie(c)
+	h.clearRefreshCookie(c)
 	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
 }
+
+// POST /auth/logout-all: revoke ทุก session ของผู้ใช้ (รวมเครื่องนี้)
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID := c.GetInt(middleware.CtxUserID)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	n, err := h.authService.LogoutAll(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout all failed"})
+		return
+	}
+
+	h.clearAuthCookie(c)
+	h.clearRefreshCookie(c)
+	c.JSON(http.StatusOK, gin.H{"message": "logged out from all devices", "revoked_sessions": n})
+}
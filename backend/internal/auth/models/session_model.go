@@ -0,0 +1,34 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, session revoked")
+	// อีกแท็บเพิ่ง refresh ด้วย token เดียวกัน: cookie ใหม่ถูกตั้งแล้ว ให้ client ลองใหม่
+	ErrRefreshTokenRotated = errors.New("refresh token was just rotated, retry the request")
+)
+
+// หนึ่งแถวใน auth_sessions (ไม่มี hash ของ token)
+type Session struct {
+	SessionID string     `json:"session_id"`
+	UserID    int        `json:"user_id"`
+	UserAgent string     `json:"user_agent"`
+	IPAddress string     `json:"ip_address"`
+	CreatedAt time.Time  `json:"created_at"`
+	RotatedAt time.Time  `json:"rotated_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ได้จาก login / register / refresh: refresh token ตัวจริงมีแค่ตรงนี้ ใน DB เก็บแค่ hash
+type TokenPair struct {
+	UserID           int
+	SessionID        string
+	AccessToken      string
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
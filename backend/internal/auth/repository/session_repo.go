@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"chaladshare_backend/internal/auth/models"
+)
+
+type SessionRepository interface {
+	Create(s *models.Session, tokenHash string) error
+	// หมุน token: hash เดิมต้องยังใช้ได้ คืน nil ถ้าไม่พบ / หมดอายุ / ถูก revoke
+	Rotate(oldHash, newHash string, expiresAt time.Time) (*models.Session, error)
+	// hash ที่ถูกหมุนไปแล้ว: เกิน grace = ใช้ซ้ำ → revoke (revoked)
+	// ยังอยู่ใน grace = สองแท็บ refresh พร้อมกัน ไม่ revoke (recent)
+	RevokeReused(oldHash string, grace time.Duration) (revoked bool, recent bool, err error)
+	RevokeByTokenHash(tokenHash string) error
+	RevokeByID(sessionID string) error
+	RevokeAllForUser(userID int) (int64, error)
+	IsActive(sessionID string) (bool, error)
+}
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(s *models.Session, tokenHash string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// เก็บกวาด session เก่าของผู้ใช้คนนี้ไปด้วย
+	if _, err := tx.Exec(`
+		DELETE FROM auth_sessions
+		WHERE user_id = $1
+		  AND (expires_at < now() OR revoked_at < now() - INTERVAL '7 days')
+	`, s.UserID); err != nil {
+		return fmt.Errorf("ลบ session เก่าไม่สำเร็จ: %w", err)
+	}
+
+	if err := tx.QueryRow(`
+		INSERT INTO auth_sessions (session_id, user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, rotated_at
+	`, s.SessionID, s.UserID, tokenHash, s.UserAgent, s.IPAddress, s.ExpiresAt).Scan(&s.CreatedAt, &s.RotatedAt); err != nil {
+		return fmt.Errorf("สร้าง session ไม่สำเร็จ: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *sessionRepository) Rotate(oldHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	var s models.Session
+	err := r.db.QueryRow(`
+		UPDATE auth_sessions
+		SET previous_token_hash = refresh_token_hash,
+		    refresh_token_hash  = $2,
+		    rotated_at          = now(),
+		    expires_at          = $3
+		WHERE refresh_token_hash = $1
+		  AND revoked_at IS NULL
+		  AND expires_at > now()
+		RETURNING session_id, user_id, user_agent, ip_address, created_at, rotated_at, expires_at
+	`, oldHash, newHash, expiresAt).Scan(&s.SessionID, &s.UserID, &s.UserAgent, &s.IPAddress,
+		&s.CreatedAt, &s.RotatedAt, &s.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("หมุน refresh token ไม่สำเร็จ: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *sessionRepository) RevokeReused(oldHash string, grace time.Duration) (bool, bool, error) {
+	var revoked, recent bool
+	err := r.db.QueryRow(`
+		WITH hit AS (
+			SELECT session_id, rotated_at >= now() - make_interval(secs => $2) AS recent
+			FROM auth_sessions
+			WHERE previous_token_hash = $1 AND revoked_at IS NULL
+			FOR UPDATE
+		), rev AS (
+			UPDATE auth_sessions s SET revoked_at = now()
+			FROM hit
+			WHERE s.session_id = hit.session_id AND NOT hit.recent
+			RETURNING s.session_id
+		)
+		SELECT EXISTS (SELECT 1 FROM rev), EXISTS (SELECT 1 FROM hit WHERE hit.recent)
+	`, oldHash, grace.Seconds()).Scan(&revoked, &recent)
+	return revoked, recent, err
+}
+
+func (r *sessionRepository) RevokeByTokenHash(tokenHash string) error {
+	_, err := r.db.Exec(`
+		UPDATE auth_sessions SET revoked_at = now()
+		WHERE (refresh_token_hash = $1 OR previous_token_hash = $1)
+		  AND revoked_at IS NULL
+	`, tokenHash)
+	return err
+}
+
+func (r *sessionRepository) RevokeByID(sessionID string) error {
+	_, err := r.db.Exec(`
+		UPDATE auth_sessions SET revoked_at = now()
+		WHERE session_id = $1 AND revoked_at IS NULL
+	`, sessionID)
+	return err
+}
+
+func (r *sessionRepository) RevokeAllForUser(userID int) (int64, error) {
+	res, err := r.db.Exec(`
+		UPDATE auth_sessions SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *sessionRepository) IsActive(sessionID string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(`
+		SELECT revoked_at IS NULL AND expires_at > now()
+		FROM auth_sessions
+		WHERE session_id = $1
+	`, sessionID).Scan(&ok)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return ok, err
+}
@@ -1,12 +1,18 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
 	"chaladshare_backend/internal/auth/models"
@@ -19,27 +25,51 @@ type AuthService interface {
 	GetUserByEmail(email string) (*models.User, error)
 	Register(email, username, password string) (*models.User, error)
 	Login(email, password string) (*models.User, error)
-	IssueToken(userID int) (string, error)
+
+	// login / register: สร้าง session ใหม่ + access token อายุสั้น + refresh token
+	StartSession(userID int, userAgent, ip string) (*models.TokenPair, error)
+	Refresh(refreshToken string) (*models.TokenPair, error)
+	// logout เครื่องนี้ (token ที่ไม่รู้จักถือว่า logout แล้ว)
+	Logout(refreshToken string) error
+	LogoutAll(userID int) (int64, error)
+	// middleware.JWT ใช้เช็คว่า sid ใน access token ยังไม่ถูก revoke
+	SessionActive(sessionID string) (bool, error)
 }
 
+// สองแท็บ refresh พร้อมกันด้วย token เดิม: ช่วงนี้ไม่นับเป็นการขโมย token
+const refreshReuseGrace = 30 * time.Second
+
 type authService struct {
 	userRepo        repository.AuthRepository
+	sessionRepo     repository.SessionRepository
 	jwtSecret       []byte
 	tokenTTLMinutes int
+	refreshTTL      time.Duration
 }
 
-func NewAuthService(userRepo repository.AuthRepository, secret []byte, ttlMin int) AuthService {
+func NewAuthService(userRepo repository.AuthRepository, sessionRepo repository.SessionRepository, secret []byte, ttlMin int, refreshTTL time.Duration) AuthService {
+	if ttlMin <= 0 {
+		ttlMin = 15
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
 	return &authService{
 		userRepo:        userRepo,
+		sessionRepo:     sessionRepo,
 		jwtSecret:       secret,
 		tokenTTLMinutes: ttlMin,
+		refreshTTL:      refreshTTL,
 	}
 }
 
-func (s *authService) IssueToken(userID int) (string, error) {
+// sid = session ที่ออก token นี้, jti = id ของ token แต่ละใบ
+func (s *authService) issueAccessToken(userID int, sessionID string) (string, error) {
 	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
+		"sid":     sessionID,
+		"jti":     uuid.NewString(),
 		"iat":     now.Unix(),
 		"exp":     now.Add(time.Duration(s.tokenTTLMinutes) * time.Minute).Unix(),
 	}
@@ -47,6 +77,121 @@ func (s *authService) IssueToken(userID int) (string, error) {
 	return t.SignedString(s.jwtSecret)
 }
 
+// 32 byte สุ่ม ส่งให้ client ตัวเดียว ใน DB เก็บ sha256
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *authService) StartSession(userID int, userAgent, ip string) (*models.TokenPair, error) {
+	if userID <= 0 {
+		return nil, errors.New("invalid user ID")
+	}
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+	if r := []rune(userAgent); len(r) > 255 {
+		userAgent = string(r[:255])
+	}
+	sess := &models.Session{
+		SessionID: uuid.NewString(),
+		UserID:    userID,
+		UserAgent: userAgent,
+		IPAddress: ip,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := s.sessionRepo.Create(sess, hash); err != nil {
+		return nil, err
+	}
+	access, err := s.issueAccessToken(userID, sess.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TokenPair{
+		UserID:           userID,
+		SessionID:        sess.SessionID,
+		AccessToken:      access,
+		RefreshToken:     token,
+		RefreshExpiresAt: sess.ExpiresAt,
+	}, nil
+}
+
+// หมุน refresh token ทุกครั้ง ตัวเก่าใช้ไม่ได้อีก ถ้ามีคนเอาตัวเก่ามาใช้ → revoke ทั้ง session
+func (s *authService) Refresh(refreshToken string) (*models.TokenPair, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil, models.ErrInvalidRefreshToken
+	}
+	oldHash := hashRefreshToken(refreshToken)
+
+	token, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+	sess, err := s.sessionRepo.Rotate(oldHash, newHash, time.Now().Add(s.refreshTTL))
+	if err != nil {
+		return nil, err
+	}
+	if sess == nil {
+		revoked, recent, err := s.sessionRepo.RevokeReused(oldHash, refreshReuseGrace)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			log.Printf("[AUTH] refresh token reuse detected, session revoked")
+			return nil, models.ErrRefreshTokenReused
+		}
+		if recent {
+			return nil, models.ErrRefreshTokenRotated
+		}
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	access, err := s.issueAccessToken(sess.UserID, sess.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TokenPair{
+		UserID:           sess.UserID,
+		SessionID:        sess.SessionID,
+		AccessToken:      access,
+		RefreshToken:     token,
+		RefreshExpiresAt: sess.ExpiresAt,
+	}, nil
+}
+
+func (s *authService) Logout(refreshToken string) error {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil
+	}
+	return s.sessionRepo.RevokeByTokenHash(hashRefreshToken(refreshToken))
+}
+
+func (s *authService) LogoutAll(userID int) (int64, error) {
+	if userID <= 0 {
+		return 0, errors.New("invalid user ID")
+	}
+	return s.sessionRepo.RevokeAllForUser(userID)
+}
+
+func (s *authService) SessionActive(sessionID string) (bool, error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return false, nil
+	}
+	return s.sessionRepo.IsActive(sessionID)
+}
+
 // ผู้ใช้ทั้งหมด
 func (s *authService) GetAllUsers() ([]models.User, error) {
 	return s.userRepo.GetAllUsers()
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"chaladshare_backend/internal/comments/models"
+	"chaladshare_backend/internal/comments/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	commentService service.CommentService
+}
+
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{commentService: commentService}
+}
+
+func parseParamID(c *gin.Context, key string) (int, bool) {
+	n, err := strconv.Atoi(c.Param(key))
+	if err != nil || n <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + key})
+		return 0, false
+	}
+	return n, true
+}
+
+// ?limit=&cursor= (cursor = next_cursor จากหน้าก่อน)
+func parsePage(c *gin.Context) (afterID, limit int, ok bool) {
+	limit, _ = strconv.Atoi(c.Query("limit"))
+	if cur := c.Query("cursor"); cur != "" {
+		n, err := strconv.Atoi(cur)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return 0, 0, false
+		}
+		afterID = n
+	}
+	return afterID, limit, true
+}
+
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment"})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	case errors.Is(err, service.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// คอมเมนต์ระดับบนสุดของโพสต์
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	uid := c.GetInt("user_id")
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	postID, ok := parseParamID(c, "id")
+	if !ok {
+		return
+	}
+	afterID, limit, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	items, next, err := h.commentService.ListComments(uid, postID, afterID, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "next_cursor": next})
+}
+
+// คำตอบทั้งเธรดของคอมเมนต์
+func (h *CommentHandler) ListReplies(c *gin.Context) {
+	uid := c.GetInt("user_id")
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	postID, ok := parseParamID(c, "id")
+	if !ok {
+		return
+	}
+	commentID, ok := parseParamID(c, "comment_id")
+	if !ok {
+		return
+	}
+	afterID, limit, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	items, next, err := h.commentService.ListReplies(uid, postID, commentID, afterID, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "next_cursor": next})
+}
+
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	uid := c.GetInt("user_id")
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	postID, ok := parseParamID(c, "id")
+	if !ok {
+		return
+	}
+	var req models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(uid, postID, req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": comment})
+}
+
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	uid := c.GetInt("user_id")
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	postID, ok := parseParamID(c, "id")
+	if !ok {
+		return
+	}
+	commentID, ok := parseParamID(c, "comment_id")
+	if !ok {
+		return
+	}
+	var req models.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(uid, postID, commentID, req.Content)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": comment})
+}
+
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	uid := c.GetInt("user_id")
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	postID, ok := parseParamID(c, "id")
+	if !ok {
+		return
+	}
+	commentID, ok := parseParamID(c, "comment_id")
+	if !ok {
+		return
+	}
+
+	if err := h.commentService.DeleteComment(uid, postID, commentID); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "comment deleted"})
+}
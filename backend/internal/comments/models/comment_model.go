@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+type Comment struct {
+	CommentID  int        `json:"comment_id"`
+	PostID     int        `json:"post_id"`
+	UserID     int        `json:"user_id"`
+	Username   string     `json:"username"`
+	AvatarURL  *string    `json:"avatar_url"`
+	ParentID   *int       `json:"parent_id"`
+	RootID     *int       `json:"root_id"`
+	Content    string     `json:"content"`
+	CreatedAt  time.Time  `json:"created_at"`
+	EditedAt   *time.Time `json:"edited_at"`
+	IsDeleted  bool       `json:"is_deleted"`
+	ReplyCount int        `json:"reply_count"`
+
+	AvatarSrcSet string `json:"avatar_srcset,omitempty"`
+
+	// ชื่อเจ้าของคอมเมนต์ที่ตอบ (เฉพาะคอมเมนต์ตอบกลับ)
+	ReplyToUsername *string `json:"reply_to_username,omitempty"`
+
+	// สิทธิ์ของคนที่ดูอยู่ ให้ frontend ซ่อน/แสดงปุ่ม
+	CanEdit   bool `json:"can_edit"`
+	CanDelete bool `json:"can_delete"`
+}
+
+type CreateCommentRequest struct {
+	Content  string `json:"content" binding:"required"`
+	ParentID *int   `json:"parent_id"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
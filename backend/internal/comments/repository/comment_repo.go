@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"chaladshare_backend/internal/comments/models"
+)
+
+type CommentRepository interface {
+	Create(c *models.Comment) (int, error)
+	GetByID(commentID int) (*models.Comment, error)
+	ListTopLevel(postID, afterID, limit int) ([]models.Comment, error)
+	ListReplies(rootID, afterID, limit int) ([]models.Comment, error)
+	UpdateContent(commentID int, content string) error
+	SoftDelete(commentID int) error
+}
+
+type commentRepository struct {
+	db *sql.DB
+}
+
+func NewCommentRepository(db *sql.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+// คอมเมนต์ที่ลบแล้วไม่ส่งเนื้อหาออกไป
+const commentSelect = `
+	SELECT c.comment_id, c.comment_post_id, c.comment_user_id, u.username, up.avatar_url,
+		c.comment_parent_id, c.comment_root_id,
+		CASE WHEN c.comment_deleted_at IS NULL THEN c.comment_content ELSE '' END,
+		c.comment_created_at, c.comment_edited_at, c.comment_deleted_at IS NOT NULL,
+		( SELECT COUNT(*) FROM comments r
+			WHERE r.comment_root_id = c.comment_id AND r.comment_deleted_at IS NULL
+		) AS reply_count,
+		pu.username AS reply_to_username
+	FROM comments c
+	JOIN users u ON u.user_id = c.comment_user_id
+	LEFT JOIN user_profiles up ON up.profile_user_id = u.user_id
+	LEFT JOIN comments pc ON pc.comment_id = c.comment_parent_id
+	LEFT JOIN users pu ON pu.user_id = pc.comment_user_id
+`
+
+func (r *commentRepository) Create(c *models.Comment) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	query := `INSERT INTO comments (comment_post_id, comment_user_id, comment_parent_id, comment_root_id, comment_content)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING comment_id`
+	if err := tx.QueryRow(query, c.PostID, c.UserID, c.ParentID, c.RootID, c.Content).Scan(&id); err != nil {
+		return 0, fmt.Errorf("insert comment: %w", err)
+	}
+	if err := updateCommentCount(tx, c.PostID, true); err != nil {
+		return 0, fmt.Errorf("update comment count: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return id, nil
+}
+
+func (r *commentRepository) GetByID(commentID int) (*models.Comment, error) {
+	c, err := scanComment(r.db.QueryRow(commentSelect+`WHERE c.comment_id = $1`, commentID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// คอมเมนต์ระดับบนสุดเรียงเก่า → ใหม่ ต่อหน้าด้วย comment_id
+// ที่ถูกลบแล้วแสดงเฉพาะเมื่อยังมีคนตอบอยู่ (เธรดไม่ขาด)
+func (r *commentRepository) ListTopLevel(postID, afterID, limit int) ([]models.Comment, error) {
+	query := commentSelect + `
+	WHERE c.comment_post_id = $1
+	  AND c.comment_root_id IS NULL
+	  AND c.comment_id > $2
+	  AND ( c.comment_deleted_at IS NULL
+		OR EXISTS (
+			SELECT 1 FROM comments r
+			WHERE r.comment_root_id = c.comment_id AND r.comment_deleted_at IS NULL
+		)
+	  )
+	ORDER BY c.comment_id
+	LIMIT $3`
+	return r.list(query, postID, afterID, limit)
+}
+
+// คำตอบทั้งเธรด (ทุกชั้น) เรียงตามเวลา
+func (r *commentRepository) ListReplies(rootID, afterID, limit int) ([]models.Comment, error) {
+	query := commentSelect + `
+	WHERE c.comment_root_id = $1
+	  AND c.comment_id > $2
+	  AND c.comment_deleted_at IS NULL
+	ORDER BY c.comment_id
+	LIMIT $3`
+	return r.list(query, rootID, afterID, limit)
+}
+
+func (r *commentRepository) list(query string, args ...any) ([]models.Comment, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	return out, rows.Err()
+}
+
+func (r *commentRepository) UpdateContent(commentID int, content string) error {
+	query := `UPDATE comments SET comment_content = $2, comment_edited_at = now()
+			  WHERE comment_id = $1 AND comment_deleted_at IS NULL`
+	_, err := r.db.Exec(query, commentID, content)
+	return err
+}
+
+func (r *commentRepository) SoftDelete(commentID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var postID int
+	query := `UPDATE comments SET comment_deleted_at = now(), comment_content = ''
+			  WHERE comment_id = $1 AND comment_deleted_at IS NULL
+			  RETURNING comment_post_id`
+	if err := tx.QueryRow(query, commentID).Scan(&postID); err != nil {
+		if err == sql.ErrNoRows {
+			// ลบไปแล้ว
+			return nil
+		}
+		return fmt.Errorf("delete comment: %w", err)
+	}
+	if err := updateCommentCount(tx, postID, false); err != nil {
+		return fmt.Errorf("update comment count: %w", err)
+	}
+	return tx.Commit()
+}
+
+// นับใหม่จากตาราง (เหมือน UpdateLikeCount) trigger ใน post_stats คิด trending ให้
+func updateCommentCount(tx *sql.Tx, postID int, activity bool) error {
+	query := `
+		INSERT INTO post_stats (post_stats_post_id, post_comment_count, post_last_activity_at)
+		VALUES (
+			$1,
+			(SELECT COUNT(*) FROM comments WHERE comment_post_id = $1 AND comment_deleted_at IS NULL),
+			NOW()
+		)
+		ON CONFLICT (post_stats_post_id)
+		DO UPDATE SET
+			post_comment_count    = EXCLUDED.post_comment_count,
+			post_last_activity_at = CASE WHEN $2 THEN EXCLUDED.post_last_activity_at
+				ELSE post_stats.post_last_activity_at END;
+	`
+	_, err := tx.Exec(query, postID, activity)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanComment(row scanner) (*models.Comment, error) {
+	var c models.Comment
+	var parentID, rootID sql.NullInt64
+	var avatar, replyTo sql.NullString
+	var editedAt sql.NullTime
+	if err := row.Scan(
+		&c.CommentID, &c.PostID, &c.UserID, &c.Username, &avatar,
+		&parentID, &rootID,
+		&c.Content,
+		&c.CreatedAt, &editedAt, &c.IsDeleted,
+		&c.ReplyCount,
+		&replyTo,
+	); err != nil {
+		return nil, err
+	}
+	if avatar.Valid {
+		c.AvatarURL = &avatar.String
+	}
+	if parentID.Valid {
+		v := int(parentID.Int64)
+		c.ParentID = &v
+	}
+	if rootID.Valid {
+		v := int(rootID.Int64)
+		c.RootID = &v
+	}
+	if editedAt.Valid {
+		c.EditedAt = &editedAt.Time
+	}
+	if replyTo.Valid {
+		c.ReplyToUsername = &replyTo.String
+	}
+	return &c, nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"chaladshare_backend/internal/comments/models"
+	"chaladshare_backend/internal/comments/repository"
+	"chaladshare_backend/internal/imaging"
+
+	notimodels "chaladshare_backend/internal/notifications/models"
+	notiservice "chaladshare_backend/internal/notifications/service"
+	postservice "chaladshare_backend/internal/posts/service"
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrForbidden  = errors.New("forbidden")
+	ErrNotFound   = errors.New("not found")
+)
+
+const (
+	maxCommentRunes = 2000
+	defaultLimit    = 20
+	maxLimit        = 100
+)
+
+type CommentService interface {
+	ListComments(viewerID, postID, afterID, limit int) ([]models.Comment, string, error)
+	ListReplies(viewerID, postID, commentID, afterID, limit int) ([]models.Comment, string, error)
+	CreateComment(viewerID, postID int, req models.CreateCommentRequest) (*models.Comment, error)
+	UpdateComment(viewerID, postID, commentID int, content string) (*models.Comment, error)
+	DeleteComment(viewerID, postID, commentID int) error
+}
+
+type commentService struct {
+	repo     repository.CommentRepository
+	postSvc  postservice.PostService
+	notifier notiservice.Notifier
+}
+
+func NewCommentService(repo repository.CommentRepository, postSvc postservice.PostService, notifier notiservice.Notifier) CommentService {
+	return &commentService{repo: repo, postSvc: postSvc, notifier: notifier}
+}
+
+// สิทธิ์เห็นคอมเมนต์ = สิทธิ์เห็นโพสต์ (ViewPost) คืนว่าเป็นเจ้าของโพสต์ไหม (ลบคอมเมนต์ได้ทุกอัน)
+func (s *commentService) access(viewerID, postID int) (bool, error) {
+	if viewerID <= 0 || postID <= 0 {
+		return false, ErrBadRequest
+	}
+	ok, reason, err := s.postSvc.ViewPost(viewerID, postID)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		if reason == "not_found" {
+			return false, ErrNotFound
+		}
+		return false, ErrForbidden
+	}
+	return reason == "owner", nil
+}
+
+func (s *commentService) ListComments(viewerID, postID, afterID, limit int) ([]models.Comment, string, error) {
+	owner, err := s.access(viewerID, postID)
+	if err != nil {
+		return nil, "", err
+	}
+	limit = clampLimit(limit)
+	items, err := s.repo.ListTopLevel(postID, max(afterID, 0), limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	items, next := page(items, limit)
+	s.decorate(items, viewerID, owner)
+	return items, next, nil
+}
+
+func (s *commentService) ListReplies(viewerID, postID, commentID, afterID, limit int) ([]models.Comment, string, error) {
+	owner, err := s.access(viewerID, postID)
+	if err != nil {
+		return nil, "", err
+	}
+	root, err := s.getInPost(postID, commentID)
+	if err != nil {
+		return nil, "", err
+	}
+	// ส่ง id ของคำตอบมาก็ได้ → ใช้เธรดของมัน
+	rootID := root.CommentID
+	if root.RootID != nil {
+		rootID = *root.RootID
+	}
+	limit = clampLimit(limit)
+	items, err := s.repo.ListReplies(rootID, max(afterID, 0), limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	items, next := page(items, limit)
+	s.decorate(items, viewerID, owner)
+	return items, next, nil
+}
+
+// ดึงเกินมา 1 แถวไว้ดูว่ามีหน้าถัดไปไหม cursor = comment_id ตัวสุดท้าย
+func page(items []models.Comment, limit int) ([]models.Comment, string) {
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, strconv.Itoa(items[limit-1].CommentID)
+}
+
+func (s *commentService) CreateComment(viewerID, postID int, req models.CreateCommentRequest) (*models.Comment, error) {
+	owner, err := s.access(viewerID, postID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := cleanContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &models.Comment{PostID: postID, UserID: viewerID, Content: content}
+	var parent *models.Comment
+	if req.ParentID != nil {
+		parent, err = s.getInPost(postID, *req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.IsDeleted {
+			return nil, ErrBadRequest
+		}
+		// ตอบคำตอบ → อยู่เธรดเดียวกับ root
+		rootID := parent.CommentID
+		if parent.RootID != nil {
+			rootID = *parent.RootID
+		}
+		c.ParentID = &parent.CommentID
+		c.RootID = &rootID
+	}
+
+	id, err := s.repo.Create(c)
+	if err != nil {
+		return nil, err
+	}
+	s.notify(id, postID, viewerID, parent)
+	return s.get(id, viewerID, owner)
+}
+
+// แก้ได้เฉพาะเจ้าของคอมเมนต์
+func (s *commentService) UpdateComment(viewerID, postID, commentID int, content string) (*models.Comment, error) {
+	owner, err := s.access(viewerID, postID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.getInPost(postID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsDeleted {
+		return nil, ErrNotFound
+	}
+	if c.UserID != viewerID {
+		return nil, ErrForbidden
+	}
+	content, err = cleanContent(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateContent(commentID, content); err != nil {
+		return nil, err
+	}
+	return s.get(commentID, viewerID, owner)
+}
+
+// ลบได้ทั้งเจ้าของคอมเมนต์ และเจ้าของโพสต์ (ดูแลคอมเมนต์ใต้โพสต์ตัวเอง)
+func (s *commentService) DeleteComment(viewerID, postID, commentID int) error {
+	owner, err := s.access(viewerID, postID)
+	if err != nil {
+		return err
+	}
+	c, err := s.getInPost(postID, commentID)
+	if err != nil {
+		return err
+	}
+	if c.UserID != viewerID && !owner {
+		return ErrForbidden
+	}
+	return s.repo.SoftDelete(commentID)
+}
+
+// ตอบกลับ → แจ้งเจ้าของคอมเมนต์ที่ตอบ / เจ้าของโพสต์ได้แจ้งเสมอ (ถ้าไม่ใช่คนเดียวกัน)
+func (s *commentService) notify(commentID, postID, actorID int, parent *models.Comment) {
+	if parent != nil {
+		s.notifier.Notify(notimodels.Event{
+			Type: notimodels.TypeCommentReply, UserID: parent.UserID, ActorID: actorID,
+			PostID: postID, CommentID: commentID,
+		})
+	}
+	ownerNotified := parent != nil && s.isPostOwner(postID, parent.UserID)
+	if !ownerNotified {
+		s.notifier.Notify(notimodels.Event{
+			Type: notimodels.TypePostComment, ActorID: actorID, PostID: postID, CommentID: commentID,
+		})
+	}
+}
+
+func (s *commentService) isPostOwner(postID, userID int) bool {
+	ok, err := s.postSvc.IsOwner(postID, userID)
+	return err == nil && ok
+}
+
+func (s *commentService) getInPost(postID, commentID int) (*models.Comment, error) {
+	if commentID <= 0 {
+		return nil, ErrBadRequest
+	}
+	c, err := s.repo.GetByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.PostID != postID {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *commentService) get(commentID, viewerID int, owner bool) (*models.Comment, error) {
+	c, err := s.repo.GetByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrNotFound
+	}
+	items := []models.Comment{*c}
+	s.decorate(items, viewerID, owner)
+	return &items[0], nil
+}
+
+func (s *commentService) decorate(items []models.Comment, viewerID int, owner bool) {
+	for i := range items {
+		c := &items[i]
+		if c.AvatarURL != nil {
+			c.AvatarSrcSet = imaging.SrcSet(*c.AvatarURL, imaging.AvatarVariants)
+		}
+		if c.IsDeleted {
+			continue
+		}
+		c.CanEdit = c.UserID == viewerID
+		c.CanDelete = c.UserID == viewerID || owner
+	}
+}
+
+func cleanContent(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" || utf8.RuneCountInString(v) > maxCommentRunes {
+		return "", ErrBadRequest
+	}
+	return v, nil
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultLimit
+	}
+	return min(limit, maxLimit)
+}
@@ -22,6 +22,45 @@ type Config struct {
 	TokenTTLMinutes int
 	CookieName      string
 	AllowOrigin     string
+
+	// document_jobs worker
+	JobWorkers      int
+	JobPollSeconds  int
+	JobLeaseMinutes int
+	JobMaxAttempts  int
+
+	// storage: local | supabase | s3
+	StorageProvider  string
+	UploadDir        string
+	LocalStorageBase string
+
+	// ขนาดไฟล์อัปโหลดสูงสุด (MB) เกิน = 413
+	MaxDocumentMB int
+	MaxImageMB    int
+
+	// วาดหน้าแรกของ PDF เป็นหน้าปก: auto | none | path ของ pdftoppm/mutool
+	PDFRenderer string
+
+	// แปลง DOCX/PPTX เป็น PDF: auto | none | path ของ soffice
+	ConvertOffice string
+
+	// session อัปโหลดแบบแบ่งก้อนที่ค้างเกินนี้ถูกลบ
+	UploadSessionTTLHours int
+
+	// เอกสาร private: ส่ง signed URL อายุสั้นแทน public URL
+	StorageDocumentBucket   string
+	StoragePrivateDocuments bool
+	SignedURLTTLSeconds     int
+	// ส่งลิงก์ /api/v1/files/:id/content (ผ่าน backend) แทน URL ของ storage
+	StorageProxyDocuments bool
+
+	// คะแนน trending: คิดใหม่ทุกกี่นาที / โพสต์เก่ากว่ากี่วันไม่ติด trending
+	TrendingRefreshMinutes int
+	TrendingWindowDays     int
+
+	// refresh token (หมุนทุกครั้งที่ใช้) อายุกี่วัน / ชื่อ cookie
+	RefreshTTLDays    int
+	RefreshCookieName string
 }
 
 func LoadConfig() (Config, error) {
@@ -42,10 +81,32 @@ func LoadConfig() (Config, error) {
 	viper.SetDefault("APP.PORT", "8080")
 
 	// ADD THIS PART
-	viper.SetDefault("JWT.TTL_MINUTES", 30)
+	viper.SetDefault("JWT.TTL_MINUTES", 15)
 	viper.SetDefault("COOKIE.NAME", "access_token")
 	viper.SetDefault("ALLOW.ORIGIN", "http://localhost:3000")
 
+	viper.SetDefault("JOB.WORKERS", 2)
+	viper.SetDefault("JOB.POLL_SECONDS", 5)
+	viper.SetDefault("JOB.LEASE_MINUTES", 15)
+	viper.SetDefault("JOB.MAX_ATTEMPTS", 3)
+
+	viper.SetDefault("STORAGE.PROVIDER", "supabase")
+	viper.SetDefault("UPLOAD.DIR", "/tmp/uploads")
+	viper.SetDefault("LOCAL.STORAGE_BASE_URL", "/uploads")
+	viper.SetDefault("UPLOAD.MAX_DOCUMENT_MB", 50)
+	viper.SetDefault("UPLOAD.MAX_IMAGE_MB", 5)
+	viper.SetDefault("UPLOAD.SESSION_TTL_HOURS", 24)
+	viper.SetDefault("PDF.RENDERER", "auto")
+	viper.SetDefault("CONVERT.OFFICE", "auto")
+	viper.SetDefault("STORAGE.DOCUMENT_BUCKET", "")
+	viper.SetDefault("STORAGE.PRIVATE_DOCUMENTS", false)
+	viper.SetDefault("STORAGE.SIGNED_URL_TTL_SECONDS", 900)
+	viper.SetDefault("STORAGE.PROXY_DOCUMENTS", false)
+	viper.SetDefault("TRENDING.REFRESH_MINUTES", 10)
+	viper.SetDefault("TRENDING.WINDOW_DAYS", 14)
+	viper.SetDefault("JWT.REFRESH_TTL_DAYS", 30)
+	viper.SetDefault("COOKIE.REFRESH_NAME", "refresh_token")
+
 	// Set config values
 	config := Config{
 		AppPort:          viper.GetString("APP.PORT"),
@@ -61,6 +122,33 @@ func LoadConfig() (Config, error) {
 		TokenTTLMinutes: viper.GetInt("JWT.TTL_MINUTES"),
 		CookieName:      viper.GetString("COOKIE.NAME"),
 		AllowOrigin:     viper.GetString("ALLOW.ORIGIN"),
+
+		JobWorkers:      viper.GetInt("JOB.WORKERS"),
+		JobPollSeconds:  viper.GetInt("JOB.POLL_SECONDS"),
+		JobLeaseMinutes: viper.GetInt("JOB.LEASE_MINUTES"),
+		JobMaxAttempts:  viper.GetInt("JOB.MAX_ATTEMPTS"),
+
+		StorageProvider:  viper.GetString("STORAGE.PROVIDER"),
+		UploadDir:        viper.GetString("UPLOAD.DIR"),
+		LocalStorageBase: viper.GetString("LOCAL.STORAGE_BASE_URL"),
+		MaxDocumentMB:    viper.GetInt("UPLOAD.MAX_DOCUMENT_MB"),
+		MaxImageMB:       viper.GetInt("UPLOAD.MAX_IMAGE_MB"),
+		PDFRenderer:      viper.GetString("PDF.RENDERER"),
+
+		ConvertOffice: viper.GetString("CONVERT.OFFICE"),
+
+		UploadSessionTTLHours: viper.GetInt("UPLOAD.SESSION_TTL_HOURS"),
+
+		StorageDocumentBucket:   viper.GetString("STORAGE.DOCUMENT_BUCKET"),
+		StoragePrivateDocuments: viper.GetBool("STORAGE.PRIVATE_DOCUMENTS"),
+		SignedURLTTLSeconds:     viper.GetInt("STORAGE.SIGNED_URL_TTL_SECONDS"),
+		StorageProxyDocuments:   viper.GetBool("STORAGE.PROXY_DOCUMENTS"),
+
+		TrendingRefreshMinutes: viper.GetInt("TRENDING.REFRESH_MINUTES"),
+		TrendingWindowDays:     viper.GetInt("TRENDING.WINDOW_DAYS"),
+
+		RefreshTTLDays:    viper.GetInt("JWT.REFRESH_TTL_DAYS"),
+		RefreshCookieName: viper.GetString("COOKIE.REFRESH_NAME"),
 	}
 
 	return config, nil
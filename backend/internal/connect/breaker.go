@@ -0,0 +1,258 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("ai backend unavailable (circuit open)")
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+type BreakerConfig struct {
+	Threshold     int           // fail ติดกันกี่ครั้งถึงเปิด
+	Cooldown      time.Duration // เปิดนานเท่าไรก่อนยอมให้ลอง 1 request (half-open)
+	ProbeInterval time.Duration
+	ProbeTimeout  time.Duration
+	HealthPath    string
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Threshold:     5,
+		Cooldown:      2 * time.Minute,
+		ProbeInterval: 30 * time.Second,
+		ProbeTimeout:  5 * time.Second,
+		HealthPath:    "/health",
+	}
+}
+
+// สถานะสำหรับ /health ของ backend
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	LastProbeAt         *time.Time `json:"last_probe_at,omitempty"`
+	LastProbeOK         bool       `json:"last_probe_ok"`
+}
+
+type Breaker struct {
+	cfg BreakerConfig
+
+	mu          sync.Mutex
+	state       string
+	failures    int
+	lastErr     string
+	openedAt    time.Time
+	trialActive bool
+	lastProbeAt time.Time
+	lastProbeOK bool
+}
+
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 1
+	}
+	return &Breaker{cfg: cfg, state: BreakerClosed}
+}
+
+// Allow = ยิง request ได้ไหม (half-open ให้ผ่านทีละ 1)
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if b.cfg.Cooldown > 0 && time.Since(b.openedAt) >= b.cfg.Cooldown {
+			b.state = BreakerHalfOpen
+			b.trialActive = true
+			return true
+		}
+		return false
+	default: // half-open
+		if b.trialActive {
+			return false
+		}
+		b.trialActive = true
+		return true
+	}
+}
+
+func (b *Breaker) Available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state != BreakerOpen || (b.cfg.Cooldown > 0 && time.Since(b.openedAt) >= b.cfg.Cooldown)
+}
+
+// นับเฉพาะ error ที่แปลว่า backend ไม่พร้อม (network/5xx/429)
+// 4xx หรือ decode error แปลว่า colab ยังตอบอยู่ ถือว่า backend ปกติ
+// ถูกยกเลิก (context.Canceled) ไม่นับทั้งสองทาง
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialActive = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err == nil || !IsRetryable(err) {
+		if b.state != BreakerClosed {
+			log.Printf("[COLAB][BREAKER] closed")
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+		if err == nil {
+			b.lastErr = ""
+		}
+		return
+	}
+
+	b.failures++
+	b.lastErr = err.Error()
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.Threshold {
+		if b.state != BreakerOpen {
+			log.Printf("[COLAB][BREAKER] open after %d failure(s): %v", b.failures, err)
+		}
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// request ไม่ได้ผล (ถูกยกเลิกก่อน) → คืนสิทธิ์ลองของ half-open โดยไม่เปลี่ยนสถานะ
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	b.trialActive = false
+	b.mu.Unlock()
+}
+
+func (b *Breaker) recordProbe(ok bool, err error) {
+	b.mu.Lock()
+	b.lastProbeAt = time.Now()
+	b.lastProbeOK = ok
+	if !ok && err != nil {
+		b.lastErr = err.Error()
+	}
+	wasOpen := b.state != BreakerClosed
+	if ok && wasOpen {
+		b.state = BreakerClosed
+		b.failures = 0
+		b.trialActive = false
+	}
+	b.mu.Unlock()
+
+	if ok && wasOpen {
+		log.Printf("[COLAB][BREAKER] closed by health probe")
+	}
+}
+
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st := BreakerStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastErr,
+		LastProbeOK:         b.lastProbeOK,
+	}
+	if b.state != BreakerClosed {
+		t := b.openedAt
+		st.OpenedAt = &t
+	}
+	if !b.lastProbeAt.IsZero() {
+		t := b.lastProbeAt
+		st.LastProbeAt = &t
+	}
+	return st
+}
+
+// ---- client helpers ----
+
+func (c *Client) Available() bool {
+	if c == nil {
+		return false
+	}
+	if c.Breaker == nil {
+		return true
+	}
+	return c.Breaker.Available()
+}
+
+func (c *Client) BreakerStatus() BreakerStatus {
+	if c.Breaker == nil {
+		return BreakerStatus{State: BreakerClosed}
+	}
+	return c.Breaker.Status()
+}
+
+func (c *Client) Probe(ctx context.Context) error {
+	path := "/health"
+	timeout := 5 * time.Second
+	if c.Breaker != nil {
+		if c.Breaker.cfg.HealthPath != "" {
+			path = c.Breaker.cfg.HealthPath
+		}
+		if c.Breaker.cfg.ProbeTimeout > 0 {
+			timeout = c.Breaker.cfg.ProbeTimeout
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("ngrok-skip-browser-warning", "true")
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("health status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// probe /health ของ AI service เป็นระยะ ปิด breaker ได้เมื่อกลับมา healthy
+func (c *Client) StartHealthProbe(ctx context.Context) {
+	if c == nil || c.Breaker == nil || c.Breaker.cfg.ProbeInterval <= 0 {
+		return
+	}
+
+	go func() {
+		t := time.NewTicker(c.Breaker.cfg.ProbeInterval)
+		defer t.Stop()
+		for {
+			err := c.Probe(ctx)
+			c.Breaker.recordProbe(err == nil, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
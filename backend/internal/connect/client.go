@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -21,6 +23,13 @@ type Client struct {
 	// timeout แยกตามงาน
 	ExtractTimeout   time.Duration
 	SummarizeTimeout time.Duration
+	EmbedTimeout     time.Duration
+
+	// ลองซ้ำเมื่อ ngrok/colab ล่มชั่วคราว
+	Retry RetryPolicy
+
+	// nil = ไม่ใช้ circuit breaker
+	Breaker *Breaker
 }
 
 func NewFromEnv() (*Client, error) {
@@ -31,24 +40,81 @@ func NewFromEnv() (*Client, error) {
 
 	key := os.Getenv("COLAB_API_KEY")
 
+	retry := DefaultRetryPolicy()
+	if n := envInt("COLAB_RETRY_MAX_ATTEMPTS"); n > 0 {
+		retry.MaxAttempts = n
+	}
+	if n := envInt("COLAB_RETRY_BASE_SECONDS"); n > 0 {
+		retry.BaseDelay = time.Duration(n) * time.Second
+	}
+	if n := envInt("COLAB_RETRY_MAX_SECONDS"); n > 0 {
+		retry.MaxDelay = time.Duration(n) * time.Second
+	}
+
+	bcfg := DefaultBreakerConfig()
+	if n := envInt("COLAB_BREAKER_THRESHOLD"); n > 0 {
+		bcfg.Threshold = n
+	}
+	if n := envInt("COLAB_BREAKER_COOLDOWN_SECONDS"); n > 0 {
+		bcfg.Cooldown = time.Duration(n) * time.Second
+	}
+	if n := envInt("COLAB_HEALTH_INTERVAL_SECONDS"); n > 0 {
+		bcfg.ProbeInterval = time.Duration(n) * time.Second
+	}
+	if p := strings.TrimSpace(os.Getenv("COLAB_HEALTH_PATH")); p != "" {
+		bcfg.HealthPath = p
+	}
+
 	return &Client{
 		BaseURL:          base,
 		APIKey:           key,
 		HTTP:             &http.Client{},
 		ExtractTimeout:   180 * time.Second, // เท่าของเดิม
 		SummarizeTimeout: 10 * time.Minute,  // summarize นานกว่า
+		EmbedTimeout:     15 * time.Second,
+		Retry:            retry,
+		Breaker:          NewBreaker(bcfg),
 	}, nil
 }
 
-func (c *Client) postPDF(ctx context.Context, endpoint string, documentID int, pdfPath string) (*http.Response, error) {
+func envInt(key string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(key)))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// ไฟล์ที่ส่งให้ AI backend (แปลงจากไฟล์ต้นฉบับแล้ว)
+type Upload struct {
+	Path         string
+	MIME         string // ชนิดของไฟล์ที่ส่ง ("" = application/pdf)
+	OriginalMIME string // ชนิดไฟล์ที่ผู้ใช้อัป (docx, pptx, รูป, markdown ...)
+}
+
+func (c *Client) postFile(ctx context.Context, endpoint string, documentID int, file Upload) (*http.Response, error) {
 	url := c.BaseURL + endpoint
 
-	f, err := os.Open(pdfPath)
+	f, err := os.Open(file.Path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
+	mimeType := file.MIME
+	if mimeType == "" {
+		mimeType = "application/pdf"
+	}
+	original := file.OriginalMIME
+	if original == "" {
+		original = mimeType
+	}
+	// PDF ใช้ field "pdf" เหมือนเดิม (colab รุ่นเก่ารับแค่นี้) ชนิดอื่นใช้ "file"
+	field := "file"
+	if mimeType == "application/pdf" {
+		field = "pdf"
+	}
+
 	pr, pw := io.Pipe()
 	w := multipart.NewWriter(pw)
 
@@ -58,9 +124,17 @@ func (c *Client) postPDF(ctx context.Context, endpoint string, documentID int, p
 		defer w.Close()
 
 		_ = w.WriteField("document_id", strconv.Itoa(documentID))
-		_ = w.WriteField("file_name", filepath.Base(pdfPath))
-
-		fw, err := w.CreateFormFile("pdf", filepath.Base(pdfPath))
+		_ = w.WriteField("file_name", filepath.Base(file.Path))
+		_ = w.WriteField("mime_type", mimeType)
+		_ = w.WriteField("original_mime_type", original)
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+			"name":     field,
+			"filename": filepath.Base(file.Path),
+		}))
+		h.Set("Content-Type", mimeType)
+		fw, err := w.CreatePart(h)
 		if err != nil {
 			_ = pw.CloseWithError(err)
 			return
@@ -0,0 +1,75 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type EmbedResp struct {
+	Embedding    []float64 `json:"embedding"`
+	EmbeddingAlt []float64 `json:"content_embedding,omitempty"`
+}
+
+// Embed แปลงข้อความ (เช่นคำค้น) เป็น vector เดียวกับ content_embedding ของเอกสาร
+// ผู้ใช้รอผลอยู่ → ยิงครั้งเดียวไม่ retry แต่ยังเคารพ circuit breaker
+func (c *Client) Embed(ctx context.Context, text string) ([]float64, error) {
+	if c.Breaker != nil && !c.Breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return nil, err
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, c.EmbedTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, "POST", c.BaseURL+"/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ngrok-skip-browser-warning", "true")
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+
+	out, err := c.doEmbed(req)
+	if c.Breaker != nil {
+		// client หลุด / request ของเราหมดเวลาเอง ไม่ได้บอกอะไรเรื่อง colab
+		if ctx.Err() != nil {
+			c.Breaker.Release()
+		} else {
+			c.Breaker.Record(err)
+		}
+	}
+	return out, err
+}
+
+func (c *Client) doEmbed(req *http.Request) ([]float64, error) {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return nil, statusErrorFromResponse("embed", resp)
+	}
+
+	var out EmbedResp
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	if len(out.Embedding) == 0 {
+		out.Embedding = out.EmbeddingAlt
+	}
+	if len(out.Embedding) == 0 {
+		return nil, fmt.Errorf("empty embedding from ai")
+	}
+	return out.Embedding, nil
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"time"
 )
@@ -19,33 +18,35 @@ type ExtractResp struct {
 	Embedding          []float64 `json:"content_embedding"`
 	EmbeddingAlt       []float64 `json:"embedding,omitempty"`
 	ClusterID          *int      `json:"cluster_id,omitempty"`
+
+	Attempts int `json:"-"`
 }
 
-func (c *Client) ExtractFeatures(documentID int, pdfPath string) (*ExtractResp, error) {
+func (c *Client) ExtractFeatures(documentID int, file Upload) (*ExtractResp, error) {
 	start := time.Now()
 
-	//context timeout
-	ctx, cancel := context.WithTimeout(context.Background(), c.ExtractTimeout)
-	defer cancel()
-
-	//ส่งไฟล์ผ่าน helper ใน client.go
-	resp, err := c.postPDF(ctx, "/extract_features", documentID, pdfPath)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	var out ExtractResp
+	attempts, err := c.withRetry("EXTRACT", c.ExtractTimeout, func(ctx context.Context) error {
+		//ส่งไฟล์ผ่าน helper ใน client.go
+		resp, err := c.postFile(ctx, "/extract_features", documentID, file)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
 
-	//เช็ค status code
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("extract status %d: %s", resp.StatusCode, string(b))
-	}
+		//เช็ค status code
+		if resp.StatusCode != 200 && resp.StatusCode != 201 {
+			return statusErrorFromResponse("extract", resp)
+		}
 
-	//decode JSON
-	var out ExtractResp
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		//decode JSON
+		out = ExtractResp{}
+		return json.NewDecoder(resp.Body).Decode(&out)
+	})
+	if err != nil {
 		return nil, err
 	}
+	out.Attempts = attempts
 
 	if len(out.Embedding) == 0 && len(out.EmbeddingAlt) > 0 {
 		out.Embedding = out.EmbeddingAlt
@@ -62,11 +63,11 @@ func (c *Client) ExtractFeatures(documentID int, pdfPath string) (*ExtractResp,
 	out.StyleVectorV16 = vec
 
 	if len(out.StyleVectorV16) != 16 {
-		return nil, fmt.Errorf("invalid style vector v16 len=%d (want 16)", len(out.StyleVectorV16))
+		return nil, &AttemptsError{Attempts: attempts, Err: fmt.Errorf("invalid style vector v16 len=%d (want 16)", len(out.StyleVectorV16))}
 	}
 
-	log.Printf("[COLAB][EXTRACT] OK time=%s doc=%d label=%v vec_len=%d",
-		time.Since(start), out.DocumentID, out.StyleLabel, len(out.StyleVectorV16))
+	log.Printf("[COLAB][EXTRACT] OK time=%s doc=%d label=%v vec_len=%d attempts=%d",
+		time.Since(start), out.DocumentID, out.StyleLabel, len(out.StyleVectorV16), out.Attempts)
 
 	return &out, nil
 }
@@ -0,0 +1,184 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    60 * time.Second,
+	}
+}
+
+// colab ตอบกลับด้วย status ที่ไม่ใช่ 2xx
+type StatusError struct {
+	Op         string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s status %d: %s", e.Op, e.StatusCode, e.Body)
+}
+
+// error สุดท้ายหลังลองครบ (หรือเจอ error ที่ไม่ควรลองซ้ำ)
+type AttemptsError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *AttemptsError) Error() string {
+	return fmt.Sprintf("%v (after %d attempt(s))", e.Err, e.Attempts)
+}
+
+func (e *AttemptsError) Unwrap() error { return e.Err }
+
+// จำนวนครั้งที่ลองไปแล้ว (0 ถ้า err ไม่ได้มาจาก retry loop)
+func Attempts(err error) int {
+	var ae *AttemptsError
+	if errors.As(err, &ae) {
+		return ae.Attempts
+	}
+	return 0
+}
+
+// ลองซ้ำเฉพาะ network error, 5xx, 408, 429
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode >= 500 ||
+			se.StatusCode == http.StatusTooManyRequests ||
+			se.StatusCode == http.StatusRequestTimeout
+	}
+
+	// timeout ของแต่ละ attempt (cold start) ถือว่าชั่วคราว
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connection reset") ||
+		strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "broken pipe")
+}
+
+// Retry-After เป็นได้ทั้งวินาที หรือ HTTP-date
+func parseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// exponential backoff + full jitter
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// เรียก do ซ้ำตาม policy โดยแต่ละรอบมี timeout ของตัวเอง
+func (c *Client) withRetry(op string, timeout time.Duration, do func(ctx context.Context) error) (int, error) {
+	p := c.Retry
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= p.MaxAttempts; attempt++ {
+		// colab หลับอยู่ → ไม่ต้องรอ timeout ทีละ 180s
+		if c.Breaker != nil && !c.Breaker.Allow() {
+			if lastErr == nil {
+				lastErr = ErrCircuitOpen
+			} else {
+				lastErr = fmt.Errorf("%w: %v", ErrCircuitOpen, lastErr)
+			}
+			return attempt - 1, &AttemptsError{Attempts: attempt - 1, Err: lastErr}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := do(ctx)
+		cancel()
+
+		if c.Breaker != nil {
+			c.Breaker.Record(err)
+		}
+
+		if err == nil {
+			return attempt, nil
+		}
+		lastErr = err
+
+		if !IsRetryable(err) || attempt == p.MaxAttempts {
+			return attempt, &AttemptsError{Attempts: attempt, Err: err}
+		}
+
+		wait := p.backoff(attempt)
+		var se *StatusError
+		if errors.As(err, &se) && se.RetryAfter > 0 {
+			wait = se.RetryAfter
+			if p.MaxDelay > 0 && wait > p.MaxDelay {
+				wait = p.MaxDelay
+			}
+		}
+
+		log.Printf("[COLAB][%s] attempt=%d/%d failed: %v (retry in %s)", op, attempt, p.MaxAttempts, err, wait)
+		time.Sleep(wait)
+	}
+	return p.MaxAttempts, &AttemptsError{Attempts: p.MaxAttempts, Err: lastErr}
+}
+
+func statusErrorFromResponse(op string, resp *http.Response) *StatusError {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	return &StatusError{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Body:       string(b),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
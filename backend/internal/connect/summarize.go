@@ -3,8 +3,6 @@ package connect
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"io"
 	"log"
 	"time"
 )
@@ -14,32 +12,35 @@ type SummarizeResp struct {
 	SummaryText   string `json:"summary_text"`
 	SummaryHTML   string `json:"summary_html,omitempty"`
 	SummaryPDFURL string `json:"summary_pdf_url,omitempty"`
+
+	Attempts int `json:"-"`
 }
 
-func (c *Client) Summarize(documentID int, pdfPath string) (*SummarizeResp, error) {
+func (c *Client) Summarize(documentID int, file Upload) (*SummarizeResp, error) {
 	start := time.Now()
 
-	ctx, cancel := context.WithTimeout(context.Background(), c.SummarizeTimeout)
-	defer cancel()
-
-	resp, err := c.postPDF(ctx, "/summarize", documentID, pdfPath)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("summarize status %d: %s", resp.StatusCode, string(b))
-	}
-
 	var out SummarizeResp
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+	attempts, err := c.withRetry("SUM", c.SummarizeTimeout, func(ctx context.Context) error {
+		resp, err := c.postFile(ctx, "/summarize", documentID, file)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != 200 && resp.StatusCode != 201 {
+			return statusErrorFromResponse("summarize", resp)
+		}
+
+		out = SummarizeResp{}
+		return json.NewDecoder(resp.Body).Decode(&out)
+	})
+	if err != nil {
 		return nil, err
 	}
+	out.Attempts = attempts
 
-	log.Printf("[COLAB][SUM] OK time=%s doc=%d len(summary)=%d",
-		time.Since(start), out.DocumentID, len(out.SummaryText))
+	log.Printf("[COLAB][SUM] OK time=%s doc=%d len(summary)=%d attempts=%d",
+		time.Since(start), out.DocumentID, len(out.SummaryText), out.Attempts)
 
 	return &out, nil
 }
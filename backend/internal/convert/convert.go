@@ -0,0 +1,202 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ชนิดไฟล์ที่รับอัปโหลดได้ (เก็บใน documents.document_mime)
+const (
+	MIMEPDF      = "application/pdf"
+	MIMEDOCX     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MIMEPPTX     = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	MIMEJPEG     = "image/jpeg"
+	MIMEPNG      = "image/png"
+	MIMEWebP     = "image/webp"
+	MIMEMarkdown = "text/markdown"
+	MIMEText     = "text/plain"
+)
+
+var extByMIME = map[string]string{
+	MIMEPDF:      ".pdf",
+	MIMEDOCX:     ".docx",
+	MIMEPPTX:     ".pptx",
+	MIMEJPEG:     ".jpg",
+	MIMEPNG:      ".png",
+	MIMEWebP:     ".webp",
+	MIMEMarkdown: ".md",
+	MIMEText:     ".txt",
+}
+
+// นามสกุลไฟล์ของแต่ละชนิด (ไม่รู้จัก = .pdf เหมือนข้อมูลเก่า)
+func Ext(mimeType string) string {
+	if ext, ok := extByMIME[mimeType]; ok {
+		return ext
+	}
+	return ".pdf"
+}
+
+// ชนิดไฟล์จากนามสกุล ("" = ไม่รู้จัก)
+func MIMEByExt(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch ext {
+	case ".jpeg":
+		return MIMEJPEG
+	case ".markdown":
+		return MIMEMarkdown
+	}
+	for m, e := range extByMIME {
+		if e == ext {
+			return m
+		}
+	}
+	return ""
+}
+
+func IsText(mimeType string) bool {
+	return mimeType == MIMEMarkdown || mimeType == MIMEText
+}
+
+var ErrUnsupported = errors.New("unsupported document type")
+
+// ไฟล์ที่ส่งเข้า AI pipeline หลังแปลงแล้ว
+type Result struct {
+	Path      string
+	MIME      string // application/pdf, text/plain หรือชนิดเดิมถ้าไม่ต้องแปลง
+	Converted bool   // true = Path เป็นไฟล์ที่สร้างใหม่ข้างไฟล์ต้นฉบับ (ลบด้วย Cleanup)
+}
+
+// แปลงไฟล์ที่ไม่ใช่ PDF ก่อนส่งให้ AI:
+// DOCX/PPTX → PDF ผ่าน LibreOffice (ไม่มี = ดึงข้อความ), JPEG/PNG → PDF หน้าเดียว, Markdown/ข้อความ → ส่งตรง
+type Converter struct {
+	office string // path ของ soffice ("" = ไม่มี)
+}
+
+// setting: "auto" = หา soffice/libreoffice จาก PATH, "none"/"" = ปิด, อื่นๆ = path ของ soffice
+func NewConverter(setting string) *Converter {
+	setting = strings.TrimSpace(setting)
+	switch strings.ToLower(setting) {
+	case "", "none", "off":
+		return &Converter{}
+	case "auto":
+		for _, name := range []string{"soffice", "libreoffice"} {
+			if bin, err := exec.LookPath(name); err == nil {
+				return &Converter{office: bin}
+			}
+		}
+		return &Converter{}
+	}
+	if bin, err := exec.LookPath(setting); err == nil {
+		return &Converter{office: bin}
+	}
+	return &Converter{}
+}
+
+func (c *Converter) OfficeAvailable() bool { return c != nil && c.office != "" }
+
+// ผลลัพธ์เขียนไว้ข้างไฟล์ต้นฉบับ ({src}.converted.pdf|txt) งานอื่นที่ใช้ไฟล์เดียวกันใช้ซ้ำได้
+func (c *Converter) Convert(ctx context.Context, src, mimeType string) (Result, error) {
+	switch mimeType {
+	case "", MIMEPDF:
+		return Result{Path: src, MIME: MIMEPDF}, nil
+	case MIMEMarkdown, MIMEText, MIMEWebP:
+		// stdlib ไม่มี codec ของ webp → ส่งรูปเดิมพร้อม MIME ให้ AI จัดการเอง
+		return Result{Path: src, MIME: mimeType}, nil
+	case MIMEJPEG, MIMEPNG:
+		return cached(src, ".pdf", MIMEPDF, func(dst string) error {
+			return imageToPDF(src, dst)
+		})
+	case MIMEDOCX, MIMEPPTX:
+		if c.OfficeAvailable() {
+			return cached(src, ".pdf", MIMEPDF, func(dst string) error {
+				return c.officeToPDF(ctx, src, Ext(mimeType), dst)
+			})
+		}
+		return cached(src, ".txt", MIMEText, func(dst string) error {
+			return officeToText(src, mimeType, dst)
+		})
+	default:
+		return Result{}, fmt.Errorf("%w: %s", ErrUnsupported, mimeType)
+	}
+}
+
+func outputPath(src, ext string) string {
+	return src + ".converted" + ext
+}
+
+// lock ต่อไฟล์ผลลัพธ์: งานของเอกสารเดียวกัน (features/summary/text/cover) แปลงพร้อมกันได้
+// คนแรกแปลง คนที่เหลือรอแล้วใช้ผลเดิม
+var (
+	outLocksMu sync.Mutex
+	outLocks   = map[string]*outLock{}
+)
+
+type outLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func lockOutput(out string) func() {
+	outLocksMu.Lock()
+	l := outLocks[out]
+	if l == nil {
+		l = &outLock{}
+		outLocks[out] = l
+	}
+	l.refs++
+	outLocksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		outLocksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(outLocks, out)
+		}
+		outLocksMu.Unlock()
+	}
+}
+
+// แปลงครั้งเดียวต่อไฟล์: เขียนลง temp ชื่อไม่ซ้ำแล้ว rename กันงานอื่น (รวมต่าง process) เห็นไฟล์ครึ่งๆ
+func cached(src, ext, mimeType string, convert func(dst string) error) (Result, error) {
+	out := outputPath(src, ext)
+	if fi, err := os.Stat(out); err == nil && fi.Size() > 0 {
+		return Result{Path: out, MIME: mimeType, Converted: true}, nil
+	}
+
+	unlock := lockOutput(out)
+	defer unlock()
+	// อีกงานแปลงเสร็จระหว่างรอ lock
+	if fi, err := os.Stat(out); err == nil && fi.Size() > 0 {
+		return Result{Path: out, MIME: mimeType, Converted: true}, nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(out), filepath.Base(out)+".*.tmp")
+	if err != nil {
+		return Result{}, err
+	}
+	tmp := f.Name()
+	f.Close()
+	if err := convert(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return Result{}, err
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		_ = os.Remove(tmp)
+		return Result{}, err
+	}
+	return Result{Path: out, MIME: mimeType, Converted: true}, nil
+}
+
+// ลบไฟล์ที่แปลงจาก src (เรียกตอนลบไฟล์ต้นฉบับ)
+func Cleanup(src string) {
+	for _, ext := range []string{".pdf", ".txt"} {
+		_ = os.Remove(outputPath(src, ext))
+	}
+}
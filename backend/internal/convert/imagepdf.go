@@ -0,0 +1,67 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"chaladshare_backend/internal/imaging"
+)
+
+const (
+	// ย่อรูปก่อนใส่ PDF (พอสำหรับอ่านลายมือ / OCR)
+	pageMaxSide = 2480
+	// ด้านยาวของหน้า (point) ≈ A4
+	pageLongSide = 842.0
+)
+
+// รูปถ่ายโน้ต → PDF หน้าเดียว (หมุนตาม EXIF แล้วฝัง JPEG ตรงๆ ด้วย DCTDecode)
+func imageToPDF(src, dst string) error {
+	outs, err := imaging.Process(src, []imaging.Variant{{Name: "page", MaxSide: pageMaxSide}}, false, filepath.Dir(dst))
+	if err != nil {
+		return err
+	}
+	defer imaging.Cleanup(outs)
+
+	img := outs[0]
+	data, err := os.ReadFile(img.Path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, jpegPDF(data, img.Width, img.Height), 0644)
+}
+
+func jpegPDF(jpg []byte, w, h int) []byte {
+	scale := pageLongSide / float64(max(w, h))
+	pw, ph := float64(w)*scale, float64(h)*scale
+	content := fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q\n", pw, ph)
+
+	var buf bytes.Buffer
+	offsets := make([]int, 0, 5)
+	obj := func(body string, stream []byte) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			buf.WriteString("stream\n")
+			buf.Write(stream)
+			buf.WriteString("\nendstream\n")
+		}
+		buf.WriteString("endobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	obj("<< /Type /Pages /Kids [3 0 R] /Count 1 >>", nil)
+	obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /XObject << /Im0 4 0 R >> >> /Contents 5 0 R >>", pw, ph), nil)
+	obj(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>", w, h, len(jpg)), jpg)
+	obj(fmt.Sprintf("<< /Length %d >>", len(content)), []byte(content))
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,180 @@
+package convert
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	officeTimeout = 2 * time.Minute
+	// กัน zip bomb: อ่าน xml แต่ละไฟล์ไม่เกินนี้
+	maxPartBytes = 32 << 20
+)
+
+var slideRe = regexp.MustCompile(`^ppt/slides/slide(\d+)\.xml$`)
+
+// soffice --convert-to pdf (แต่ละครั้งใช้ profile แยก รันพร้อมกันได้)
+func (c *Converter) officeToPDF(ctx context.Context, src, ext, dst string) error {
+	ctx, cancel := context.WithTimeout(ctx, officeTimeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp(filepath.Dir(dst), "office-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// soffice ดูชนิดไฟล์จากนามสกุล → ให้ชื่อที่ถูกต้องก่อน
+	in := filepath.Join(dir, "source"+ext)
+	if err := os.Link(src, in); err != nil {
+		if err := copyFile(src, in); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, c.office, "--headless", "--norestore",
+		"-env:UserInstallation=file://"+filepath.ToSlash(filepath.Join(dir, "profile")),
+		"--convert-to", "pdf", "--outdir", dir, in)
+	if b, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("soffice: %v: %s", err, strings.TrimSpace(string(b)))
+	}
+
+	out := filepath.Join(dir, "source.pdf")
+	if fi, err := os.Stat(out); err != nil || fi.Size() == 0 {
+		return fmt.Errorf("soffice: no output")
+	}
+	return copyFile(out, dst)
+}
+
+// ไม่มี LibreOffice → ดึงข้อความจาก xml ใน docx/pptx แทน
+func officeToText(src, mimeType, dst string) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	var text string
+	switch mimeType {
+	case MIMEDOCX:
+		text, err = docxText(&zr.Reader)
+	case MIMEPPTX:
+		text, err = pptxText(&zr.Reader)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupported, mimeType)
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("no text found in %s", filepath.Base(src))
+	}
+	return os.WriteFile(dst, []byte(text), 0644)
+}
+
+func docxText(zr *zip.Reader) (string, error) {
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			return xmlText(f, "t", "p")
+		}
+	}
+	return "", fmt.Errorf("word/document.xml not found")
+}
+
+// ข้อความทุกสไลด์ตามลำดับเลขสไลด์
+func pptxText(zr *zip.Reader) (string, error) {
+	type slide struct {
+		n int
+		f *zip.File
+	}
+	var slides []slide
+	for _, f := range zr.File {
+		if m := slideRe.FindStringSubmatch(f.Name); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			slides = append(slides, slide{n: n, f: f})
+		}
+	}
+	if len(slides) == 0 {
+		return "", fmt.Errorf("no slides found")
+	}
+	sort.Slice(slides, func(i, j int) bool { return slides[i].n < slides[j].n })
+
+	var b strings.Builder
+	for _, s := range slides {
+		t, err := xmlText(s.f, "t", "p")
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "--- slide %d ---\n%s\n", s.n, strings.TrimSpace(t))
+	}
+	return b.String(), nil
+}
+
+// รวม chardata ใน element textTag (w:t / a:t) ขึ้นบรรทัดใหม่เมื่อจบ paraTag
+func xmlText(f *zip.File, textTag, paraTag string) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(io.LimitReader(rc, maxPartBytes))
+	var b strings.Builder
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", f.Name, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == textTag {
+				inText = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case textTag:
+				inText = false
+			case paraTag:
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
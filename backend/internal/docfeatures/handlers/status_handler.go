@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"chaladshare_backend/internal/docfeatures/models"
+	"chaladshare_backend/internal/docfeatures/service"
+	fileservice "chaladshare_backend/internal/files/service"
+	jobservice "chaladshare_backend/internal/jobs/service"
+	"chaladshare_backend/internal/middleware"
+	summarymodels "chaladshare_backend/internal/summaries/models"
+	summaryservice "chaladshare_backend/internal/summaries/service"
+)
+
+// สถานะการประมวลผลเอกสาร (feature + summary + งานในคิว) และสั่งทำใหม่เมื่อ failed
+type StatusHandler struct {
+	featureService service.FeatureService
+	summaryService summaryservice.SummaryService
+	jobService     jobservice.JobService
+	fileService    fileservice.FileService
+}
+
+func NewStatusHandler(featureService service.FeatureService, summaryService summaryservice.SummaryService,
+	jobService jobservice.JobService, fileService fileservice.FileService) *StatusHandler {
+	return &StatusHandler{
+		featureService: featureService,
+		summaryService: summaryService,
+		jobService:     jobService,
+		fileService:    fileService,
+	}
+}
+
+// เช็ค document_id + เจ้าของไฟล์
+func (h *StatusHandler) ownedDocID(c *gin.Context) (int, bool) {
+	authUID := c.GetInt(middleware.CtxUserID)
+	if authUID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, false
+	}
+
+	docID, err := strconv.Atoi(c.Param("document_id"))
+	if err != nil || docID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document_id"})
+		return 0, false
+	}
+
+	ok, err := h.fileService.IsOwner(docID, authUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบไฟล์นี้"})
+			return 0, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return 0, false
+	}
+	return docID, true
+}
+
+func (h *StatusHandler) status(docID int) (*models.DocumentStatus, error) {
+	feature, err := h.featureService.GetByDocumentID(docID)
+	if err != nil {
+		return nil, err
+	}
+	if feature != nil {
+		// หน้าสถานะไม่ต้องส่ง vector
+		feature.StyleVector = nil
+	}
+	summary, err := h.summaryService.GetStatus(docID)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := h.jobService.ListByDocument(docID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DocumentStatus{DocumentID: docID, Feature: feature, Summary: summary, Jobs: jobs}, nil
+}
+
+// GET /api/v1/files/:document_id/status
+func (h *StatusHandler) GetStatus(c *gin.Context) {
+	docID, ok := h.ownedDocID(c)
+	if !ok {
+		return
+	}
+	st, err := h.status(docID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// POST /api/v1/files/:document_id/reprocess
+// ทำใหม่เฉพาะส่วนที่ failed (feature และ/หรือ summary) ไม่มีส่วนไหน failed = 409
+// แต่ละส่วนทำแยกกัน: เข้าคิวได้บางส่วน = 207 พร้อม errors ของส่วนที่ไม่สำเร็จ
+func (h *StatusHandler) Reprocess(c *gin.Context) {
+	docID, ok := h.ownedDocID(c)
+	if !ok {
+		return
+	}
+
+	var requeued []string
+	errs := map[string]error{}
+
+	switch err := h.featureService.Reprocess(docID); {
+	case err == nil:
+		requeued = append(requeued, models.StageFeature)
+	case errors.Is(err, models.ErrNotFailed):
+	default:
+		errs[models.StageFeature] = err
+	}
+
+	sum, err := h.summaryService.GetStatus(docID)
+	switch {
+	case err != nil:
+		errs[models.StageSummary] = err
+	case sum != nil && sum.SummaryStatus == summarymodels.SummaryFailed:
+		if _, err := h.summaryService.Regenerate(docID); err != nil {
+			errs[models.StageSummary] = err
+		} else {
+			requeued = append(requeued, models.StageSummary)
+		}
+	}
+
+	if len(requeued) == 0 {
+		// ไม่ได้เข้าคิวเลย: ตอบตาม error แรก (feature ก่อน summary)
+		for _, stage := range []string{models.StageFeature, models.StageSummary} {
+			if err := errs[stage]; err != nil {
+				c.JSON(reprocessErrorStatus(err), gin.H{"error": err.Error(), "stage": stage})
+				return
+			}
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": models.ErrNotFailed.Error()})
+		return
+	}
+
+	st, err := h.status(docID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "requeued": requeued})
+		return
+	}
+	out := models.ReprocessResult{DocumentStatus: st, Requeued: requeued}
+	if len(errs) == 0 {
+		c.JSON(http.StatusAccepted, out)
+		return
+	}
+	out.Errors = map[string]string{}
+	for stage, err := range errs {
+		out.Errors[stage] = err.Error()
+	}
+	c.JSON(http.StatusMultiStatus, out)
+}
+
+func reprocessErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrAIUnavailable), errors.Is(err, summarymodels.ErrAIUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, summarymodels.ErrSummaryInProgress):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
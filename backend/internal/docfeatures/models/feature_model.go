@@ -2,7 +2,11 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
+
+	jobmodels "chaladshare_backend/internal/jobs/models"
+	summarymodels "chaladshare_backend/internal/summaries/models"
 )
 
 const (
@@ -12,6 +16,15 @@ const (
 	FeatureFailed     = "failed"
 )
 
+// ไม่มี AI client: ใช้ข้อความที่ดึงในเครื่องแทน (ยังไม่มี style vector / embedding)
+// เป็นตัวบอกด้วยว่า failed เพราะยังไม่มีข้อความ ไม่ใช่ AI ล้ม
+const NoAIMessage = "ai client is nil, local text only (embeddings pending)"
+
+var (
+	ErrAIUnavailable = errors.New("ai client is not configured")
+	ErrNotFailed     = errors.New("document has nothing to reprocess")
+)
+
 type DocumentFeature struct {
 	DocumentID    int             `json:"document_id"`
 	FeatureStatus string          `json:"feature_status"`
@@ -19,10 +32,34 @@ type DocumentFeature struct {
 	StyleVector   json.RawMessage `json:"style_vector,omitempty"`
 	ClusterID     *int            `json:"cluster_id,omitempty"`
 	ErrorMessage  *string         `json:"error_message,omitempty"`
+	AttemptCount  int             `json:"attempt_count"`
+	LastError     *string         `json:"last_error,omitempty"`
+	LastAttemptAt *time.Time      `json:"last_attempt_at,omitempty"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
+// response ของ GET /files/:document_id/status (ยังไม่มีแถว = null)
+type DocumentStatus struct {
+	DocumentID int                          `json:"document_id"`
+	Feature    *DocumentFeature             `json:"feature"`
+	Summary    *summarymodels.SummaryStatus `json:"summary"`
+	Jobs       []jobmodels.Job              `json:"jobs"`
+}
+
+// ส่วนที่สั่งทำใหม่ได้ใน POST /files/:document_id/reprocess
+const (
+	StageFeature = "feature"
+	StageSummary = "summary"
+)
+
+// response ของ reprocess: สถานะล่าสุด + ส่วนที่เข้าคิวแล้ว / ส่วนที่ไม่สำเร็จ (207)
+type ReprocessResult struct {
+	*DocumentStatus
+	Requeued []string          `json:"requeued"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
 // ตอนสร้างแถวเริ่มต้น
 type CreateQueuedInput struct {
 	DocumentID int `json:"document_id"`
@@ -37,6 +74,12 @@ type SaveResult struct {
 	ClusterID        *int      `json:"cluster_id,omitempty"`
 }
 
+// ข้อความหนึ่งหน้าจาก pdftext (page_no เริ่มที่ 1)
+type PageText struct {
+	PageNo int    `json:"page_no"`
+	Text   string `json:"page_text"`
+}
+
 func (df *DocumentFeature) VectorAsFloat64() ([]float64, error) {
 	if len(df.StyleVector) == 0 {
 		return nil, nil
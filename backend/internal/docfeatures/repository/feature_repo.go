@@ -13,9 +13,14 @@ import (
 type DocFeaturesRepo interface {
 	CreateQueued(documentID int) error
 	MarkProcessing(documentID int) error
+	MarkDeferred(documentID int, reason string) error
 	SaveResult(input models.SaveResult) error
 	MarkFailed(documentID int, msg string) error
+	MarkWithoutAI(documentID int) (string, error)
+	RecordAttempts(documentID int, attempts int, lastErr string) error
 	GetByDocumentID(documentID int) (*models.DocumentFeature, error)
+	SaveLocalText(documentID int, pages []models.PageText) error
+	RequeueFailed(documentID int) (bool, error)
 }
 
 type FeatureRepo struct {
@@ -46,6 +51,17 @@ func (r *FeatureRepo) MarkProcessing(documentID int) error {
 	return err
 }
 
+// AI backend ไม่พร้อม → กลับเป็น queued พร้อมเหตุผล รอ worker รอบถัดไป
+func (r *FeatureRepo) MarkDeferred(documentID int, reason string) error {
+	q := `
+		UPDATE document_features
+		SET feature_status = $2, error_message = $3
+		WHERE document_id = $1;
+	`
+	_, err := r.db.Exec(q, documentID, models.FeatureQueued, reason)
+	return err
+}
+
 func f64ToF32(a []float64) []float32 {
 	out := make([]float32, len(a))
 	for i, v := range a {
@@ -77,7 +93,7 @@ func (r *FeatureRepo) SaveResult(input models.SaveResult) error {
 		    style_label       = $3,
 		    style_vector_v16  = $4,
 		    style_vector_raw  = $5::jsonb,
-		    content_text      = $6,
+		    content_text      = COALESCE(NULLIF($6, ''), content_text),
 		    content_embedding = $7,
 		    cluster_id        = COALESCE($8, cluster_id),
 		    error_message     = NULL
@@ -96,6 +112,56 @@ func (r *FeatureRepo) SaveResult(input models.SaveResult) error {
 	return err
 }
 
+// เก็บข้อความรายหน้า แล้วเติม content_text ถ้ายังว่าง (ผลจาก AI มาก่อนก็ไม่ทับ)
+func (r *FeatureRepo) SaveLocalText(documentID int, pages []models.PageText) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM document_pages WHERE document_id = $1`, documentID); err != nil {
+		return err
+	}
+	for _, p := range pages {
+		if _, err := tx.Exec(`
+			INSERT INTO document_pages (document_id, page_no, page_text)
+			VALUES ($1, $2, $3)
+		`, documentID, p.PageNo, p.Text); err != nil {
+			return err
+		}
+	}
+
+	q := `
+		UPDATE document_features
+		SET content_text = (
+			SELECT string_agg(page_text, E'\n\n' ORDER BY page_no)
+			FROM document_pages
+			WHERE document_id = $1
+		)
+		WHERE document_id = $1
+		  AND COALESCE(content_text, '') = '';
+	`
+	if _, err := tx.Exec(q, documentID); err != nil {
+		return err
+	}
+
+	// งาน features (ไม่มี AI) จบไปก่อนข้อความมาถึง → ได้ข้อความแล้วถือว่าเสร็จ
+	if len(pages) > 0 {
+		q = `
+			UPDATE document_features
+			SET feature_status = $2
+			WHERE document_id = $1
+			  AND feature_status = $3
+			  AND error_message = $4;
+		`
+		if _, err := tx.Exec(q, documentID, models.FeatureDone, models.FeatureFailed, models.NoAIMessage); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 func (r *FeatureRepo) MarkFailed(documentID int, msg string) error {
 	q := `
 		UPDATE document_features
@@ -106,10 +172,46 @@ func (r *FeatureRepo) MarkFailed(documentID int, msg string) error {
 	return err
 }
 
+// ไม่มี AI: มีข้อความจาก extract_text แล้ว = done (ค้นหาได้) ยังไม่มี = failed
+// คืนสถานะที่บันทึก
+func (r *FeatureRepo) MarkWithoutAI(documentID int) (string, error) {
+	q := `
+		UPDATE document_features
+		SET feature_status = CASE WHEN COALESCE(content_text, '') <> '' THEN $2 ELSE $3 END,
+		    error_message  = $4
+		WHERE document_id = $1
+		RETURNING feature_status;
+	`
+	var status string
+	err := r.db.QueryRow(q, documentID, models.FeatureDone, models.FeatureFailed, models.NoAIMessage).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return status, err
+}
+
+// สะสมจำนวนครั้งที่เรียก colab (รวม retry) และ error ล่าสุด ("" = สำเร็จ)
+func (r *FeatureRepo) RecordAttempts(documentID int, attempts int, lastErr string) error {
+	var errArg any = nil
+	if lastErr != "" {
+		errArg = lastErr
+	}
+
+	q := `
+		UPDATE document_features
+		SET attempt_count   = attempt_count + $2,
+		    last_error      = $3,
+		    last_attempt_at = now()
+		WHERE document_id = $1;
+	`
+	_, err := r.db.Exec(q, documentID, attempts, errArg)
+	return err
+}
+
 func (r *FeatureRepo) GetByDocumentID(documentID int) (*models.DocumentFeature, error) {
 	q := `
 		SELECT document_id, feature_status, style_label, style_vector_raw, cluster_id,
-		       error_message, created_at, updated_at
+		       error_message, attempt_count, last_error, last_attempt_at, created_at, updated_at
 		FROM document_features
 		WHERE document_id = $1;
 	`
@@ -122,6 +224,9 @@ func (r *FeatureRepo) GetByDocumentID(documentID int) (*models.DocumentFeature,
 		&out.StyleVector,
 		&out.ClusterID,
 		&out.ErrorMessage,
+		&out.AttemptCount,
+		&out.LastError,
+		&out.LastAttemptAt,
 		&out.CreatedAt,
 		&out.UpdatedAt,
 	)
@@ -133,3 +238,21 @@ func (r *FeatureRepo) GetByDocumentID(documentID int) (*models.DocumentFeature,
 	}
 	return &out, nil
 }
+
+// เริ่มนับใหม่เฉพาะที่ failed อยู่ (กดซ้ำระหว่างกำลังทำไม่ได้ผล) false = ไม่ได้อยู่ในสถานะ failed
+func (r *FeatureRepo) RequeueFailed(documentID int) (bool, error) {
+	q := `
+		UPDATE document_features
+		SET feature_status = $2,
+		    error_message  = NULL,
+		    attempt_count  = 0,
+		    last_error     = NULL
+		WHERE document_id = $1 AND feature_status = $3;
+	`
+	res, err := r.db.Exec(q, documentID, models.FeatureQueued, models.FeatureFailed)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
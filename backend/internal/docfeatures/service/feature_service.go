@@ -1,11 +1,17 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"log"
 
 	"chaladshare_backend/internal/connect"
 	"chaladshare_backend/internal/docfeatures/models"
 	"chaladshare_backend/internal/docfeatures/repository"
+	jobmodels "chaladshare_backend/internal/jobs/models"
+	jobservice "chaladshare_backend/internal/jobs/service"
+	notimodels "chaladshare_backend/internal/notifications/models"
+	notiservice "chaladshare_backend/internal/notifications/service"
 )
 
 type FeatureService interface {
@@ -14,18 +20,23 @@ type FeatureService interface {
 	SaveResult(input models.SaveResult) error
 	MarkFailed(documentID int, msg string) error
 	GetByDocumentID(documentID int) (*models.DocumentFeature, error)
-	ProcessDocument(documentID int, pdfPath string)
+	ProcessDocument(in jobmodels.Input) error
+	Reprocess(documentID int) error
 }
 
 type featureService struct {
 	featureRepo repository.DocFeaturesRepo
 	aiClient    *connect.Client
+	jobSvc      jobservice.JobService
+	notifier    notiservice.Notifier
 }
 
-func NewFeatureService(featureRepo repository.DocFeaturesRepo, aiClient *connect.Client) FeatureService {
+func NewFeatureService(featureRepo repository.DocFeaturesRepo, aiClient *connect.Client, jobSvc jobservice.JobService, notifier notiservice.Notifier) FeatureService {
 	return &featureService{
 		featureRepo: featureRepo,
 		aiClient:    aiClient,
+		jobSvc:      jobSvc,
+		notifier:    notifier,
 	}
 }
 
@@ -57,7 +68,12 @@ func (s *featureService) MarkFailed(documentID int, msg string) error {
 	if msg == "" {
 		msg = "unknown error"
 	}
-	return s.featureRepo.MarkFailed(documentID, msg)
+	if err := s.featureRepo.MarkFailed(documentID, msg); err != nil {
+		return err
+	}
+	// แจ้งเจ้าของเอกสาร (อันที่ยังไม่อ่านไม่แจ้งซ้ำตอน retry)
+	s.notifier.Notify(notimodels.Event{Type: notimodels.TypeDocumentFailed, DocumentID: documentID, Message: msg})
+	return nil
 }
 
 func (s *featureService) GetByDocumentID(documentID int) (*models.DocumentFeature, error) {
@@ -67,36 +83,100 @@ func (s *featureService) GetByDocumentID(documentID int) (*models.DocumentFeatur
 	return s.featureRepo.GetByDocumentID(documentID)
 }
 
-func (s *featureService) ProcessDocument(documentID int, pdfPath string) {
+// เอกสารที่ failed → กลับเป็น queued แล้วเข้าคิว extract_features ใหม่ (นับ attempt ใหม่)
+func (s *featureService) Reprocess(documentID int) error {
+	if documentID <= 0 {
+		return fmt.Errorf("invalid documentID")
+	}
 	if s.aiClient == nil {
-		_ = s.MarkFailed(documentID, "ai client is nil")
-		return
+		return models.ErrAIUnavailable
+	}
+	ok, err := s.featureRepo.RequeueFailed(documentID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.ErrNotFailed
+	}
+	if err := s.jobSvc.Enqueue(jobmodels.EnqueueInput{
+		DocumentID: documentID,
+		JobType:    jobmodels.JobExtractFeatures,
+	}); err != nil {
+		_ = s.featureRepo.MarkFailed(documentID, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (s *featureService) recordAttempts(documentID int, attempts int, lastErr string) {
+	if attempts <= 0 {
+		attempts = 1
+	}
+	if err := s.featureRepo.RecordAttempts(documentID, attempts, lastErr); err != nil {
+		log.Printf("[FEATURE] record attempts doc=%d err=%v", documentID, err)
+	}
+}
+
+// ไม่ต้องเสียเวลารอ timeout ตอน colab หลับ → เลื่อนงานไว้ก่อน
+func (s *featureService) deferDocument(documentID int, cause error) error {
+	reason := "ai backend unavailable, deferred: " + cause.Error()
+	if err := s.featureRepo.MarkDeferred(documentID, reason); err != nil {
+		log.Printf("[FEATURE] mark deferred doc=%d err=%v", documentID, err)
+	}
+	return fmt.Errorf("%w: %v", jobmodels.ErrDeferred, cause)
+}
+
+// error ที่คืนใช้บอก job queue เท่านั้น สถานะใน document_features ถูกบันทึกแล้ว
+func (s *featureService) ProcessDocument(in jobmodels.Input) error {
+	documentID := in.DocumentID
+
+	// ไม่มี AI: content_text ยังได้จากงาน extract_text (pdftext) ใช้ค้นหาได้ตามปกติ
+	// failed เฉพาะตอนยังไม่มีข้อความ (extract_text มาทีหลังจะเปลี่ยนเป็น done เอง)
+	// ไม่ได้ตั้งค่า AI เป็นเรื่องของระบบ ไม่ต้องแจ้งผู้ใช้
+	if s.aiClient == nil {
+		status, err := s.featureRepo.MarkWithoutAI(documentID)
+		if err != nil {
+			return err
+		}
+		if status == models.FeatureFailed {
+			return errors.New("ai client is nil and no local text yet")
+		}
+		return nil
+	}
+
+	if in.Path == "" {
+		_ = s.MarkFailed(documentID, "input path is empty")
+		return errors.New("input path is empty")
 	}
 
-	if pdfPath == "" {
-		_ = s.MarkFailed(documentID, "pdfPath is empty")
-		return
+	if !s.aiClient.Available() {
+		return s.deferDocument(documentID, connect.ErrCircuitOpen)
 	}
 
 	if err := s.MarkProcessing(documentID); err != nil {
 		_ = s.MarkFailed(documentID, err.Error())
-		return
+		return err
 	}
 
-	resp, err := s.aiClient.ExtractFeatures(documentID, pdfPath)
+	resp, err := s.aiClient.ExtractFeatures(documentID, connect.Upload{Path: in.Path, MIME: in.MIME, OriginalMIME: in.OriginalMIME})
 	if err != nil {
+		s.recordAttempts(documentID, connect.Attempts(err), err.Error())
+		if errors.Is(err, connect.ErrCircuitOpen) {
+			return s.deferDocument(documentID, err)
+		}
 		_ = s.MarkFailed(documentID, err.Error())
-		return
+		return err
 	}
+	s.recordAttempts(documentID, resp.Attempts, "")
 
 	if resp.StyleLabel == nil || *resp.StyleLabel == "" {
 		_ = s.MarkFailed(documentID, "missing style label ")
-		return
+		return errors.New("missing style label")
 	}
 
 	if len(resp.StyleVectorV16) == 0 {
 		_ = s.MarkFailed(documentID, "empty style_vector_v16 from ai")
-		return
+		return errors.New("empty style_vector_v16 from ai")
 	}
 
 	label := *resp.StyleLabel
@@ -110,6 +190,8 @@ func (s *featureService) ProcessDocument(documentID int, pdfPath string) {
 		ClusterID:        resp.ClusterID,
 	}); err != nil {
 		_ = s.MarkFailed(documentID, err.Error())
-		return
+		return err
 	}
+	s.notifier.Notify(notimodels.Event{Type: notimodels.TypeDocumentReady, DocumentID: documentID})
+	return nil
 }
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"chaladshare_backend/internal/convert"
+	"chaladshare_backend/internal/docfeatures/models"
+	"chaladshare_backend/internal/docfeatures/repository"
+	"chaladshare_backend/internal/pdftext"
+
+	jobmodels "chaladshare_backend/internal/jobs/models"
+)
+
+// ไฟล์ข้อความ / markdown อ่านไม่เกินนี้
+const maxTextBytes = 8 << 20
+
+// หัวสไลด์ที่ convert ใส่ไว้ตอนดึงข้อความจาก pptx
+var slideMarkRe = regexp.MustCompile(`(?m)^--- slide \d+ ---\n?`)
+
+// processor ของงาน extract_text: ดึงข้อความในเครื่องด้วย pdftext ไม่ต้องพึ่ง Colab
+// ผลใช้ค้นหาได้ทันที และเป็น content_text สำรองเมื่อ AI ใช้ไม่ได้
+type TextService interface {
+	ProcessDocument(in jobmodels.Input) error
+	MarkFailed(documentID int, msg string) error
+}
+
+type textService struct {
+	featureRepo repository.DocFeaturesRepo
+}
+
+func NewTextService(featureRepo repository.DocFeaturesRepo) TextService {
+	return &textService{featureRepo: featureRepo}
+}
+
+func (s *textService) ProcessDocument(in jobmodels.Input) error {
+	texts, err := readPages(in)
+	if err != nil {
+		return fmt.Errorf("extract text: %w", err)
+	}
+
+	// เก็บเฉพาะหน้าที่มีข้อความ (หน้าสแกน / รูปไม่มีให้ค้น)
+	var pages []models.PageText
+	for i, t := range texts {
+		if t = strings.TrimSpace(t); t != "" {
+			pages = append(pages, models.PageText{PageNo: i + 1, Text: t})
+		}
+	}
+	if err := s.featureRepo.SaveLocalText(in.DocumentID, pages); err != nil {
+		return fmt.Errorf("save text: %w", err)
+	}
+	log.Printf("[TEXT] doc=%d %d/%d page(s) with text", in.DocumentID, len(pages), len(texts))
+	return nil
+}
+
+func readPages(in jobmodels.Input) ([]string, error) {
+	switch {
+	case in.MIME == convert.MIMEPDF:
+		return pdftext.ExtractFile(in.Path)
+	case convert.IsText(in.MIME):
+		f, err := os.Open(in.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		b, err := io.ReadAll(io.LimitReader(f, maxTextBytes))
+		if err != nil {
+			return nil, err
+		}
+		text := strings.ToValidUTF8(string(b), "")
+		// pptx ที่แปลงเป็นข้อความ → แยกหน้าตามสไลด์
+		if in.OriginalMIME == convert.MIMEPPTX {
+			if parts := slideMarkRe.Split(text, -1); len(parts) > 1 && strings.TrimSpace(parts[0]) == "" {
+				return parts[1:], nil
+			}
+		}
+		return []string{text}, nil
+	}
+	// รูปที่ไม่ได้แปลงเป็น PDF (webp) ไม่มีข้อความให้ดึง
+	return nil, nil
+}
+
+// ข้อความเป็นของเสริม ไม่มีสถานะให้อัปเดต แค่ log ไว้
+func (s *textService) MarkFailed(documentID int, msg string) error {
+	log.Printf("[TEXT] doc=%d failed: %s", documentID, msg)
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -17,18 +19,22 @@ import (
 
 	"chaladshare_backend/internal/files/models"
 	"chaladshare_backend/internal/files/service"
+	"chaladshare_backend/internal/imaging"
 	"chaladshare_backend/internal/middleware"
+	postService "chaladshare_backend/internal/posts/service"
+	"chaladshare_backend/internal/storage"
 )
 
 type FileHandler struct {
 	fileservice service.FileService
+	postService postService.PostService
 }
 
-func NewFileHandler(fileservice service.FileService) *FileHandler {
-	return &FileHandler{fileservice: fileservice}
+func NewFileHandler(fileservice service.FileService, postService postService.PostService) *FileHandler {
+	return &FileHandler{fileservice: fileservice, postService: postService}
 }
 
-// PDF
+// เอกสาร
 func (h *FileHandler) UploadFile(c *gin.Context) {
 	uid := c.GetInt(middleware.CtxUserID)
 	if uid == 0 {
@@ -37,36 +43,16 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 	}
 	log.Printf("[UploadFile] HIT uid=%d", uid)
 
-	fh, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาแนบไฟล์ PDF", "detail": err.Error()})
-		return
-	}
-
-	id := uuid.New().String()
-	filename := id + ".pdf"
-
-	baseDir := filepath.Join(os.TempDir(), "chaladshare")
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		log.Printf("[UploadFile] MkdirAll failed baseDir=%s err=%v", baseDir, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "สร้าง temp dir ไม่ได้", "detail": err.Error()})
-		return
-	}
-
-	abs := filepath.Join(baseDir, filename)
-	log.Printf("[UploadFile] abs=%s orig=%s size=%d", abs, fh.Filename, fh.Size)
-
-	if err := c.SaveUploadedFile(fh, abs); err != nil {
-		log.Printf("[UploadFile] SaveUploadedFile failed abs=%s err=%v", abs, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "save tmp failed", "detail": err.Error()})
+	abs, name, ok := h.saveDocument(c, "UploadFile")
+	if !ok {
 		return
 	}
 
 	req := &models.UploadRequest{
 		UserID:          uid,
-		DocumentName:    fh.Filename,
+		DocumentName:    name,
 		DocumentURL:     "",
-		StorageProvider: "supabase",
+		StorageProvider: "",
 		LocalPath:       abs,
 	}
 
@@ -74,16 +60,44 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 	if err != nil {
 		log.Printf("[UploadFile] service.UploadFile failed err=%v", err)
 		_ = os.Remove(abs) // กันไฟล์ค้างถ้าอัปไม่สำเร็จ
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"document_id": resp.DocumentID,
-		"pdf_url":     resp.FileURL,
+		"document_id":  resp.DocumentID,
+		"pdf_url":      resp.FileURL,
+		"deduplicated": resp.Deduplicated,
 	})
 }
 
+// รับไฟล์เอกสาร (PDF / DOCX / PPTX / รูป / Markdown) จาก form แล้วเก็บเป็นไฟล์ temp คืน path และชื่อไฟล์เดิม
+func (h *FileHandler) saveDocument(c *gin.Context, tag string) (string, string, bool) {
+	fh, ok := h.formFile(c, service.KindDocument, "กรุณาแนบไฟล์เอกสาร")
+	if !ok {
+		return "", "", false
+	}
+
+	filename := service.TempFileName(fh.Filename)
+
+	baseDir := filepath.Join(os.TempDir(), "chaladshare")
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		log.Printf("[%s] MkdirAll failed baseDir=%s err=%v", tag, baseDir, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "สร้าง temp dir ไม่ได้", "detail": err.Error()})
+		return "", "", false
+	}
+
+	abs := filepath.Join(baseDir, filename)
+	log.Printf("[%s] abs=%s orig=%s size=%d", tag, abs, fh.Filename, fh.Size)
+
+	if err := c.SaveUploadedFile(fh, abs); err != nil {
+		log.Printf("[%s] SaveUploadedFile failed abs=%s err=%v", tag, abs, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "save tmp failed", "detail": err.Error()})
+		return "", "", false
+	}
+	return abs, fh.Filename, true
+}
+
 func (h *FileHandler) UploadCover(c *gin.Context) {
 	uid := c.GetInt(middleware.CtxUserID)
 	if uid == 0 {
@@ -92,17 +106,12 @@ func (h *FileHandler) UploadCover(c *gin.Context) {
 	}
 	log.Printf("[UploadCover] HIT uid=%d", uid)
 
-	fh, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาแนบรูปหน้าปก", "detail": err.Error()})
+	fh, ok := h.formFile(c, service.KindImage, "กรุณาแนบรูปหน้าปก")
+	if !ok {
 		return
 	}
-
+	// นามสกุลจริงดูจาก magic byte ใน service
 	ext := strings.ToLower(filepath.Ext(fh.Filename))
-	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "รองรับเฉพาะ .jpg .jpeg .png"})
-		return
-	}
 
 	baseDir := filepath.Join(os.TempDir(), "chaladshare")
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
@@ -129,25 +138,20 @@ func (h *FileHandler) UploadCover(c *gin.Context) {
 
 	log.Printf("[UploadCover] saved tmp=%s", abs)
 
-	st, err := service.NewSupabaseStorageFromEnv()
-	if err != nil {
-		log.Printf("[UploadCover] storage env error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	objectPath := fmt.Sprintf("covers/%d/%s", uid, filename)
-	log.Printf("[UploadCover] upload bucket=%s path=%s", os.Getenv("SUPABASE_STORAGE_BUCKET"), objectPath)
-
-	publicURL, err := st.UploadLocalFile(c.Request.Context(), objectPath, abs)
+	publicURL, provider, err := h.fileservice.UploadCover(uid, abs)
 	if err != nil {
 		log.Printf("[UploadCover] upload failed err=%v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("[UploadCover] success url=%s", publicURL)
-	c.JSON(http.StatusCreated, gin.H{"cover_url": publicURL, "cover_storage": "supabase"})
+	log.Printf("[UploadCover] success provider=%s url=%s", provider, publicURL)
+	c.JSON(http.StatusCreated, gin.H{
+		"cover_url":      publicURL,
+		"cover_storage":  provider,
+		"cover_variants": imaging.VariantURLs(publicURL, imaging.CoverVariants),
+		"cover_srcset":   imaging.SrcSet(publicURL, imaging.CoverVariants),
+	})
 }
 
 func (h *FileHandler) UploadAvatar(c *gin.Context) {
@@ -158,17 +162,12 @@ func (h *FileHandler) UploadAvatar(c *gin.Context) {
 	}
 	log.Printf("[UploadAvatar] HIT uid=%d", uid)
 
-	fh, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาแนบรูปโปรไฟล์", "detail": err.Error()})
+	fh, ok := h.formFile(c, service.KindImage, "กรุณาแนบรูปโปรไฟล์")
+	if !ok {
 		return
 	}
-
+	// นามสกุลจริงดูจาก magic byte ใน service
 	ext := strings.ToLower(filepath.Ext(fh.Filename))
-	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "รองรับเฉพาะ .jpg .jpeg .png"})
-		return
-	}
 
 	baseDir := filepath.Join(os.TempDir(), "chaladshare")
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
@@ -187,26 +186,60 @@ func (h *FileHandler) UploadAvatar(c *gin.Context) {
 	}
 	defer func() { _ = os.Remove(abs) }()
 
-	st, err := service.NewSupabaseStorageFromEnv()
+	publicURL, provider, err := h.fileservice.UploadAvatar(uid, abs)
 	if err != nil {
-		log.Printf("[UploadAvatar] storage env error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("[UploadAvatar] upload failed uid=%d err=%v", uid, err)
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	objectPath := fmt.Sprintf("avatars/%d/avatar%s", uid, ext)
+	c.JSON(http.StatusCreated, gin.H{
+		"avatar_url":      publicURL,
+		"avatar_storage":  provider,
+		"avatar_variants": imaging.VariantURLs(publicURL, imaging.AvatarVariants),
+		"avatar_srcset":   imaging.SrcSet(publicURL, imaging.AvatarVariants),
+	})
+}
+
+// เผื่อ header ของ multipart นอกจากตัวไฟล์
+const multipartOverhead = 1 << 20
 
-	publicURL, err := st.UploadLocalFile(c.Request.Context(), objectPath, abs)
+// จำกัดขนาด body ตามชนิดไฟล์ก่อน parse multipart เกิน = 413
+func (h *FileHandler) formFile(c *gin.Context, kind, missingMsg string) (*multipart.FileHeader, bool) {
+	limit := h.fileservice.MaxUploadBytes(kind)
+	if limit > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+	}
+
+	fh, err := c.FormFile("file")
 	if err != nil {
-		log.Printf("[UploadAvatar] upload failed uid=%d path=%s err=%v", uid, objectPath, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "ไฟล์ใหญ่เกินกำหนด", "max_bytes": limit})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": missingMsg, "detail": err.Error()})
+		return nil, false
+	}
+	if limit > 0 && fh.Size > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "ไฟล์ใหญ่เกินกำหนด", "max_bytes": limit})
+		return nil, false
 	}
+	return fh, true
+}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"avatar_url":     publicURL,
-		"avatar_storage": "supabase",
-	})
+// error จากการตรวจไฟล์ → status code
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrInvalidPDF), errors.Is(err, service.ErrEncryptedPDF), errors.Is(err, service.ErrInvalidImage):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // GET
@@ -234,17 +267,26 @@ func (h *FileHandler) GetFilesByUserID(c *gin.Context) {
 	c.JSON(http.StatusOK, files)
 }
 
-// GET /api/v1/documents/:document_id/summary
-func (h *FileHandler) GetSummaryByDocumentID(c *gin.Context) {
-	authUID := c.GetInt(middleware.CtxUserID)
+// GET เนื้อไฟล์ผ่าน backend (เจ้าของ หรือคนที่เห็นโพสต์ที่แนบไฟล์นี้) รองรับ Range / ETag
+func (h *FileHandler) GetFileContent(c *gin.Context) {
+	uid := c.GetInt(middleware.CtxUserID)
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
 	docID, err := strconv.Atoi(c.Param("document_id"))
 	if err != nil || docID <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document_id"})
 		return
 	}
 
-	ok, err := h.fileservice.IsOwner(docID, authUID)
+	ok, err := h.canViewDocument(docID, uid)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบไฟล์นี้"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -253,16 +295,56 @@ func (h *FileHandler) GetSummaryByDocumentID(c *gin.Context) {
 		return
 	}
 
-	summary, err := h.fileservice.GetSummaryByDocumentID(docID)
+	content, err := h.fileservice.OpenDocumentContent(c.Request.Context(), docID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสรุปของไฟล์นี้"})
-			return
+		switch {
+		case errors.Is(err, sql.ErrNoRows), errors.Is(err, storage.ErrNotFound), errors.Is(err, service.ErrNoStoredContent):
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบไฟล์นี้"})
+		default:
+			log.Printf("[GetFileContent] open doc=%d err=%v", docID, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "เปิดไฟล์จาก storage ไม่สำเร็จ"})
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, summary)
+	defer content.Body.Close()
+
+	disposition := "inline"
+	if d, _ := strconv.ParseBool(c.Query("download")); d {
+		disposition = "attachment"
+	}
+	if v := mime.FormatMediaType(disposition, map[string]string{"filename": content.Name}); v != "" {
+		disposition = v
+	}
+
+	c.Header("Content-Type", content.ContentType)
+	c.Header("Content-Disposition", disposition)
+	c.Header("ETag", content.ETag)
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, content.Name, content.ModTime, content.Body)
+}
+
+// เจ้าของเห็นเสมอ ไม่งั้นต้องเห็นอย่างน้อยหนึ่งโพสต์ที่แนบเอกสารนี้
+func (h *FileHandler) canViewDocument(docID, uid int) (bool, error) {
+	isOwner, err := h.fileservice.IsOwner(docID, uid)
+	if err != nil || isOwner {
+		return isOwner, err
+	}
+
+	postIDs, err := h.fileservice.GetPostIDsByDocumentID(docID)
+	if err != nil {
+		return false, err
+	}
+	for _, postID := range postIDs {
+		ok, _, err := h.postService.ViewPost(uid, postID)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // DELETE
@@ -299,3 +381,130 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "ลบไฟล์สำเร็จ"})
 }
+
+// POST /files/:document_id/versions อัปไฟล์ใหม่แทนของเดิม (เก็บเวอร์ชันเก่าไว้)
+func (h *FileHandler) UploadVersion(c *gin.Context) {
+	uid := c.GetInt(middleware.CtxUserID)
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	docID, ok := h.ownedDocument(c, uid)
+	if !ok {
+		return
+	}
+
+	abs, name, ok := h.saveDocument(c, "UploadVersion")
+	if !ok {
+		return
+	}
+
+	resp, err := h.fileservice.UploadVersion(docID, &models.UploadRequest{
+		UserID:       uid,
+		DocumentName: name,
+		LocalPath:    abs,
+	})
+	if err != nil {
+		log.Printf("[UploadVersion] doc=%d err=%v", docID, err)
+		_ = os.Remove(abs)
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"document_id":      resp.DocumentID,
+		"document_version": resp.File.Version,
+		"pdf_url":          resp.FileURL,
+		"deduplicated":     resp.Deduplicated,
+	})
+}
+
+// GET /files/:document_id/versions (ใครเห็นเอกสารได้ก็เห็นประวัติได้)
+func (h *FileHandler) ListVersions(c *gin.Context) {
+	uid := c.GetInt(middleware.CtxUserID)
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	docID, err := strconv.Atoi(c.Param("document_id"))
+	if err != nil || docID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document_id"})
+		return
+	}
+
+	ok, err := h.canViewDocument(docID, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบไฟล์นี้"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	versions, err := h.fileservice.ListVersions(docID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบไฟล์นี้"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// POST /files/:document_id/restore ให้เวอร์ชันนี้กลับมาเป็นเวอร์ชันปัจจุบัน
+func (h *FileHandler) RestoreVersion(c *gin.Context) {
+	uid := c.GetInt(middleware.CtxUserID)
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	docID, ok := h.ownedDocument(c, uid)
+	if !ok {
+		return
+	}
+
+	doc, err := h.fileservice.RestoreVersion(docID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบไฟล์นี้"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "กู้คืนเวอร์ชันสำเร็จ", "data": doc})
+}
+
+// document_id จาก path ที่ผู้ใช้เป็นเจ้าของ (ตอบ error ไปแล้วถ้าไม่ผ่าน)
+func (h *FileHandler) ownedDocument(c *gin.Context, uid int) (int, bool) {
+	docID, err := strconv.Atoi(c.Param("document_id"))
+	if err != nil || docID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document_id"})
+		return 0, false
+	}
+
+	ok, err := h.fileservice.IsOwner(docID, uid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบไฟล์นี้"})
+			return 0, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return 0, false
+	}
+	return docID, true
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"chaladshare_backend/internal/files/models"
+	"chaladshare_backend/internal/files/service"
+	"chaladshare_backend/internal/middleware"
+)
+
+// อัปโหลดแบบแบ่งก้อน (คล้าย tus): header Upload-Offset / Upload-Length บอกตำแหน่ง
+type UploadSessionHandler struct {
+	uploadService service.UploadSessionService
+}
+
+func NewUploadSessionHandler(uploadService service.UploadSessionService) *UploadSessionHandler {
+	return &UploadSessionHandler{uploadService: uploadService}
+}
+
+// POST /files/uploads {file_name, size}
+func (h *UploadSessionHandler) Create(c *gin.Context) {
+	uid := c.GetInt(middleware.CtxUserID)
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.CreateUploadSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	sess, err := h.uploadService.Create(uid, req.FileName, req.Size)
+	if err != nil {
+		if errors.Is(err, service.ErrFileTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", "/api/v1/files/uploads/"+sess.UploadID)
+	setUploadHeaders(c, sess.Received, sess.TotalSize)
+	c.JSON(http.StatusCreated, gin.H{"data": sess})
+}
+
+// HEAD /files/uploads/:upload_id → ถามว่า server ได้ไปแล้วกี่ byte
+func (h *UploadSessionHandler) Head(c *gin.Context) {
+	sess, ok := h.session(c)
+	if !ok {
+		return
+	}
+	setUploadHeaders(c, sess.Received, sess.TotalSize)
+	c.Status(http.StatusOK)
+}
+
+// GET /files/uploads/:upload_id
+func (h *UploadSessionHandler) Get(c *gin.Context) {
+	sess, ok := h.session(c)
+	if !ok {
+		return
+	}
+	setUploadHeaders(c, sess.Received, sess.TotalSize)
+	c.JSON(http.StatusOK, gin.H{"data": sess})
+}
+
+// PATCH /files/uploads/:upload_id (Upload-Offset: n, body = byte ดิบของก้อนถัดไป)
+func (h *UploadSessionHandler) Patch(c *gin.Context) {
+	uid := c.GetInt(middleware.CtxUserID)
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ต้องส่ง header Upload-Offset"})
+		return
+	}
+
+	newOffset, err := h.uploadService.WriteChunk(uid, c.Param("upload_id"), offset, c.Request.Body)
+	if err != nil {
+		var mismatch *service.OffsetMismatchError
+		switch {
+		case errors.As(err, &mismatch):
+			c.Header("Upload-Offset", strconv.FormatInt(mismatch.Offset, 10))
+			c.JSON(http.StatusConflict, gin.H{"error": "offset ไม่ตรง", "offset": mismatch.Offset})
+		case errors.Is(err, service.ErrUploadNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบ upload session"})
+		case errors.Is(err, service.ErrUploadCompleted):
+			c.JSON(http.StatusConflict, gin.H{"error": "อัปโหลดเสร็จไปแล้ว"})
+		case errors.Is(err, service.ErrFileTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		default:
+			// เน็ตหลุดกลางก้อน: ส่วนที่ได้แล้วเก็บไว้ client ส่งต่อจาก offset ใหม่
+			log.Printf("[UploadPatch] upload=%s offset=%d err=%v", c.Param("upload_id"), newOffset, err)
+			c.Header("Upload-Offset", strconv.FormatInt(newOffset, 10))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "อ่านข้อมูลไม่ครบ", "offset": newOffset})
+		}
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(newOffset, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// POST /files/uploads/:upload_id/complete → สร้าง document เหมือน /files/doc
+func (h *UploadSessionHandler) Complete(c *gin.Context) {
+	uid := c.GetInt(middleware.CtxUserID)
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	resp, err := h.uploadService.Complete(uid, c.Param("upload_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUploadNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบ upload session"})
+		case errors.Is(err, service.ErrUploadIncomplete), errors.Is(err, service.ErrUploadCompleted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUploadLost):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		default:
+			log.Printf("[UploadComplete] upload=%s err=%v", c.Param("upload_id"), err)
+			c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"document_id":  resp.DocumentID,
+		"pdf_url":      resp.FileURL,
+		"deduplicated": resp.Deduplicated,
+	})
+}
+
+// DELETE /files/uploads/:upload_id
+func (h *UploadSessionHandler) Abort(c *gin.Context) {
+	uid := c.GetInt(middleware.CtxUserID)
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	if err := h.uploadService.Abort(uid, c.Param("upload_id")); err != nil {
+		if errors.Is(err, service.ErrUploadNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบ upload session"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UploadSessionHandler) session(c *gin.Context) (*models.UploadSession, bool) {
+	uid := c.GetInt(middleware.CtxUserID)
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+	sess, err := h.uploadService.Get(uid, c.Param("upload_id"))
+	if err != nil {
+		if errors.Is(err, service.ErrUploadNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบ upload session"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return sess, true
+}
+
+func setUploadHeaders(c *gin.Context, offset, length int64) {
+	c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(length, 10))
+	c.Header("Cache-Control", "no-store")
+}
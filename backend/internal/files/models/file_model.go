@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 // ข้อมูลไฟล์ที่อัปโหลด
 type Document struct {
@@ -9,7 +12,21 @@ type Document struct {
 	DocumentName    string    `json:"document_name"`
 	DocumentURL     string    `json:"document_url"`
 	StorageProvider string    `json:"storage_provider"`
+	DocumentMIME    string    `json:"document_mime"`
+	DocumentPath    *string   `json:"-"` // object path ใน storage (NULL = ลิงก์ภายนอก/ข้อมูลเก่า)
+	DocumentBucket  *string   `json:"-"`
+	DocumentSize    *int64    `json:"document_size"`  // byte
+	DocumentPages   *int      `json:"document_pages"` // NULL = ไฟล์เก่า/ลิงก์ภายนอก
+	CoverURL        *string   `json:"cover_url"`      // หน้าปกที่ render จากหน้าแรก
 	UploadedAt      time.Time `json:"uploaded_at"`
+	ContentHash     *string   `json:"-"` // sha256 (hex) ของไฟล์ที่อัปโหลด
+	DedupSourceID   *int      `json:"-"` // ไฟล์ซ้ำ: เอกสารต้นทางที่ใช้ object ร่วมกัน
+	UploadID        *string   `json:"-"` // มาจาก upload session (บันทึก document_id ลง session ใน tx เดียวกัน)
+
+	// เวอร์ชัน: family = document_id ของเวอร์ชันแรก
+	FamilyID  int  `json:"document_family_id"`
+	Version   int  `json:"document_version"`
+	IsCurrent bool `json:"is_current"`
 }
 
 // เก็บข้อมูลจากไฟล์ที่สรุปเนื้อหาด้วย AI
@@ -22,12 +39,22 @@ type Summary struct {
 	DocumentID       int       `json:"document_id"`
 }
 
+// ผลที่คัดลอกมาจากเอกสารที่มีไฟล์เดียวกัน (ส่วนที่ false ยังต้องประมวลผลเอง)
+type DerivedCopy struct {
+	Features bool
+	Summary  bool
+	Cover    bool
+	Text     bool
+}
+
 type UploadRequest struct {
 	UserID          int    `json:"-"`
 	DocumentName    string `json:"document_name"`
 	DocumentURL     string `json:"document_url"`
 	StorageProvider string `json:"storage_provider"`
 	LocalPath       string `json:"-"`
+	FamilyID        int    `json:"-"` // > 0 = อัปเป็นเวอร์ชันใหม่ของ family นี้
+	UploadID        string `json:"-"` // complete ของ upload session
 }
 
 // response ตอนอัปโหลดสำเร็จ
@@ -36,4 +63,16 @@ type UploadResponse struct {
 	File       Document `json:"file"`
 	FileURL    string   `json:"file_url"`
 	DocumentID int      `json:"document_id"`
+	// ไฟล์เดียวกับที่ผู้ใช้คนนี้เคยอัปแล้ว ใช้ object และผลวิเคราะห์เดิม
+	// (ใช้ร่วมกับไฟล์ของคนอื่นก็ทำเงียบๆ ไม่ตั้งค่านี้)
+	Deduplicated bool `json:"deduplicated,omitempty"`
+}
+
+// เนื้อไฟล์สำหรับ stream ผ่าน /files/:document_id/content (ผู้เรียกต้อง Close Body)
+type DocumentContent struct {
+	Name        string
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+	Body        io.ReadSeekCloser
 }
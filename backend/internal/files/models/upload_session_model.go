@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// session ของการอัปโหลดแบบแบ่งก้อน (POST สร้าง → PATCH ทีละก้อน → complete)
+type UploadSession struct {
+	UploadID    string     `json:"upload_id"`
+	UserID      int        `json:"-"`
+	FileName    string     `json:"file_name"`
+	TotalSize   int64      `json:"total_size"`
+	Received    int64      `json:"offset"`
+	DocumentID  *int       `json:"document_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+type CreateUploadSessionRequest struct {
+	FileName string `json:"file_name" binding:"required"`
+	Size     int64  `json:"size" binding:"required"`
+}
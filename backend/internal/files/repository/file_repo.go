@@ -2,27 +2,49 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"chaladshare_backend/internal/files/models"
+	"chaladshare_backend/internal/storage"
 )
 
 type FileRepository interface {
 	// documents
 	CreateDocument(doc *models.Document) (*models.Document, error)
 	GetListDocByUserID(userID int) ([]models.Document, error)
-	DeleteDocument(id int) error
+	// ลบเอกสาร (เลื่อนเวอร์ชัน + ลบ summary) ใน tx เดียว คืนจำนวนเอกสารอื่นที่ยังชี้ object เดียวกัน
+	DeleteDocument(id int) (int, error)
 
 	GetDocumentOwnerID(documentID int) (int, error)
 	GetDocumentByID(documentID int) (*models.Document, error)
+	GetPostIDsByDocumentID(documentID int) ([]int, error)
+	SetDocumentCover(documentID int, url, path, bucket, provider string) (int64, error)
+
+	// dedup ตาม content_hash (ข้ามผู้ใช้ได้ ไฟล์ของผู้อัปเองมาก่อน)
+	FindByContentHash(userID int, hash string) (*models.Document, error)
+	CopyDerivedData(srcDocumentID, dstDocumentID int) (models.DerivedCopy, error)
+
+	// เวอร์ชัน (family = document_id ของเวอร์ชันแรก)
+	CreateVersion(familyID int, doc *models.Document) (*models.Document, error)
+	ListVersions(familyID int) ([]models.Document, error)
+	SetCurrentVersion(documentID int) error
 
 	// summaries
 	GetSummaryByDocID(docID int) (*models.Summary, error)
 	CreateSummary(summary *models.Summary) (*models.Summary, error)
+	// แทนที่ summary เดิมของเอกสารใน tx เดียว (insert ไม่ผ่าน = ของเดิมยังอยู่)
+	ReplaceSummary(summary *models.Summary) (*models.Summary, error)
 	DeleteSummariesByDocID(docID int) error
 }
 
+// เอกสารต้นทางของไฟล์ซ้ำถูกลบไประหว่างอัป → ต้องอัป object ใหม่เอง
+var ErrDedupSourceGone = errors.New("dedup source document no longer exists")
+
+// upload session นี้สร้างเอกสารไปแล้ว (complete ซ้อนกัน) → ไม่สร้างซ้ำ
+var ErrUploadSessionDone = errors.New("upload session already completed")
+
 type fileRepository struct {
 	db *sql.DB
 }
@@ -31,28 +53,110 @@ func NewFileRepository(db *sql.DB) FileRepository {
 	return &fileRepository{db: db}
 }
 
-// CreateDocument
-func (r *fileRepository) CreateDocument(req *models.Document) (*models.Document, error) {
-	err := r.db.QueryRow(`
-		INSERT INTO documents (document_user_id, document_name, document_url, storage_provider, uploaded_at)
-		VALUES ($1,$2,$3,$4,$5)
-		RETURNING document_id, uploaded_at
-	`,
-		req.DocumentUserID, req.DocumentName, req.DocumentURL, req.StorageProvider, time.Now(),
-	).Scan(&req.DocumentID, &req.UploadedAt)
+const documentColumns = `document_id, document_user_id, document_name, document_url, storage_provider,
+		document_path, document_bucket, document_size, document_pages, document_cover_url, uploaded_at,
+		content_hash, document_family_id, document_version, document_is_current, document_mime`
+
+func scanDocument(row interface{ Scan(...any) error }) (*models.Document, error) {
+	var d models.Document
+	if err := row.Scan(
+		&d.DocumentID, &d.DocumentUserID, &d.DocumentName, &d.DocumentURL, &d.StorageProvider,
+		&d.DocumentPath, &d.DocumentBucket, &d.DocumentSize, &d.DocumentPages, &d.CoverURL, &d.UploadedAt,
+		&d.ContentHash, &d.FamilyID, &d.Version, &d.IsCurrent, &d.DocumentMIME,
+	); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
 
+// CreateDocument (เวอร์ชันแรกของ family ใหม่)
+func (r *fileRepository) CreateDocument(req *models.Document) (*models.Document, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockDedupSource(tx, req); err != nil {
+		return nil, err
+	}
+	if err := insertDocument(tx, req, nil, 1); err != nil {
 		return nil, fmt.Errorf("ไม่สามารถบันทึกไฟล์ได้: %v", err)
 	}
-	return req, nil
+	if err := completeUploadSession(tx, req); err != nil {
+		return nil, err
+	}
+	return req, tx.Commit()
+}
+
+// ไฟล์ซ้ำ: ล็อกแถวต้นทาง (FOR SHARE) ไว้จน commit
+// DeleteDocument ล็อกแถวที่ใช้ object ร่วมแบบ FOR UPDATE → ลบกับอัปซ้ำจะไม่สวนกันจน object หาย
+func lockDedupSource(tx *sql.Tx, req *models.Document) error {
+	if req.DedupSourceID == nil {
+		return nil
+	}
+	var id int
+	err := tx.QueryRow(`
+		SELECT document_id FROM documents
+		WHERE document_id = $1 AND document_path = $2
+		FOR SHARE
+	`, *req.DedupSourceID, req.DocumentPath).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrDedupSourceGone
+	}
+	return err
+}
+
+// มาจาก upload session: ผูก document_id กับ session ใน tx เดียวกับเอกสาร
+// commit แล้ว complete ซ้ำจะได้เอกสารเดิม ไม่มีช่วงที่มีเอกสารแต่ session ยังเปิดอยู่
+func completeUploadSession(tx *sql.Tx, req *models.Document) error {
+	if req.UploadID == nil {
+		return nil
+	}
+	var id string
+	err := tx.QueryRow(`
+		UPDATE upload_sessions
+		SET document_id = $2, completed_at = now(), updated_at = now()
+		WHERE upload_id = $1 AND completed_at IS NULL
+		RETURNING upload_id
+	`, *req.UploadID, req.DocumentID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrUploadSessionDone
+	}
+	return err
+}
+
+// family = nil → trigger ใส่ document_id ของตัวเองให้
+// มี path แต่ไม่มี URL (ใช้ object ของคนอื่น) → เก็บ route /content ของแถวนี้แทน URL ของ storage
+func insertDocument(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, req *models.Document, familyID any, version int) error {
+	err := q.QueryRow(`
+		INSERT INTO documents (document_user_id, document_name, document_url, storage_provider,
+			document_path, document_bucket, document_size, document_pages, content_hash, uploaded_at,
+			document_family_id, document_version, document_is_current, document_mime)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,TRUE,COALESCE(NULLIF($13, ''), 'application/pdf'))
+		RETURNING document_id, uploaded_at, document_family_id, document_version, document_is_current, document_mime
+	`,
+		req.DocumentUserID, req.DocumentName, req.DocumentURL, req.StorageProvider,
+		req.DocumentPath, req.DocumentBucket, req.DocumentSize, req.DocumentPages, req.ContentHash, time.Now(),
+		familyID, version, req.DocumentMIME,
+	).Scan(&req.DocumentID, &req.UploadedAt, &req.FamilyID, &req.Version, &req.IsCurrent, &req.DocumentMIME)
+	if err != nil || req.DocumentURL != "" || req.DocumentPath == nil {
+		return err
+	}
+	return q.QueryRow(`
+		UPDATE documents SET document_url = $2 WHERE document_id = $1
+		RETURNING document_url
+	`, req.DocumentID, storage.ContentPath(req.DocumentID)).Scan(&req.DocumentURL)
 }
 
-// etListDocByUserID latest
+// etListDocByUserID latest (เฉพาะเวอร์ชันปัจจุบัน)
 func (r *fileRepository) GetListDocByUserID(userID int) ([]models.Document, error) {
 	rows, err := r.db.Query(`
-		SELECT document_id, document_user_id, document_name, document_url, storage_provider, uploaded_at
+		SELECT `+documentColumns+`
 		FROM documents
-		WHERE document_user_id = $1
+		WHERE document_user_id = $1 AND document_is_current
 		ORDER BY uploaded_at DESC
 	`, userID)
 	if err != nil {
@@ -62,11 +166,11 @@ func (r *fileRepository) GetListDocByUserID(userID int) ([]models.Document, erro
 
 	var docs []models.Document
 	for rows.Next() {
-		var d models.Document
-		if err := rows.Scan(&d.DocumentID, &d.DocumentUserID, &d.DocumentName, &d.DocumentURL, &d.StorageProvider, &d.UploadedAt); err != nil {
+		d, err := scanDocument(rows)
+		if err != nil {
 			return nil, err
 		}
-		docs = append(docs, d)
+		docs = append(docs, *d)
 	}
 	return docs, nil
 }
@@ -85,6 +189,27 @@ func (r *fileRepository) CreateSummary(summary *models.Summary) (*models.Summary
 	return summary, nil
 }
 
+func (r *fileRepository) ReplaceSummary(summary *models.Summary) (*models.Summary, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM summaries WHERE document_id = $1`, summary.DocumentID); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRow(`
+		INSERT INTO summaries (summary_text, summary_html, summary_pdf_url, summary_created_at, document_id)
+		VALUES ($1,$2,$3,$4,$5)
+		RETURNING summary_id, summary_created_at
+	`, summary.SummaryText, summary.SummaryHTML, summary.SummaryPDFURL, time.Now(), summary.DocumentID).
+		Scan(&summary.SummaryID, &summary.SummaryCreatedAt); err != nil {
+		return nil, err
+	}
+	return summary, tx.Commit()
+}
+
 // GetSummaryByDocID
 func (r *fileRepository) GetSummaryByDocID(docID int) (*models.Summary, error) {
 	var s models.Summary
@@ -93,23 +218,71 @@ func (r *fileRepository) GetSummaryByDocID(docID int) (*models.Summary, error) {
 		FROM summaries
 		WHERE document_id = $1
 	`, docID).Scan(&s.SummaryID, &s.SummaryText, &s.SummaryHTML, &s.SummaryPDFURL, &s.SummaryCreatedAt, &s.DocumentID)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("ไม่พบสรุปของเอกสารนี้")
+	if err != nil {
+		return nil, err
 	}
-	return &s, err
+	return &s, nil
 }
 
-// DeleteDocument
-func (r *fileRepository) DeleteDocument(id int) error {
-	res, err := r.db.Exec("DELETE FROM documents WHERE document_id = $1", id)
+// DeleteDocument: ผู้เรียกลบ object ใน storage หลัง commit เมื่อคืน 0 เท่านั้น
+func (r *fileRepository) DeleteDocument(id int) (int, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return err
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// ล็อกแถวตัวเองก่อน: อัปซ้ำที่ใช้เอกสารนี้เป็นต้นทางต้องรอ แล้วจะเห็นว่าถูกลบไปแล้ว
+	var (
+		familyID int
+		current  bool
+		provider string
+		bucket   sql.NullString
+		path     sql.NullString
+	)
+	if err := tx.QueryRow(`
+		SELECT document_family_id, document_is_current, storage_provider, document_bucket, document_path
+		FROM documents WHERE document_id = $1
+		FOR UPDATE
+	`, id).Scan(&familyID, &current, &provider, &bucket, &path); err != nil {
+		return 0, err
+	}
+
+	if current {
+		if err := detachVersion(tx, familyID, id); err != nil {
+			return 0, err
+		}
+	}
+
+	// เอกสารอื่นที่ชี้ object เดียวกัน (ล็อกไว้ด้วย ไม่ให้ถูกลบพร้อมกันแล้วต่างฝ่ายต่างนับได้ 1)
+	refs := 0
+	if path.Valid && path.String != "" {
+		if err := tx.QueryRow(`
+			SELECT COUNT(*)
+			FROM (
+				SELECT document_id FROM documents
+				WHERE document_id <> $1
+				  AND storage_provider = $2
+				  AND COALESCE(document_bucket, '') = $3
+				  AND document_path = $4
+				FOR UPDATE
+			) refs
+		`, id, provider, bucket.String, path.String).Scan(&refs); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM summaries WHERE document_id = $1`, id); err != nil {
+		return 0, err
 	}
-	rowsAffected, _ := res.RowsAffected()
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+	res, err := tx.Exec(`DELETE FROM documents WHERE document_id = $1`, id)
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return refs, tx.Commit()
 }
 
 func (r *fileRepository) GetDocumentOwnerID(documentID int) (int, error) {
@@ -126,18 +299,331 @@ func (r *fileRepository) GetDocumentOwnerID(documentID int) (int, error) {
 }
 
 func (r *fileRepository) GetDocumentByID(id int) (*models.Document, error) {
-	var d models.Document
-	err := r.db.QueryRow(
-		`SELECT document_id, document_user_id, document_name, document_url, storage_provider, uploaded_at
+	return scanDocument(r.db.QueryRow(`
+		SELECT `+documentColumns+`
 		FROM documents
-		WHERE document_id = $1`, id).Scan(&d.DocumentID, &d.DocumentUserID, &d.DocumentName, &d.DocumentURL, &d.StorageProvider, &d.UploadedAt)
+		WHERE document_id = $1`, id))
+}
+
+// โพสต์ทั้งหมดที่แนบเอกสารนี้ (ทุกเวอร์ชันใน family เดียวกัน) ใช้เช็คสิทธิ์ดาวน์โหลด
+func (r *fileRepository) GetPostIDsByDocumentID(documentID int) ([]int, error) {
+	rows, err := r.db.Query(`
+		SELECT p.post_id
+		FROM posts p
+		JOIN documents d ON d.document_family_id = p.post_document_family_id
+		WHERE d.document_id = $1
+		ORDER BY p.post_id
+	`, documentID)
 	if err != nil {
 		return nil, err
 	}
-	return &d, nil
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// บันทึกหน้าปกของเอกสาร แล้วใช้เป็นหน้าปกของโพสต์ที่ยังไม่มี คืนจำนวนโพสต์ที่อัปเดต
+func (r *fileRepository) SetDocumentCover(documentID int, url, path, bucket, provider string) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE documents
+		SET document_cover_url = $2, document_cover_path = $3,
+			document_cover_bucket = NULLIF($4, ''), document_cover_storage = $5
+		WHERE document_id = $1
+	`, documentID, url, path, bucket, provider); err != nil {
+		return 0, err
+	}
+
+	res, err := tx.Exec(`
+		UPDATE posts
+		SET post_cover_url = $2, post_cover_path = $3,
+			post_cover_bucket = NULLIF($4, ''), post_cover_storage = $5
+		WHERE post_document_id = $1
+		  AND (post_cover_url IS NULL OR post_cover_url = '')
+	`, documentID, url, path, bucket, provider)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return n, tx.Commit()
 }
 
 func (r *fileRepository) DeleteSummariesByDocID(docID int) error {
 	_, err := r.db.Exec(`DELETE FROM summaries WHERE document_id = $1`, docID)
 	return err
 }
+
+// เอกสารเดิมที่มีไฟล์เดียวกันและยังมี object ใน storage
+// เลือกของผู้อัปเองก่อน แล้วตัวที่วิเคราะห์เสร็จแล้ว (ของคนอื่นใช้ร่วมได้ แต่ห้ามบอก client)
+// ไม่พบคืน nil, nil
+func (r *fileRepository) FindByContentHash(userID int, hash string) (*models.Document, error) {
+	d, err := scanDocument(r.db.QueryRow(`
+		SELECT `+documentColumns+`
+		FROM documents
+		WHERE content_hash = $1
+		  AND document_path IS NOT NULL AND document_path <> ''
+		ORDER BY document_user_id = $2 DESC, EXISTS (
+			SELECT 1 FROM document_features f
+			WHERE f.document_id = documents.document_id AND f.feature_status = 'done'
+		) DESC, document_id
+		LIMIT 1
+	`, hash, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// คัดลอกผลที่ประมวลผลเสร็จแล้ว (features / summary / หน้าปก) จากเอกสารต้นทาง
+// แถว queued ของปลายทางต้องสร้างไว้ก่อน ส่วนที่ต้นทางยังไม่เสร็จจะไม่ถูกแตะ
+// features ที่ done แบบไม่มี AI (มีแต่ข้อความ) ไม่นับ ให้ปลายทางวิเคราะห์เอง
+func (r *fileRepository) CopyDerivedData(srcID, dstID int) (models.DerivedCopy, error) {
+	var out models.DerivedCopy
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return out, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE document_features dst
+		SET feature_status    = src.feature_status,
+		    style_label       = src.style_label,
+		    style_vector_v16  = src.style_vector_v16,
+		    style_vector_raw  = src.style_vector_raw,
+		    content_text      = src.content_text,
+		    content_embedding = src.content_embedding,
+		    cluster_id        = src.cluster_id,
+		    error_message     = NULL
+		FROM document_features src
+		WHERE dst.document_id = $2
+		  AND src.document_id = $1
+		  AND src.feature_status = 'done'
+		  AND src.style_vector_v16 IS NOT NULL
+	`, srcID, dstID)
+	if err != nil {
+		return out, err
+	}
+	n, _ := res.RowsAffected()
+	out.Features = n > 0
+
+	res, err = tx.Exec(`
+		UPDATE summary_status dst
+		SET summary_status = 'done', error_message = NULL, updated_at = now()
+		FROM summary_status src
+		WHERE dst.document_id = $2
+		  AND src.document_id = $1
+		  AND src.summary_status = 'done'
+		  AND EXISTS (SELECT 1 FROM summaries s WHERE s.document_id = $1)
+	`, srcID, dstID)
+	if err != nil {
+		return out, err
+	}
+	if n, _ = res.RowsAffected(); n > 0 {
+		if _, err := tx.Exec(`
+			INSERT INTO summaries (summary_text, summary_html, summary_pdf_url, summary_created_at, document_id)
+			SELECT summary_text, summary_html, summary_pdf_url, now(), $2
+			FROM summaries
+			WHERE document_id = $1
+		`, srcID, dstID); err != nil {
+			return out, err
+		}
+		out.Summary = true
+	}
+
+	res, err = tx.Exec(`
+		UPDATE documents dst
+		SET document_cover_url     = src.document_cover_url,
+		    document_cover_path    = src.document_cover_path,
+		    document_cover_bucket  = src.document_cover_bucket,
+		    document_cover_storage = src.document_cover_storage
+		FROM documents src
+		WHERE dst.document_id = $2
+		  AND src.document_id = $1
+		  AND src.document_cover_url IS NOT NULL AND src.document_cover_url <> ''
+		  AND src.document_user_id = dst.document_user_id
+	`, srcID, dstID)
+	if err != nil {
+		return out, err
+	}
+	n, _ = res.RowsAffected()
+	out.Cover = n > 0
+
+	// ข้อความรายหน้า (pdftext) → เติม content_text ถ้า features ยังไม่ได้มาจาก AI
+	res, err = tx.Exec(`
+		INSERT INTO document_pages (document_id, page_no, page_text)
+		SELECT $2, page_no, page_text
+		FROM document_pages
+		WHERE document_id = $1
+		ON CONFLICT DO NOTHING
+	`, srcID, dstID)
+	if err != nil {
+		return out, err
+	}
+	if n, _ = res.RowsAffected(); n > 0 {
+		if _, err := tx.Exec(`
+			UPDATE document_features
+			SET content_text = (
+				SELECT string_agg(page_text, E'\n\n' ORDER BY page_no)
+				FROM document_pages
+				WHERE document_id = $1
+			)
+			WHERE document_id = $1
+			  AND COALESCE(content_text, '') = ''
+		`, dstID); err != nil {
+			return out, err
+		}
+		out.Text = true
+	}
+
+	return out, tx.Commit()
+}
+
+// อัปเวอร์ชันใหม่ของ family แล้วให้โพสต์ที่ผูกกับ family ชี้เวอร์ชันนี้
+func (r *fileRepository) CreateVersion(familyID int, req *models.Document) (*models.Document, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// ล็อกทุกเวอร์ชันของ family กันอัปพร้อมกันแล้วได้เลขซ้ำ
+	var last sql.NullInt64
+	if err := lockDedupSource(tx, req); err != nil {
+		return nil, err
+	}
+
+	if err := tx.QueryRow(`
+		SELECT MAX(document_version)
+		FROM (
+			SELECT document_version FROM documents
+			WHERE document_family_id = $1
+			FOR UPDATE
+		) v
+	`, familyID).Scan(&last); err != nil {
+		return nil, err
+	}
+	if !last.Valid {
+		return nil, sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE documents SET document_is_current = FALSE
+		WHERE document_family_id = $1 AND document_is_current
+	`, familyID); err != nil {
+		return nil, err
+	}
+
+	if err := insertDocument(tx, req, familyID, int(last.Int64)+1); err != nil {
+		return nil, fmt.Errorf("ไม่สามารถบันทึกไฟล์ได้: %v", err)
+	}
+	if err := completeUploadSession(tx, req); err != nil {
+		return nil, err
+	}
+	if err := repointPosts(tx, familyID, req.DocumentID); err != nil {
+		return nil, err
+	}
+	return req, tx.Commit()
+}
+
+// ทุกเวอร์ชันของ family (ใหม่สุดก่อน)
+func (r *fileRepository) ListVersions(familyID int) ([]models.Document, error) {
+	rows, err := r.db.Query(`
+		SELECT `+documentColumns+`
+		FROM documents
+		WHERE document_family_id = $1
+		ORDER BY document_version DESC
+	`, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []models.Document
+	for rows.Next() {
+		d, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, *d)
+	}
+	return docs, rows.Err()
+}
+
+// restore: ให้เวอร์ชันนี้เป็นเวอร์ชันปัจจุบันของ family
+func (r *fileRepository) SetCurrentVersion(documentID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var familyID int
+	if err := tx.QueryRow(`
+		SELECT document_family_id FROM documents WHERE document_id = $1 FOR UPDATE
+	`, documentID).Scan(&familyID); err != nil {
+		return err
+	}
+	if err := setCurrent(tx, familyID, documentID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ก่อนลบเวอร์ชันปัจจุบัน → เลื่อนเวอร์ชันล่าสุดที่เหลือขึ้นมาแทน (โพสต์จะได้ไม่ถูกลบตาม)
+func detachVersion(tx *sql.Tx, familyID, documentID int) error {
+	var nextID int
+	err := tx.QueryRow(`
+		SELECT document_id FROM documents
+		WHERE document_family_id = $1 AND document_id <> $2
+		ORDER BY document_version DESC
+		LIMIT 1
+	`, familyID, documentID).Scan(&nextID)
+	if err == sql.ErrNoRows {
+		return nil // เวอร์ชันเดียว ลบตามปกติ
+	}
+	if err != nil {
+		return err
+	}
+	return setCurrent(tx, familyID, nextID)
+}
+
+func setCurrent(tx *sql.Tx, familyID, documentID int) error {
+	if _, err := tx.Exec(`
+		UPDATE documents SET document_is_current = FALSE
+		WHERE document_family_id = $1 AND document_is_current AND document_id <> $2
+	`, familyID, documentID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE documents SET document_is_current = TRUE WHERE document_id = $1
+	`, documentID); err != nil {
+		return err
+	}
+	return repointPosts(tx, familyID, documentID)
+}
+
+func repointPosts(tx *sql.Tx, familyID, documentID int) error {
+	_, err := tx.Exec(`
+		UPDATE posts SET post_document_id = $2, post_updated_at = now()
+		WHERE post_document_family_id = $1 AND post_document_id IS DISTINCT FROM $2
+	`, familyID, documentID)
+	return err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"chaladshare_backend/internal/files/models"
+)
+
+type UploadSessionRepository interface {
+	Create(s *models.UploadSession) error
+	GetByID(uploadID string) (*models.UploadSession, error)
+	SetReceived(uploadID string, received int64) error
+	Delete(uploadID string) error
+	// session ที่หมดอายุ (janitor ลบไฟล์ที่ค้างแล้วลบแถว)
+	ListExpired(now time.Time, limit int) ([]string, error)
+}
+
+type uploadSessionRepository struct {
+	db *sql.DB
+}
+
+func NewUploadSessionRepository(db *sql.DB) UploadSessionRepository {
+	return &uploadSessionRepository{db: db}
+}
+
+func (r *uploadSessionRepository) Create(s *models.UploadSession) error {
+	return r.db.QueryRow(`
+		INSERT INTO upload_sessions (upload_id, user_id, file_name, total_size, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`, s.UploadID, s.UserID, s.FileName, s.TotalSize, s.ExpiresAt).Scan(&s.CreatedAt)
+}
+
+func (r *uploadSessionRepository) GetByID(uploadID string) (*models.UploadSession, error) {
+	var s models.UploadSession
+	err := r.db.QueryRow(`
+		SELECT upload_id, user_id, file_name, total_size, received, document_id,
+			created_at, expires_at, completed_at
+		FROM upload_sessions
+		WHERE upload_id = $1
+	`, uploadID).Scan(&s.UploadID, &s.UserID, &s.FileName, &s.TotalSize, &s.Received, &s.DocumentID,
+		&s.CreatedAt, &s.ExpiresAt, &s.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *uploadSessionRepository) SetReceived(uploadID string, received int64) error {
+	_, err := r.db.Exec(`
+		UPDATE upload_sessions SET received = $2, updated_at = now()
+		WHERE upload_id = $1
+	`, uploadID, received)
+	return err
+}
+
+func (r *uploadSessionRepository) Delete(uploadID string) error {
+	_, err := r.db.Exec(`DELETE FROM upload_sessions WHERE upload_id = $1`, uploadID)
+	return err
+}
+
+func (r *uploadSessionRepository) ListExpired(now time.Time, limit int) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT upload_id
+		FROM upload_sessions
+		WHERE expires_at < $1
+		ORDER BY expires_at
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
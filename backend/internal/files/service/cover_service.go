@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"chaladshare_backend/internal/convert"
+	"chaladshare_backend/internal/files/repository"
+	"chaladshare_backend/internal/imaging"
+	"chaladshare_backend/internal/storage"
+
+	jobModels "chaladshare_backend/internal/jobs/models"
+)
+
+// processor ของงาน render_cover: วาดหน้าแรกของ PDF เป็นหน้าปกสำรอง
+type CoverService interface {
+	ProcessDocument(in jobModels.Input) error
+	MarkFailed(documentID int, msg string) error
+}
+
+type coverService struct {
+	filerepo repository.FileRepository
+	storage  *storage.Registry
+	renderer *imaging.PDFRenderer
+}
+
+func NewCoverService(filerepo repository.FileRepository, storage *storage.Registry, renderer *imaging.PDFRenderer) CoverService {
+	return &coverService{filerepo: filerepo, storage: storage, renderer: renderer}
+}
+
+func (s *coverService) ProcessDocument(in jobModels.Input) error {
+	documentID, pdfPath := in.DocumentID, in.Path
+	// ไฟล์ที่แปลงเป็น PDF ไม่ได้ (Markdown / DOCX ที่ไม่มี LibreOffice) ไม่มีหน้าให้วาด
+	if in.MIME != convert.MIMEPDF {
+		log.Printf("[COVER] doc=%d skip: %s has no page to render", documentID, in.OriginalMIME)
+		return nil
+	}
+
+	doc, err := s.filerepo.GetDocumentByID(documentID)
+	if err != nil {
+		return fmt.Errorf("get document: %w", err)
+	}
+	if doc.CoverURL != nil && *doc.CoverURL != "" {
+		return nil
+	}
+
+	page, err := s.renderer.RenderFirstPage(context.Background(), pdfPath, filepath.Dir(pdfPath))
+	if err != nil {
+		return fmt.Errorf("render page 1: %w", err)
+	}
+	defer os.Remove(page)
+
+	outs, err := imaging.Process(page, imaging.CoverVariants, false, filepath.Dir(page))
+	if err != nil {
+		return fmt.Errorf("process cover: %w", err)
+	}
+	defer imaging.Cleanup(outs)
+
+	st, err := s.storage.Default()
+	if err != nil {
+		return err
+	}
+	// อยู่ใต้ covers/{owner}/ เหมือนหน้าปกที่ผู้ใช้อัปเอง
+	prefix := fmt.Sprintf("covers/%d/doc-%d", doc.DocumentUserID, documentID)
+
+	var fullURL, fullPath string
+	for _, o := range outs {
+		objectPath := imaging.ObjectPath(prefix, o.Name)
+		u, err := st.UploadLocalFile(context.Background(), objectPath, o.Path)
+		if err != nil {
+			return fmt.Errorf("upload cover %s: %w", o.Name, err)
+		}
+		if imaging.IsFull(o.Name) {
+			fullURL, fullPath = u, objectPath
+		}
+	}
+
+	n, err := s.filerepo.SetDocumentCover(documentID, fullURL, fullPath, st.Bucket(), st.Provider())
+	if err != nil {
+		return fmt.Errorf("save cover: %w", err)
+	}
+	log.Printf("[COVER] doc=%d rendered by %s, applied to %d post(s)", documentID, s.renderer.Name(), n)
+	return nil
+}
+
+// หน้าปกเป็นของเสริม ไม่มีสถานะให้อัปเดต แค่ log ไว้
+func (s *coverService) MarkFailed(documentID int, msg string) error {
+	log.Printf("[COVER] doc=%d failed: %s", documentID, msg)
+	return nil
+}
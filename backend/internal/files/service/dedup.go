@@ -0,0 +1,51 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"chaladshare_backend/internal/files/models"
+)
+
+// sha256 ของไฟล์ (hex ตัวเล็ก) ใช้เป็น content_hash
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// หาเอกสารเดิม (ของใครก็ได้) ที่ใช้ object ร่วมได้ (provider ต้องตรงถ้าระบุมา และ storage นั้นยังตั้งค่าอยู่)
+// หาไม่เจอหรือ error → อัปไฟล์ใหม่ตามปกติ
+func (s *fileService) findDuplicate(userID int, hash, provider string) *models.Document {
+	src, err := s.filerepo.FindByContentHash(userID, hash)
+	if err != nil {
+		log.Printf("[UPLOAD] find duplicate hash=%s err=%v", hash, err)
+		return nil
+	}
+	if src == nil {
+		return nil
+	}
+	if provider != "" && !strings.EqualFold(provider, src.StorageProvider) {
+		return nil
+	}
+	obj, ok := documentObject(src)
+	if !ok {
+		return nil
+	}
+	if _, err := s.storage.ForObject(obj.Provider, obj.Bucket); err != nil {
+		return nil
+	}
+	return src
+}
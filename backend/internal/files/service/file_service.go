@@ -4,20 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"mime"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"chaladshare_backend/internal/convert"
 	"chaladshare_backend/internal/files/models"
 	"chaladshare_backend/internal/files/repository"
+	"chaladshare_backend/internal/imaging"
+	"chaladshare_backend/internal/storage"
 
 	docfeaturesService "chaladshare_backend/internal/docfeatures/service"
+	jobModels "chaladshare_backend/internal/jobs/models"
+	jobService "chaladshare_backend/internal/jobs/service"
+	summaryService "chaladshare_backend/internal/summaries/service"
 
 	"github.com/google/uuid"
 )
 
 type FileService interface {
 	UploadFile(req *models.UploadRequest) (*models.UploadResponse, error)
+	UploadCover(userID int, localPath string) (publicURL string, provider string, err error)
+	UploadAvatar(userID int, localPath string) (publicURL string, provider string, err error)
 	GetFilesByUserID(userID int) ([]models.Document, error)
 	DeleteFile(documentID int) error
 
@@ -27,15 +37,35 @@ type FileService interface {
 	GetSummaryByDocumentID(docID int) (*models.Summary, error)
 
 	IsOwner(documentID int, userID int) (bool, error)
+	MaxUploadBytes(kind string) int64
+	GetPostIDsByDocumentID(documentID int) ([]int, error)
+	OpenDocumentContent(ctx context.Context, documentID int) (*models.DocumentContent, error)
+
+	// เวอร์ชันของเอกสาร
+	UploadVersion(documentID int, req *models.UploadRequest) (*models.UploadResponse, error)
+	ListVersions(documentID int) ([]models.Document, error)
+	RestoreVersion(documentID int) (*models.Document, error)
 }
 
+// เอกสารเป็นลิงก์ภายนอก ไม่มีไฟล์ใน storage ให้ stream
+var ErrNoStoredContent = errors.New("document has no stored content")
+
 type fileService struct {
 	filerepo   repository.FileRepository
 	featureSvc docfeaturesService.FeatureService
+	summarySvc summaryService.SummaryService
+	jobSvc     jobService.JobService
+	storage    *storage.Registry
+	limits     UploadLimits
+}
+
+func NewFileService(filerepo repository.FileRepository, featureSvc docfeaturesService.FeatureService, summarySvc summaryService.SummaryService, jobSvc jobService.JobService, storage *storage.Registry, limits UploadLimits) FileService {
+	return &fileService{filerepo: filerepo, featureSvc: featureSvc, summarySvc: summarySvc, jobSvc: jobSvc, storage: storage, limits: limits}
 }
 
-func NewFileService(filerepo repository.FileRepository, featureSvc docfeaturesService.FeatureService) FileService {
-	return &fileService{filerepo: filerepo, featureSvc: featureSvc}
+// handler ใช้จำกัดขนาด body ก่อน parse multipart
+func (s *fileService) MaxUploadBytes(kind string) int64 {
+	return s.limits.forKind(kind)
 }
 
 func (s *fileService) UploadFile(req *models.UploadRequest) (*models.UploadResponse, error) {
@@ -43,38 +73,47 @@ func (s *fileService) UploadFile(req *models.UploadRequest) (*models.UploadRespo
 		return nil, errors.New("ต้องระบุชื่อไฟล์")
 	}
 
+	hasLocal := strings.TrimSpace(req.LocalPath) != ""
 	provider := strings.ToLower(strings.TrimSpace(req.StorageProvider))
-	if provider == "" {
-		provider = "local"
-	}
 
-	// local มี URL เหมือนเดิม
-	if provider != "supabase" && strings.TrimSpace(req.DocumentURL) == "" {
+	// ไม่มีไฟล์ในมือ = ลิงก์ภายนอก ต้องมี URL มาเอง
+	if !hasLocal && strings.TrimSpace(req.DocumentURL) == "" {
 		return nil, errors.New("ต้องระบุ URL ของไฟล์")
 	}
 
-	// supabase ใช้ LocalPath อัปก่อน แล้วค่อยได้ URL
-	if provider == "supabase" {
-		if strings.TrimSpace(req.LocalPath) == "" {
-			return nil, errors.New("ต้องมี LocalPath เพื่ออัปขึ้น Supabase")
-		}
-
-		st, err := NewSupabaseStorageFromEnv()
+	// มีไฟล์ temp → อัปขึ้น storage ตาม provider (ว่าง = ค่า default จาก config)
+	var docPath, docBucket, contentHash *string
+	var docSize *int64
+	var docPages *int
+	var source *models.Document
+	var ext string
+	docMIME := convert.MIMEByExt(req.DocumentURL)
+	if hasLocal {
+		// ตรวจไฟล์ก่อนอัป: ดูชนิดจาก magic byte (PDF ต้องไม่เข้ารหัส) และไม่เกินขนาด
+		info, err := inspectDocument(req.LocalPath, req.DocumentName, s.limits.MaxDocumentBytes)
 		if err != nil {
-			return nil, fmt.Errorf("supabase storage not configured: %v", err)
-		}
-
-		ext := strings.ToLower(filepath.Ext(req.LocalPath))
-		if ext == "" {
-			ext = ".pdf"
+			return nil, err
 		}
-		objectPath := fmt.Sprintf("documents/%d/%s%s", req.UserID, uuid.NewString(), ext)
+		docSize, docPages, docMIME = &info.Size, info.Pages, info.MIME
 
-		publicURL, err := st.UploadLocalFile(context.Background(), objectPath, req.LocalPath)
+		hash, err := hashFile(req.LocalPath)
 		if err != nil {
-			return nil, fmt.Errorf("อัปขึ้น Supabase ไม่สำเร็จ: %v", err)
+			return nil, fmt.Errorf("อ่านไฟล์ไม่สำเร็จ: %v", err)
+		}
+		contentHash = &hash
+
+		// ไฟล์เดียวกับที่มีอยู่แล้ว → ชี้ object เดิม ไม่อัปซ้ำ
+		// ไม่คัดลอก URL เดิม (path documents/{uid}/... บอกว่าใครอัป) repo ใส่ route /content ให้
+		if source = s.findDuplicate(req.UserID, hash, provider); source != nil {
+			provider = source.StorageProvider
+			req.DocumentURL = ""
+			docPath, docBucket = source.DocumentPath, source.DocumentBucket
+		} else if provider, docPath, docBucket, err = s.uploadObject(req, provider, info.Ext); err != nil {
+			return nil, err
 		}
-		req.DocumentURL = publicURL
+		ext = info.Ext
+	} else if provider == "" {
+		provider = storage.ProviderLocal
 	}
 
 	doc := &models.Document{
@@ -82,9 +121,33 @@ func (s *fileService) UploadFile(req *models.UploadRequest) (*models.UploadRespo
 		DocumentName:    req.DocumentName,
 		DocumentURL:     req.DocumentURL,
 		StorageProvider: provider,
+		DocumentMIME:    docMIME,
+		DocumentPath:    docPath,
+		DocumentBucket:  docBucket,
+		DocumentSize:    docSize,
+		DocumentPages:   docPages,
+		ContentHash:     contentHash,
+	}
+	if source != nil {
+		doc.DedupSourceID = &source.DocumentID
+	}
+	if req.UploadID != "" {
+		doc.UploadID = &req.UploadID
 	}
 
-	savedDoc, err := s.filerepo.CreateDocument(doc)
+	savedDoc, err := s.createDocument(req.FamilyID, doc)
+	if errors.Is(err, repository.ErrDedupSourceGone) {
+		// ต้นทางถูกลบไประหว่างนี้ (object อาจหายไปแล้ว) → อัปไฟล์เองแล้วบันทึกใหม่
+		source, doc.DedupSourceID = nil, nil
+		if doc.StorageProvider, doc.DocumentPath, doc.DocumentBucket, err = s.uploadObject(req, provider, ext); err != nil {
+			return nil, err
+		}
+		doc.DocumentURL = req.DocumentURL
+		savedDoc, err = s.createDocument(req.FamilyID, doc)
+	}
+	if errors.Is(err, repository.ErrUploadSessionDone) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("บันทึกไฟล์ไม่สำเร็จ: %v", err)
 	}
@@ -93,36 +156,173 @@ func (s *fileService) UploadFile(req *models.UploadRequest) (*models.UploadRespo
 		return nil, fmt.Errorf("สร้าง document_features ไม่สำเร็จ: %v", err)
 	}
 
-	pdfPath := req.LocalPath
-	if strings.TrimSpace(pdfPath) == "" {
-		pdfPath = "." + savedDoc.DocumentURL
+	if s.summarySvc != nil {
+		if err := s.summarySvc.CreateQueued(savedDoc.DocumentID); err != nil {
+			return nil, fmt.Errorf("สร้าง summary_status ไม่สำเร็จ: %v", err)
+		}
 	}
 
-	// ถ้าใช้ temp file (supabase) แนะนำลบหลัง process เสร็จ
-	go func(docID int, path string, cleanup bool) {
-		s.featureSvc.ProcessDocument(docID, path)
-		if cleanup {
-			_ = os.Remove(path)
+	// ใช้ style vector / embedding / ข้อความ / summary / หน้าปกของไฟล์เดิม ส่วนที่ยังไม่เสร็จค่อยเข้าคิวเอง
+	var copied models.DerivedCopy
+	if source != nil {
+		if copied, err = s.filerepo.CopyDerivedData(source.DocumentID, savedDoc.DocumentID); err != nil {
+			log.Printf("[UPLOAD] copy derived data src=%d dst=%d err=%v", source.DocumentID, savedDoc.DocumentID, err)
+			copied = models.DerivedCopy{}
+		}
+		if copied.Cover {
+			savedDoc.CoverURL = source.CoverURL
 		}
-	}(savedDoc.DocumentID, pdfPath, provider == "supabase")
+	}
+
+	// ส่งเข้าคิว document_jobs (รอด restart) ไฟล์ temp worker จะลบเองเมื่อทุกงานใช้เสร็จ
+	// ไม่มีไฟล์ temp → worker โหลดจาก storage / URL เอง
+	var jobTypes []string
+	if !copied.Text {
+		jobTypes = append(jobTypes, jobModels.JobExtractText)
+	}
+	if !copied.Features {
+		jobTypes = append(jobTypes, jobModels.JobExtractFeatures)
+	}
+	if s.summarySvc != nil && !copied.Summary {
+		jobTypes = append(jobTypes, jobModels.JobSummarize)
+	}
+	if !copied.Cover && s.jobSvc.Handles(jobModels.JobRenderCover) {
+		jobTypes = append(jobTypes, jobModels.JobRenderCover)
+	}
+	for _, jt := range jobTypes {
+		if err := s.jobSvc.Enqueue(jobModels.EnqueueInput{
+			DocumentID:  savedDoc.DocumentID,
+			JobType:     jt,
+			LocalPath:   req.LocalPath,
+			LocalIsTemp: hasLocal,
+		}); err != nil {
+			return nil, fmt.Errorf("เข้าคิวประมวลผลไม่สำเร็จ: %v", err)
+		}
+	}
+	// ได้ผลครบจากไฟล์เดิมแล้ว ไม่มี worker มาลบไฟล์ temp ให้
+	if hasLocal && len(jobTypes) == 0 {
+		_ = os.Remove(req.LocalPath)
+	}
+
+	// bucket private → ตอบกลับด้วย signed URL (ใน DB ยังเก็บ URL เดิม)
+	file := *savedDoc
+	if err := s.signDocumentURL(&file); err != nil {
+		return nil, err
+	}
 
 	resp := &models.UploadResponse{
-		Message:    "อัปโหลดไฟล์สำเร็จ",
-		File:       *savedDoc,
-		FileURL:    savedDoc.DocumentURL,
-		DocumentID: savedDoc.DocumentID,
+		Message:      "อัปโหลดไฟล์สำเร็จ",
+		File:         file,
+		FileURL:      file.DocumentURL,
+		DocumentID:   savedDoc.DocumentID,
+		Deduplicated: source != nil && source.DocumentUserID == req.UserID,
 	}
 	return resp, nil
 }
 
+func (s *fileService) signDocumentURL(doc *models.Document) error {
+	obj, ok := documentObject(doc)
+	if ok && s.storage.ProxyDocuments() {
+		doc.DocumentURL = storage.ContentPath(doc.DocumentID)
+		return nil
+	}
+	u, err := s.storage.DocumentURL(context.Background(), obj, doc.DocumentURL)
+	if err != nil {
+		return fmt.Errorf("สร้างลิงก์ดาวน์โหลดไม่สำเร็จ: %v", err)
+	}
+	doc.DocumentURL = u
+	return nil
+}
+
+// หน้าปกแต่ละรูปได้โฟลเดอร์ของตัวเอง: covers/{uid}/{uuid}/{thumb,medium,full}.jpg
+func (s *fileService) UploadCover(userID int, localPath string) (string, string, error) {
+	prefix := fmt.Sprintf("covers/%d/%s", userID, uuid.NewString())
+	return s.uploadImage(prefix, localPath, imaging.CoverVariants, false)
+}
+
+// avatar ได้โฟลเดอร์ใหม่ทุกครั้ง (URL เปลี่ยน cache เก่าไม่ค้าง): avatars/{uid}/{uuid}/{thumb,medium,full}.jpg
+// ของเดิมลบตอนอัปเดตโปรไฟล์ไปใช้รูปใหม่แล้ว
+func (s *fileService) UploadAvatar(userID int, localPath string) (string, string, error) {
+	prefix := fmt.Sprintf("avatars/%d/%s", userID, uuid.NewString())
+	return s.uploadImage(prefix, localPath, imaging.AvatarVariants, true)
+}
+
+// ตรวจ magic byte → ย่อ/หมุน/ตัด EXIF → อัปทุกขนาด คืน URL ของ full
+func (s *fileService) uploadImage(prefix, localPath string, variants []imaging.Variant, square bool) (string, string, error) {
+	ext, err := inspectImage(localPath, s.limits.MaxImageBytes)
+	if err != nil {
+		return "", "", err
+	}
+	// webp ย่อ/ตัดสี่เหลี่ยมไม่ได้ (stdlib ไม่มี codec) ปล่อยผ่านไปก็ได้รูปต้นฉบับเต็มขนาด → ไม่รับ
+	if ext == ".webp" {
+		return "", "", fmt.Errorf("%w: webp is not supported for covers and avatars, use jpeg or png", ErrUnsupportedType)
+	}
+	st, err := s.storage.Default()
+	if err != nil {
+		return "", "", err
+	}
+
+	outs, err := imaging.Process(localPath, variants, square, filepath.Dir(localPath))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	defer imaging.Cleanup(outs)
+
+	var fullURL string
+	for _, o := range outs {
+		u, err := st.UploadLocalFile(context.Background(), imaging.ObjectPath(prefix, o.Name), o.Path)
+		if err != nil {
+			return "", "", fmt.Errorf("อัปโหลดรูป %s ขึ้น %s ไม่สำเร็จ: %v", o.Name, st.Provider(), err)
+		}
+		if imaging.IsFull(o.Name) {
+			fullURL = u
+		}
+	}
+	return fullURL, st.Provider(), nil
+}
+
 func (s *fileService) GetFilesByUserID(userID int) ([]models.Document, error) {
 	files, err := s.filerepo.GetListDocByUserID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("ไม่สามารถดึงข้อมูลไฟล์ได้: %v", err)
 	}
+	for i := range files {
+		if err := s.signDocumentURL(&files[i]); err != nil {
+			return nil, err
+		}
+	}
 	return files, nil
 }
 
+// อัปไฟล์ temp ขึ้น storage ตาม provider (ว่าง = ค่า default) ตั้ง req.DocumentURL ให้ด้วย
+func (s *fileService) uploadObject(req *models.UploadRequest, provider, ext string) (string, *string, *string, error) {
+	st, err := s.storage.DocumentClient(provider)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	provider = st.Provider()
+
+	objectPath := fmt.Sprintf("documents/%d/%s%s", req.UserID, uuid.NewString(), ext)
+
+	publicURL, err := st.UploadLocalFile(context.Background(), objectPath, req.LocalPath)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("อัปโหลดไฟล์ขึ้น %s ไม่สำเร็จ: %v", provider, err)
+	}
+	req.DocumentURL = publicURL
+	var bucket *string
+	if b := st.Bucket(); b != "" {
+		bucket = &b
+	}
+	return provider, &objectPath, bucket, nil
+}
+
+func (s *fileService) createDocument(familyID int, doc *models.Document) (*models.Document, error) {
+	if familyID > 0 {
+		return s.filerepo.CreateVersion(familyID, doc)
+	}
+	return s.filerepo.CreateDocument(doc)
+}
+
 func (s *fileService) DeleteFile(documentID int) error {
 	if documentID <= 0 {
 		return errors.New("document_id ไม่ถูกต้อง")
@@ -133,37 +333,53 @@ func (s *fileService) DeleteFile(documentID int) error {
 		return fmt.Errorf("ไม่พบเอกสาร: %v", err)
 	}
 
-	// local delete
-	if strings.EqualFold(doc.StorageProvider, "local") && strings.TrimSpace(doc.DocumentURL) != "" {
-		p := filepath.Clean("." + doc.DocumentURL)
-		_ = os.Remove(p)
-	}
-
-	// supabase delete
-	if strings.EqualFold(doc.StorageProvider, "supabase") && strings.TrimSpace(doc.DocumentURL) != "" {
-		st, err := NewSupabaseStorageFromEnv()
-		if err != nil {
-			return fmt.Errorf("supabase storage not configured: %v", err)
+	// หา object ที่จะลบให้ได้ก่อนลบแถว (ลบแถวไปแล้วจะไม่เหลือข้อมูลให้ลองใหม่)
+	var st storage.Client
+	var objectPath string
+	if obj, ok := documentObject(doc); ok {
+		if st, err = s.storage.ForObject(obj.Provider, obj.Bucket); err != nil {
+			return fmt.Errorf("ลบไฟล์ไม่ได้: %v", err)
 		}
-
-		objectPath, ok := st.ObjectPathFromPublicURL(doc.DocumentURL)
-		if !ok {
-			return errors.New("ลบไฟล์ใน Supabase ไม่ได้: แปลง object path จาก DocumentURL ไม่สำเร็จ (แนะนำเพิ่ม document_path ใน DB)")
+		objectPath = obj.Path
+	} else if strings.TrimSpace(doc.DocumentURL) != "" && !strings.EqualFold(doc.StorageProvider, storage.ProviderLocal) {
+		// แถวที่ migration แกะ URL ไม่ออก → ลองแกะตาม config ปัจจุบันอีกรอบ
+		if st, err = s.storage.Get(doc.StorageProvider); err != nil {
+			return fmt.Errorf("ลบไฟล์ไม่ได้: %v", err)
 		}
-
-		if err := st.Delete(context.Background(), objectPath); err != nil {
-			return fmt.Errorf("ลบไฟล์ใน Supabase ไม่สำเร็จ: %v", err)
+		var ok bool
+		if objectPath, ok = st.ObjectPathFromPublicURL(doc.DocumentURL); !ok {
+			return fmt.Errorf("ลบไฟล์ใน %s ไม่ได้: ไม่มี document_path และแปลง object path จาก DocumentURL ไม่สำเร็จ", st.Provider())
 		}
 	}
 
-	_ = s.filerepo.DeleteSummariesByDocID(documentID)
-
-	if err := s.filerepo.DeleteDocument(documentID); err != nil {
+	// ลบเวอร์ชันปัจจุบัน → เวอร์ชันก่อนหน้าขึ้นมาแทน (ทำใน tx เดียวกับการนับ object ที่ใช้ร่วม)
+	refs, err := s.filerepo.DeleteDocument(documentID)
+	if err != nil {
 		return fmt.Errorf("ไม่สามารถลบไฟล์ได้: %v", err)
 	}
+
+	// ไฟล์ซ้ำใช้ object ร่วมกัน → ลบ object หลัง commit เมื่อไม่เหลือเอกสารอื่นอ้างถึงแล้ว
+	// ลบไม่สำเร็จ = object ค้างใน storage (แถวลบไปแล้ว) ดีกว่าเอกสารชี้ไฟล์ที่หายไป
+	if st != nil && refs == 0 {
+		if err := st.Delete(context.Background(), objectPath); err != nil {
+			log.Printf("[FILE] delete object doc=%d provider=%s path=%s err=%v", documentID, st.Provider(), objectPath, err)
+		}
+	}
 	return nil
 }
 
+// ตำแหน่งไฟล์ที่บันทึกไว้ (ไม่มี = ลิงก์ภายนอกหรือข้อมูลเก่า)
+func documentObject(doc *models.Document) (storage.Object, bool) {
+	if doc.DocumentPath == nil || strings.TrimSpace(*doc.DocumentPath) == "" {
+		return storage.Object{}, false
+	}
+	obj := storage.Object{Provider: doc.StorageProvider, Path: *doc.DocumentPath}
+	if doc.DocumentBucket != nil {
+		obj.Bucket = *doc.DocumentBucket
+	}
+	return obj, true
+}
+
 func (s *fileService) SaveSummary(summary *models.Summary) (*models.Summary, error) {
 	if summary.DocumentID == 0 {
 		return nil, errors.New("ต้องระบุ document_id")
@@ -186,7 +402,7 @@ func (s *fileService) GetSummaryByDocumentID(docID int) (*models.Summary, error)
 
 	summary, err := s.filerepo.GetSummaryByDocID(docID)
 	if err != nil {
-		return nil, fmt.Errorf("ไม่พบสรุปของไฟล์นี้: %v", err)
+		return nil, fmt.Errorf("ไม่พบสรุปของไฟล์นี้: %w", err)
 	}
 	return summary, nil
 }
@@ -210,7 +426,125 @@ func (s *fileService) IsOwner(documentID int, userID int) (bool, error) {
 	}
 	ownerID, err := s.filerepo.GetDocumentOwnerID(documentID)
 	if err != nil {
-		return false, fmt.Errorf("ตรวจสอบเจ้าของไฟล์ล้มเหลว: %v", err)
+		return false, fmt.Errorf("ตรวจสอบเจ้าของไฟล์ล้มเหลว: %w", err)
 	}
 	return ownerID == userID, nil
 }
+
+func (s *fileService) GetPostIDsByDocumentID(documentID int) ([]int, error) {
+	if documentID <= 0 {
+		return nil, errors.New("document_id ไม่ถูกต้อง")
+	}
+	ids, err := s.filerepo.GetPostIDsByDocumentID(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("ดึงโพสต์ของเอกสารล้มเหลว: %w", err)
+	}
+	return ids, nil
+}
+
+// เปิดไฟล์จาก storage แบบ seek ได้ (handler ใช้ตอบ Range / ETag)
+func (s *fileService) OpenDocumentContent(ctx context.Context, documentID int) (*models.DocumentContent, error) {
+	doc, err := s.filerepo.GetDocumentByID(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("ไม่พบเอกสาร: %w", err)
+	}
+
+	obj, ok := documentObject(doc)
+	if !ok {
+		// แถวเก่าที่ไม่มี document_path → ลองแกะจาก URL
+		if obj, ok = s.storage.Locate(doc.DocumentURL); !ok {
+			return nil, ErrNoStoredContent
+		}
+	}
+	st, err := s.storage.ForObject(obj.Provider, obj.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	body, info, err := storage.OpenSeekable(ctx, st, obj.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &models.DocumentContent{
+		Name:        doc.DocumentName,
+		ContentType: info.ContentType,
+		ETag:        info.ETag,
+		ModTime:     info.ModTime,
+		Body:        body,
+	}
+	// ชนิดที่ตรวจจาก magic byte ตอนอัปเชื่อได้มากกว่า header ของ storage
+	if doc.DocumentMIME != "" {
+		content.ContentType = doc.DocumentMIME
+	} else if content.ContentType == "" || content.ContentType == "application/octet-stream" {
+		content.ContentType = mime.TypeByExtension(strings.ToLower(filepath.Ext(doc.DocumentName)))
+		if content.ContentType == "" {
+			content.ContentType = "application/pdf"
+		}
+	}
+	if content.ModTime.IsZero() {
+		content.ModTime = doc.UploadedAt
+	}
+	if content.ETag == "" {
+		content.ETag = fmt.Sprintf(`"doc-%d-%x-%x"`, doc.DocumentID, info.Size, doc.UploadedAt.Unix())
+	}
+	return content, nil
+}
+
+// อัปไฟล์ใหม่เป็นเวอร์ชันถัดไปของเอกสาร documentID (โพสต์ที่แนบไว้จะชี้เวอร์ชันใหม่)
+// features / summary แยกตามเวอร์ชัน จึงเข้าคิวประมวลผลใหม่เหมือนอัปไฟล์ปกติ
+func (s *fileService) UploadVersion(documentID int, req *models.UploadRequest) (*models.UploadResponse, error) {
+	if documentID <= 0 {
+		return nil, errors.New("document_id ไม่ถูกต้อง")
+	}
+	if strings.TrimSpace(req.LocalPath) == "" {
+		return nil, errors.New("ต้องแนบไฟล์เวอร์ชันใหม่")
+	}
+	base, err := s.filerepo.GetDocumentByID(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("ไม่พบเอกสาร: %w", err)
+	}
+	if base.DocumentUserID != req.UserID {
+		return nil, errors.New("ไม่ใช่เจ้าของเอกสาร")
+	}
+	req.FamilyID = base.FamilyID
+	return s.UploadFile(req)
+}
+
+func (s *fileService) ListVersions(documentID int) ([]models.Document, error) {
+	if documentID <= 0 {
+		return nil, errors.New("document_id ไม่ถูกต้อง")
+	}
+	doc, err := s.filerepo.GetDocumentByID(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("ไม่พบเอกสาร: %w", err)
+	}
+	versions, err := s.filerepo.ListVersions(doc.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("ดึงเวอร์ชันของเอกสารล้มเหลว: %v", err)
+	}
+	for i := range versions {
+		if err := s.signDocumentURL(&versions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// กลับไปใช้เวอร์ชันเดิม (ไม่สร้างแถวใหม่ ผลวิเคราะห์ของเวอร์ชันนั้นยังอยู่ครบ)
+func (s *fileService) RestoreVersion(documentID int) (*models.Document, error) {
+	if documentID <= 0 {
+		return nil, errors.New("document_id ไม่ถูกต้อง")
+	}
+	if err := s.filerepo.SetCurrentVersion(documentID); err != nil {
+		return nil, fmt.Errorf("กู้คืนเวอร์ชันไม่สำเร็จ: %w", err)
+	}
+	doc, err := s.filerepo.GetDocumentByID(documentID)
+	if err != nil {
+		return nil, fmt.Errorf("ไม่พบเอกสาร: %w", err)
+	}
+	if err := s.signDocumentURL(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
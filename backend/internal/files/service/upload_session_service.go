@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"chaladshare_backend/internal/files/models"
+	"chaladshare_backend/internal/files/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUploadNotFound   = errors.New("upload session not found")
+	ErrUploadIncomplete = errors.New("upload is not complete")
+	ErrUploadCompleted  = errors.New("upload already completed")
+	// ไฟล์ที่รับมาหายระหว่าง complete ต่อไม่ได้แล้ว ต้องเริ่ม session ใหม่
+	ErrUploadLost = errors.New("upload data lost, start a new upload")
+)
+
+// offset ที่ client ส่งมาไม่ตรงกับที่ server มี → client ต้องถามใหม่แล้วส่งต่อจาก Offset
+type OffsetMismatchError struct {
+	Offset int64
+}
+
+func (e *OffsetMismatchError) Error() string {
+	return fmt.Sprintf("offset mismatch: server has %d bytes", e.Offset)
+}
+
+// อัปโหลดเอกสารใหญ่ทีละก้อน เน็ตหลุดก็ส่งต่อจาก offset เดิมได้ ครบแล้วส่งต่อให้ FileService.UploadFile
+type UploadSessionService interface {
+	Create(userID int, fileName string, size int64) (*models.UploadSession, error)
+	Get(userID int, uploadID string) (*models.UploadSession, error)
+	WriteChunk(userID int, uploadID string, offset int64, body io.Reader) (int64, error)
+	Complete(userID int, uploadID string) (*models.UploadResponse, error)
+	Abort(userID int, uploadID string) error
+	StartJanitor(ctx context.Context)
+}
+
+type uploadSessionService struct {
+	repo    repository.UploadSessionRepository
+	fileSvc FileService
+	dir     string
+	ttl     time.Duration
+
+	locks sync.Map // upload_id → *sync.Mutex (PATCH / complete ของ session เดียวกันห้ามทับกัน)
+}
+
+func NewUploadSessionService(repo repository.UploadSessionRepository, fileSvc FileService, ttl time.Duration) UploadSessionService {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &uploadSessionService{
+		repo:    repo,
+		fileSvc: fileSvc,
+		dir:     filepath.Join(os.TempDir(), "chaladshare", "uploads"),
+		ttl:     ttl,
+	}
+}
+
+func (s *uploadSessionService) partPath(uploadID string) string {
+	return filepath.Join(s.dir, uploadID+".part")
+}
+
+func (s *uploadSessionService) lock(uploadID string) func() {
+	m, _ := s.locks.LoadOrStore(uploadID, &sync.Mutex{})
+	mu := m.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (s *uploadSessionService) Create(userID int, fileName string, size int64) (*models.UploadSession, error) {
+	fileName = strings.TrimSpace(filepath.Base(fileName))
+	if fileName == "" || fileName == "." {
+		return nil, errors.New("ต้องระบุชื่อไฟล์")
+	}
+	if size <= 0 {
+		return nil, errors.New("ขนาดไฟล์ไม่ถูกต้อง")
+	}
+	if limit := s.fileSvc.MaxUploadBytes(KindDocument); limit > 0 && size > limit {
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", ErrFileTooLarge, size, limit)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("สร้าง temp dir ไม่ได้: %v", err)
+	}
+
+	sess := &models.UploadSession{
+		UploadID:  uuid.NewString(),
+		UserID:    userID,
+		FileName:  fileName,
+		TotalSize: size,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	f, err := os.OpenFile(s.partPath(sess.UploadID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("สร้างไฟล์ชั่วคราวไม่ได้: %v", err)
+	}
+	f.Close()
+
+	if err := s.repo.Create(sess); err != nil {
+		_ = os.Remove(s.partPath(sess.UploadID))
+		return nil, fmt.Errorf("สร้าง upload session ไม่สำเร็จ: %v", err)
+	}
+	return sess, nil
+}
+
+// session ของคนอื่น / หมดอายุ = ไม่พบ (ไม่บอกว่ามีอยู่)
+func (s *uploadSessionService) Get(userID int, uploadID string) (*models.UploadSession, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return nil, ErrUploadNotFound
+	}
+	sess, err := s.repo.GetByID(uploadID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sess.UserID != userID || (sess.CompletedAt == nil && time.Now().After(sess.ExpiresAt)) {
+		return nil, ErrUploadNotFound
+	}
+
+	// ขนาดไฟล์บนดิสก์คือของจริง (DB อาจช้ากว่าถ้าเขียนค้างตอนเน็ตหลุด)
+	if sess.CompletedAt == nil {
+		if fi, err := os.Stat(s.partPath(uploadID)); err == nil {
+			sess.Received = fi.Size()
+		} else {
+			return nil, ErrUploadNotFound
+		}
+	}
+	return sess, nil
+}
+
+// เขียนต่อท้ายที่ offset คืน offset ใหม่ (เขียนได้ครึ่งก้อนก็เก็บไว้ให้ส่งต่อ)
+func (s *uploadSessionService) WriteChunk(userID int, uploadID string, offset int64, body io.Reader) (int64, error) {
+	unlock := s.lock(uploadID)
+	defer unlock()
+
+	sess, err := s.Get(userID, uploadID)
+	if err != nil {
+		return 0, err
+	}
+	if sess.CompletedAt != nil {
+		return sess.Received, ErrUploadCompleted
+	}
+	if offset != sess.Received {
+		return sess.Received, &OffsetMismatchError{Offset: sess.Received}
+	}
+
+	f, err := os.OpenFile(s.partPath(uploadID), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return offset, err
+	}
+	remaining := sess.TotalSize - offset
+	n, werr := io.Copy(f, io.LimitReader(body, remaining+1))
+	if n > remaining {
+		// ส่งเกินขนาดที่ประกาศไว้ → ตัดก้อนนี้ทิ้งทั้งก้อน
+		_ = f.Truncate(offset)
+		f.Close()
+		return offset, fmt.Errorf("%w: chunk exceeds declared size %d", ErrFileTooLarge, sess.TotalSize)
+	}
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+
+	newOffset := offset + n
+	if err := s.repo.SetReceived(uploadID, newOffset); err != nil {
+		log.Printf("[UPLOAD] save offset upload=%s err=%v", uploadID, err)
+	}
+	return newOffset, werr
+}
+
+// ได้ครบแล้ว → ย้ายไฟล์แล้วส่งเข้า UploadFile เหมือนอัปทีเดียว (เรียกซ้ำได้)
+func (s *uploadSessionService) Complete(userID int, uploadID string) (*models.UploadResponse, error) {
+	unlock := s.lock(uploadID)
+	defer unlock()
+
+	sess, err := s.Get(userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if sess.CompletedAt != nil && sess.DocumentID != nil {
+		return &models.UploadResponse{Message: "อัปโหลดไฟล์สำเร็จ", DocumentID: *sess.DocumentID}, nil
+	}
+	if sess.Received != sess.TotalSize {
+		return nil, fmt.Errorf("%w: %d/%d bytes", ErrUploadIncomplete, sess.Received, sess.TotalSize)
+	}
+
+	// ตั้งชื่อแบบเดียวกับ /files/doc ไฟล์นี้ worker จะลบเองเมื่อประมวลผลเสร็จ
+	abs := filepath.Join(filepath.Dir(s.dir), TempFileName(sess.FileName))
+	if err := os.Rename(s.partPath(uploadID), abs); err != nil {
+		return nil, fmt.Errorf("ย้ายไฟล์ไม่สำเร็จ: %v", err)
+	}
+
+	// repo บันทึก document_id ลง session ใน tx เดียวกับเอกสาร
+	resp, err := s.fileSvc.UploadFile(&models.UploadRequest{
+		UserID:       userID,
+		DocumentName: sess.FileName,
+		LocalPath:    abs,
+		UploadID:     uploadID,
+	})
+	if err != nil {
+		return nil, s.restorePart(userID, uploadID, abs, err)
+	}
+	return resp, nil
+}
+
+// UploadFile ล้ม: ถ้าเอกสารถูกสร้างแล้ว (พังหลัง commit) ไฟล์เป็นของงานประมวลผลไปแล้ว ไม่ย้ายคืน
+// ไม่งั้นคืนไฟล์ไว้ที่เดิม client จะลอง complete ใหม่หรือยกเลิกก็ได้
+// ย้ายคืนไม่ได้ = ข้อมูลหาย ปิด session แล้วบอก client ให้เริ่มอัปใหม่
+func (s *uploadSessionService) restorePart(userID int, uploadID, abs string, cause error) error {
+	if errors.Is(cause, repository.ErrUploadSessionDone) {
+		_ = os.Remove(abs)
+		return ErrUploadCompleted
+	}
+	if sess, err := s.Get(userID, uploadID); err == nil && sess.DocumentID != nil {
+		return cause
+	}
+	if err := os.Rename(abs, s.partPath(uploadID)); err != nil {
+		log.Printf("[UPLOAD] restore part upload=%s err=%v (upload failed: %v)", uploadID, err, cause)
+		_ = os.Remove(abs)
+		if derr := s.repo.Delete(uploadID); derr != nil {
+			log.Printf("[UPLOAD] delete lost upload=%s err=%v", uploadID, derr)
+		}
+		return fmt.Errorf("%w: %v", ErrUploadLost, cause)
+	}
+	return cause
+}
+
+func (s *uploadSessionService) Abort(userID int, uploadID string) error {
+	unlock := s.lock(uploadID)
+	defer unlock()
+
+	if _, err := s.Get(userID, uploadID); err != nil {
+		return err
+	}
+	_ = os.Remove(s.partPath(uploadID))
+	s.locks.Delete(uploadID)
+	return s.repo.Delete(uploadID)
+}
+
+// ลบ session ที่หมดอายุ (ไฟล์ค้างใน temp dir) ทุก 15 นาที
+func (s *uploadSessionService) StartJanitor(ctx context.Context) {
+	go func() {
+		t := time.NewTicker(15 * time.Minute)
+		defer t.Stop()
+		for {
+			s.sweep()
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func (s *uploadSessionService) sweep() {
+	ids, err := s.repo.ListExpired(time.Now(), 500)
+	if err != nil {
+		log.Printf("[UPLOAD] list expired err=%v", err)
+		return
+	}
+	for _, id := range ids {
+		unlock := s.lock(id)
+		_ = os.Remove(s.partPath(id))
+		if err := s.repo.Delete(id); err != nil {
+			log.Printf("[UPLOAD] delete expired upload=%s err=%v", id, err)
+		}
+		unlock()
+		s.locks.Delete(id)
+	}
+	if len(ids) > 0 {
+		log.Printf("[UPLOAD] removed %d expired upload session(s)", len(ids))
+	}
+}
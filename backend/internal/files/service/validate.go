@@ -0,0 +1,333 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"chaladshare_backend/internal/convert"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindDocument = "document"
+	KindImage    = "image"
+)
+
+var (
+	ErrFileTooLarge    = errors.New("file too large")
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrInvalidPDF      = errors.New("invalid or corrupt pdf")
+	ErrEncryptedPDF    = errors.New("encrypted pdf is not supported")
+	ErrInvalidImage    = errors.New("invalid or corrupt image")
+	ErrInvalidDocument = errors.New("invalid or corrupt document")
+)
+
+// ขนาดไฟล์สูงสุดแยกตามชนิด (byte)
+type UploadLimits struct {
+	MaxDocumentBytes int64
+	MaxImageBytes    int64
+}
+
+func (l UploadLimits) forKind(kind string) int64 {
+	if kind == KindImage {
+		return l.MaxImageBytes
+	}
+	return l.MaxDocumentBytes
+}
+
+// ชื่อไฟล์ temp ตามนามสกุลที่ผู้ใช้ส่งมา (ชนิดจริงตรวจจาก magic byte อีกที)
+func TempFileName(original string) string {
+	ext := strings.ToLower(filepath.Ext(original))
+	if convert.MIMEByExt(ext) == "" {
+		ext = ".bin"
+	}
+	return uuid.NewString() + ext
+}
+
+// ผลตรวจ PDF ที่บันทึกลง documents
+type pdfInfo struct {
+	Size  int64
+	Pages int
+}
+
+// ผลตรวจเอกสารทุกชนิด (Pages = nil ถ้านับไม่ได้ เช่น Markdown)
+type docInfo struct {
+	Size  int64
+	Pages *int
+	MIME  string
+	Ext   string
+}
+
+var (
+	docxPagesRe = regexp.MustCompile(`<Pages>(\d+)</Pages>`)
+	pptxSlideRe = regexp.MustCompile(`^ppt/slides/slide\d+\.xml$`)
+)
+
+// ดูชนิดไฟล์จาก magic byte: PDF / DOCX / PPTX / JPEG / PNG / WebP / Markdown หรือข้อความ UTF-8
+// name ใช้แยก Markdown กับข้อความธรรมดาเท่านั้น
+func inspectDocument(path, name string, maxBytes int64) (docInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return docInfo{}, err
+	}
+	if maxBytes > 0 && fi.Size() > maxBytes {
+		return docInfo{}, fmt.Errorf("%w: %d bytes (max %d)", ErrFileTooLarge, fi.Size(), maxBytes)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return docInfo{}, err
+	}
+	head := make([]byte, 1024)
+	n, _ := io.ReadFull(f, head)
+	f.Close()
+	head = head[:n]
+
+	one := 1
+	switch {
+	case bytes.Contains(head, []byte("%PDF-")):
+		info, err := inspectPDF(path, maxBytes)
+		if err != nil {
+			return docInfo{}, err
+		}
+		return docInfo{Size: info.Size, Pages: &info.Pages, MIME: convert.MIMEPDF, Ext: ".pdf"}, nil
+
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return inspectOffice(path, fi.Size())
+
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}),
+		bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")),
+		len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		ext, err := inspectImage(path, maxBytes)
+		if err != nil {
+			return docInfo{}, err
+		}
+		return docInfo{Size: fi.Size(), Pages: &one, MIME: convert.MIMEByExt(ext), Ext: ext}, nil
+	}
+
+	// ไม่มี magic byte → รับเฉพาะข้อความ UTF-8 (ไม่มี NUL)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return docInfo{}, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if len(bytes.TrimSpace(data)) == 0 || bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return docInfo{}, fmt.Errorf("%w: expected pdf, docx, pptx, image, markdown or text", ErrUnsupportedType)
+	}
+	if convert.MIMEByExt(name) == convert.MIMEMarkdown {
+		return docInfo{Size: fi.Size(), MIME: convert.MIMEMarkdown, Ext: ".md"}, nil
+	}
+	return docInfo{Size: fi.Size(), MIME: convert.MIMEText, Ext: ".txt"}, nil
+}
+
+// zip ที่มี word/document.xml = DOCX, ppt/presentation.xml = PPTX
+func inspectOffice(path string, size int64) (docInfo, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return docInfo{}, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	defer zr.Close()
+
+	var isDocx, isPptx bool
+	var appXML *zip.File
+	slides := 0
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "word/document.xml":
+			isDocx = true
+		case f.Name == "ppt/presentation.xml":
+			isPptx = true
+		case f.Name == "docProps/app.xml":
+			appXML = f
+		case pptxSlideRe.MatchString(f.Name):
+			slides++
+		}
+	}
+
+	switch {
+	case isPptx:
+		info := docInfo{Size: size, MIME: convert.MIMEPPTX, Ext: ".pptx"}
+		if slides > 0 {
+			info.Pages = &slides
+		}
+		return info, nil
+	case isDocx:
+		info := docInfo{Size: size, MIME: convert.MIMEDOCX, Ext: ".docx"}
+		// จำนวนหน้าที่ Word บันทึกไว้ตอนเซฟ (อาจไม่มี)
+		if pages := officeAppPages(appXML); pages > 0 {
+			info.Pages = &pages
+		}
+		return info, nil
+	default:
+		return docInfo{}, fmt.Errorf("%w: zip is not a docx or pptx", ErrUnsupportedType)
+	}
+}
+
+func officeAppPages(f *zip.File) int {
+	if f == nil {
+		return 0
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return 0
+	}
+	defer rc.Close()
+	b, _ := io.ReadAll(io.LimitReader(rc, 1<<20))
+	m := docxPagesRe.FindSubmatch(b)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(m[1])))
+	return n
+}
+
+var (
+	// /Encrypt ใน trailer หรือ xref stream (ชี้ไป object หรือเป็น dict ตรงๆ)
+	pdfEncryptRe = regexp.MustCompile(`/Encrypt\s*(\d+\s+\d+\s+R|<<)`)
+	// root /Pages มี /Count มากสุด (key สลับลำดับได้)
+	pdfPagesCountRe = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+	pdfPageRe       = regexp.MustCompile(`/Type\s*/Page\b`)
+	// PDF 1.5+ เก็บ dict ของหน้าไว้ใน object stream ที่บีบอัด
+	pdfObjStmRe = regexp.MustCompile(`/Type\s*/ObjStm[^{}]*?stream\r?\n`)
+)
+
+// เช็ค magic byte / ขนาด / การเข้ารหัส แล้วนับจำนวนหน้า
+func inspectPDF(path string, maxBytes int64) (pdfInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return pdfInfo{}, err
+	}
+	if maxBytes > 0 && fi.Size() > maxBytes {
+		return pdfInfo{}, fmt.Errorf("%w: %d bytes (max %d)", ErrFileTooLarge, fi.Size(), maxBytes)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pdfInfo{}, err
+	}
+
+	// header %PDF- อยู่ใน 1024 byte แรกได้ (ตาม spec)
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return pdfInfo{}, fmt.Errorf("%w: not a pdf", ErrUnsupportedType)
+	}
+	if !bytes.Contains(data[max(0, len(data)-2048):], []byte("%%EOF")) {
+		return pdfInfo{}, fmt.Errorf("%w: missing %%%%EOF (truncated?)", ErrInvalidPDF)
+	}
+	if pdfEncryptRe.Match(data) {
+		return pdfInfo{}, ErrEncryptedPDF
+	}
+
+	pages := countPDFPages(data)
+	if pages == 0 {
+		return pdfInfo{}, fmt.Errorf("%w: no pages found", ErrInvalidPDF)
+	}
+	return pdfInfo{Size: fi.Size(), Pages: pages}, nil
+}
+
+func countPDFPages(data []byte) int {
+	chunks := append([][]byte{data}, inflateObjectStreams(data)...)
+
+	count, leaves := 0, 0
+	for _, b := range chunks {
+		for _, m := range pdfPagesCountRe.FindAllSubmatch(b, -1) {
+			v := m[1]
+			if len(v) == 0 {
+				v = m[2]
+			}
+			if n, err := strconv.Atoi(string(v)); err == nil && n > count {
+				count = n
+			}
+		}
+		leaves += len(pdfPageRe.FindAllIndex(b, -1))
+	}
+	if count > 0 {
+		return count
+	}
+	return leaves
+}
+
+// คลาย object stream (FlateDecode) ทีละอัน จำกัดขนาดกัน zip bomb
+func inflateObjectStreams(data []byte) [][]byte {
+	const maxInflated = 64 << 20
+
+	var out [][]byte
+	total := 0
+	for _, loc := range pdfObjStmRe.FindAllIndex(data, -1) {
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data[start : start+end]))
+		if err != nil {
+			continue
+		}
+		// stream เสียกลางทางก็ยังใช้ส่วนที่อ่านได้
+		b, _ := io.ReadAll(io.LimitReader(zr, int64(maxInflated-total)))
+		zr.Close()
+		out = append(out, b)
+		if total += len(b); total >= maxInflated {
+			break
+		}
+	}
+	return out
+}
+
+// ตรวจรูปจาก magic byte (JPEG / PNG / WebP) คืนนามสกุลที่ถูกต้อง
+func inspectImage(path string, maxBytes int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if maxBytes > 0 && fi.Size() > maxBytes {
+		return "", fmt.Errorf("%w: %d bytes (max %d)", ErrFileTooLarge, fi.Size(), maxBytes)
+	}
+
+	head := make([]byte, 32)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		if _, err := jpeg.DecodeConfig(f); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		return ".jpg", nil
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		if _, err := png.DecodeConfig(f); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		return ".png", nil
+	case len(head) >= 16 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		// stdlib ไม่มี decoder ของ webp เช็คแค่ chunk แรก
+		switch string(head[12:16]) {
+		case "VP8 ", "VP8L", "VP8X":
+			return ".webp", nil
+		}
+		return "", fmt.Errorf("%w: bad webp chunk", ErrInvalidImage)
+	default:
+		return "", fmt.Errorf("%w: expected jpeg, png or webp", ErrUnsupportedType)
+	}
+}
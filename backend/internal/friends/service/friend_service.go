@@ -7,6 +7,9 @@ import (
 
 	"chaladshare_backend/internal/friends/models"
 	"chaladshare_backend/internal/friends/repository"
+
+	notimodels "chaladshare_backend/internal/notifications/models"
+	notiservice "chaladshare_backend/internal/notifications/service"
 )
 
 var (
@@ -42,10 +45,11 @@ type FriendService interface {
 
 type friendsService struct {
 	friendsrepo repository.FriendRepository
+	notifier    notiservice.Notifier
 }
 
-func NewFriendService(friendsrepo repository.FriendRepository) FriendService {
-	return &friendsService{friendsrepo: friendsrepo}
+func NewFriendService(friendsrepo repository.FriendRepository, notifier notiservice.Notifier) FriendService {
+	return &friendsService{friendsrepo: friendsrepo, notifier: notifier}
 }
 
 func normalizeSearch(s string) string {
@@ -196,7 +200,12 @@ func (s *friendsService) SendFriendRequest(ctx context.Context, actorID, toUserI
 		return 0, ErrBadRequest
 	}
 
-	return s.friendsrepo.CreateFriendRequest(ctx, actorID, toUserID)
+	requestID, err := s.friendsrepo.CreateFriendRequest(ctx, actorID, toUserID)
+	if err != nil {
+		return 0, err
+	}
+	s.notifier.Notify(notimodels.Event{Type: notimodels.TypeFriendRequest, UserID: toUserID, ActorID: actorID})
+	return requestID, nil
 }
 
 func (s *friendsService) ListIncomingRequests(ctx context.Context, actorID int, page, size int) ([]models.IncomingReqItem, int, error) {
@@ -242,7 +251,11 @@ func (s *friendsService) AcceptFriendRequest(ctx context.Context, actorID, reque
 	if fr.AddresseeUserID != actorID || fr.RequestStatus != models.FRPending {
 		return ErrForbidden
 	}
-	return s.friendsrepo.AcceptFriendRequest(ctx, requestID, actorID)
+	if err := s.friendsrepo.AcceptFriendRequest(ctx, requestID, actorID); err != nil {
+		return err
+	}
+	s.notifier.Notify(notimodels.Event{Type: notimodels.TypeFriendAccept, UserID: fr.RequesterUserID, ActorID: actorID})
+	return nil
 }
 
 func (s *friendsService) DeclineFriendRequest(ctx context.Context, actorID, requestID int) error {
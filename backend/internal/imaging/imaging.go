@@ -0,0 +1,195 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // ให้ image.Decode รู้จัก png
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ขนาดรูปที่สร้าง (ด้านยาวสุดไม่เกิน MaxSide, ไม่ขยายรูปเล็ก)
+type Variant struct {
+	Name    string
+	MaxSide int
+}
+
+var (
+	CoverVariants = []Variant{
+		{Name: "thumb", MaxSide: 320},
+		{Name: "medium", MaxSide: 800},
+		{Name: "full", MaxSide: 1600},
+	}
+	// avatar ครอปเป็นสี่เหลี่ยมจัตุรัสก่อนย่อ
+	AvatarVariants = []Variant{
+		{Name: "thumb", MaxSide: 64},
+		{Name: "medium", MaxSide: 256},
+		{Name: "full", MaxSide: 512},
+	}
+)
+
+const (
+	// ชื่อไฟล์ของแต่ละขนาดใน storage: {prefix}/{name}.jpg
+	VariantExt = ".jpg"
+	fullName   = "full"
+
+	jpegQuality = 82
+	// กันรูปที่ประกาศขนาดใหญ่ผิดปกติ (decompression bomb)
+	maxPixels = 50_000_000
+)
+
+var ErrTooManyPixels = errors.New("image dimensions too large")
+
+type Output struct {
+	Name   string
+	Path   string // ไฟล์ชั่วคราว ผู้เรียกต้องลบเอง
+	Width  int
+	Height int
+}
+
+// decode → หมุนตาม EXIF → (ครอป) → ย่อ → encode JPEG ใหม่ (metadata ทั้งหมดหายไปตอน encode)
+func Process(srcPath string, variants []Variant, square bool, tmpDir string) ([]Output, error) {
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	img := flatten(src)
+	img = orient(img, jpegOrientation(data))
+	if square {
+		img = cropSquare(img)
+	}
+
+	outs := make([]Output, 0, len(variants))
+	for _, v := range variants {
+		dst := fit(img, v.MaxSide)
+
+		f, err := os.CreateTemp(tmpDir, "img-"+v.Name+"-*"+VariantExt)
+		if err != nil {
+			Cleanup(outs)
+			return nil, err
+		}
+		err = jpeg.Encode(f, dst, &jpeg.Options{Quality: jpegQuality})
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(f.Name())
+			Cleanup(outs)
+			return nil, fmt.Errorf("encode %s: %w", v.Name, err)
+		}
+		b := dst.Bounds()
+		outs = append(outs, Output{Name: v.Name, Path: f.Name(), Width: b.Dx(), Height: b.Dy()})
+	}
+	return outs, nil
+}
+
+func Cleanup(outs []Output) {
+	for _, o := range outs {
+		_ = os.Remove(o.Path)
+	}
+}
+
+// วางบนพื้นขาว (JPEG ไม่มี alpha) และแปลงเป็น RGBA ให้ย่อ/หมุนง่าย
+func flatten(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+func cropSquare(img *image.RGBA) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w == h {
+		return img
+	}
+	side := min(w, h)
+	x0, y0 := (w-side)/2, (h-side)/2
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), img, image.Pt(x0, y0), draw.Src)
+	return dst
+}
+
+// ย่อให้ด้านยาวสุด = maxSide (รูปเล็กกว่าใช้ขนาดเดิม)
+func fit(img *image.RGBA, maxSide int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if maxSide <= 0 || (w <= maxSide && h <= maxSide) {
+		return img
+	}
+	if w >= h {
+		return resizeArea(img, maxSide, max(1, h*maxSide/w))
+	}
+	return resizeArea(img, max(1, w*maxSide/h), maxSide)
+}
+
+// URL ของแต่ละขนาด เดาจาก URL ของ full ({prefix}/full.jpg)
+// รูปเก่าที่ไม่ได้ผ่าน pipeline คืน nil
+func VariantURLs(fullURL string, variants []Variant) map[string]string {
+	base, ok := variantBase(fullURL)
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(variants))
+	for _, v := range variants {
+		out[v.Name] = base + v.Name + VariantExt + query(fullURL)
+	}
+	return out
+}
+
+// srcset ใช้ MaxSide เป็น width descriptor
+func SrcSet(fullURL string, variants []Variant) string {
+	base, ok := variantBase(fullURL)
+	if !ok {
+		return ""
+	}
+	parts := make([]string, 0, len(variants))
+	for _, v := range variants {
+		parts = append(parts, fmt.Sprintf("%s%s%s%s %dw", base, v.Name, VariantExt, query(fullURL), v.MaxSide))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func variantBase(fullURL string) (string, bool) {
+	u := fullURL
+	if i := strings.IndexByte(u, '?'); i >= 0 {
+		u = u[:i]
+	}
+	if !strings.HasSuffix(u, "/"+fullName+VariantExt) {
+		return "", false
+	}
+	return strings.TrimSuffix(u, fullName+VariantExt), true
+}
+
+func query(u string) string {
+	if i := strings.IndexByte(u, '?'); i >= 0 {
+		return u[i:]
+	}
+	return ""
+}
+
+// ชื่อ object ของแต่ละขนาด
+func ObjectPath(prefix, name string) string {
+	return filepath.ToSlash(filepath.Join(prefix, name+VariantExt))
+}
+
+func IsFull(name string) bool { return name == fullName }
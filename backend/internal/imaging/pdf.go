@@ -0,0 +1,99 @@
+package imaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNoRenderer = errors.New("no pdf renderer available")
+
+const (
+	renderTimeout = 60 * time.Second
+	// ด้านยาวสุดของภาพที่ render (เท่ากับ full ของหน้าปก)
+	renderMaxSide = 1600
+)
+
+// Go ล้วนวาด PDF ไม่ได้ จึงเรียกโปรแกรมในเครื่อง (poppler pdftoppm หรือ mupdf mutool)
+type PDFRenderer struct {
+	kind string // pdftoppm | mutool
+	bin  string
+}
+
+// setting: "auto" = หาเองจาก PATH, "none"/"" = ปิด, อื่นๆ = path ของ pdftoppm หรือ mutool
+func NewPDFRenderer(setting string) *PDFRenderer {
+	setting = strings.TrimSpace(setting)
+	switch strings.ToLower(setting) {
+	case "", "none", "off":
+		return &PDFRenderer{}
+	case "auto":
+		for _, kind := range []string{"pdftoppm", "mutool"} {
+			if bin, err := exec.LookPath(kind); err == nil {
+				return &PDFRenderer{kind: kind, bin: bin}
+			}
+		}
+		return &PDFRenderer{}
+	}
+
+	bin, err := exec.LookPath(setting)
+	if err != nil {
+		return &PDFRenderer{}
+	}
+	kind := strings.TrimSuffix(filepath.Base(bin), filepath.Ext(bin))
+	if kind != "pdftoppm" && kind != "mutool" {
+		return &PDFRenderer{}
+	}
+	return &PDFRenderer{kind: kind, bin: bin}
+}
+
+func (r *PDFRenderer) Available() bool { return r != nil && r.bin != "" }
+
+func (r *PDFRenderer) Name() string {
+	if !r.Available() {
+		return "none"
+	}
+	return r.kind
+}
+
+// วาดหน้า 1 เป็น PNG ใน outDir คืน path ของไฟล์ (ผู้เรียกลบเอง)
+func (r *PDFRenderer) RenderFirstPage(ctx context.Context, pdfPath, outDir string) (string, error) {
+	if !r.Available() {
+		return "", ErrNoRenderer
+	}
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	f, err := os.CreateTemp(outDir, "page1-*.png")
+	if err != nil {
+		return "", err
+	}
+	out := f.Name()
+	f.Close()
+
+	side := strconv.Itoa(renderMaxSide)
+	var cmd *exec.Cmd
+	switch r.kind {
+	case "pdftoppm":
+		// -singlefile เขียน {prefix}.png ตรงๆ ไม่เติมเลขหน้า
+		cmd = exec.CommandContext(ctx, r.bin, "-png", "-f", "1", "-l", "1", "-singlefile",
+			"-scale-to", side, pdfPath, strings.TrimSuffix(out, ".png"))
+	default:
+		cmd = exec.CommandContext(ctx, r.bin, "draw", "-q", "-o", out, "-w", side, "-h", side, pdfPath, "1")
+	}
+
+	if b, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(out)
+		return "", fmt.Errorf("%s: %v: %s", r.kind, err, strings.TrimSpace(string(b)))
+	}
+	if fi, err := os.Stat(out); err != nil || fi.Size() == 0 {
+		_ = os.Remove(out)
+		return "", fmt.Errorf("%s: no output", r.kind)
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package imaging
+
+import "image"
+
+// ย่อแบบเฉลี่ยพื้นที่ (box filter ที่คิดเศษ pixel) แยกแนวนอน/แนวตั้ง
+// ใช้ย่ออย่างเดียว ภาพไม่แตกเหมือน nearest-neighbor
+func resizeArea(src *image.RGBA, dw, dh int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+
+	// แนวนอน: sw×sh → dw×sh
+	tmp := make([]float32, dw*sh*4)
+	xs := spans(sw, dw)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, sp := range xs {
+			var acc [4]float32
+			for _, c := range sp {
+				p := row[c.i*4 : c.i*4+4]
+				for k := 0; k < 4; k++ {
+					acc[k] += float32(p[k]) * c.w
+				}
+			}
+			copy(tmp[(y*dw+x)*4:], acc[:])
+		}
+	}
+
+	// แนวตั้ง: dw×sh → dw×dh
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	ys := spans(sh, dh)
+	for y, sp := range ys {
+		for x := 0; x < dw; x++ {
+			var acc [4]float32
+			for _, c := range sp {
+				p := tmp[(c.i*dw+x)*4:]
+				for k := 0; k < 4; k++ {
+					acc[k] += p[k] * c.w
+				}
+			}
+			di := y*dst.Stride + x*4
+			for k := 0; k < 4; k++ {
+				dst.Pix[di+k] = clamp8(acc[k])
+			}
+		}
+	}
+	return dst
+}
+
+type contrib struct {
+	i int
+	w float32
+}
+
+// pixel ต้นทางที่ทับ pixel ปลายทางแต่ละตัว พร้อมน้ำหนัก (รวม = 1)
+func spans(srcLen, dstLen int) [][]contrib {
+	scale := float64(srcLen) / float64(dstLen)
+	out := make([][]contrib, dstLen)
+	for d := 0; d < dstLen; d++ {
+		lo, hi := float64(d)*scale, float64(d+1)*scale
+		var cs []contrib
+		for i := int(lo); i < srcLen && float64(i) < hi; i++ {
+			cover := min(hi, float64(i+1)) - max(lo, float64(i))
+			if cover > 0 {
+				cs = append(cs, contrib{i: i, w: float32(cover / scale)})
+			}
+		}
+		out[d] = cs
+	}
+	return out
+}
+
+func clamp8(v float32) uint8 {
+	v += 0.5
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v)
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// processor คืน error นี้ (wrap ได้) เมื่ออยากให้เลื่อนงานไปทำทีหลัง เช่น AI backend ล่ม
+var ErrDeferred = errors.New("job deferred")
+
+// ประเภทงานที่ worker รับได้
+const (
+	JobExtractFeatures = "extract_features"
+	JobSummarize       = "summarize"
+	JobRenderCover     = "render_cover"
+	JobExtractText     = "extract_text"
+)
+
+const (
+	JobQueued     = "queued"
+	JobProcessing = "processing"
+	JobDone       = "done"
+	JobFailed     = "failed"
+)
+
+type Job struct {
+	JobID       int64      `json:"job_id"`
+	DocumentID  int        `json:"document_id"`
+	JobType     string     `json:"job_type"`
+	JobStatus   string     `json:"job_status"`
+	Attempts    int        `json:"attempts"`
+	LocalPath   *string    `json:"-"`
+	LocalIsTemp bool       `json:"-"`
+	LeaseOwner  *string    `json:"lease_owner,omitempty"`
+	LeasedUntil *time.Time `json:"leased_until,omitempty"`
+	LastError   *string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type EnqueueInput struct {
+	DocumentID  int
+	JobType     string
+	LocalPath   string // ว่างได้ → worker โหลดไฟล์จาก storage เอง
+	LocalIsTemp bool   // true = ลบไฟล์ทิ้งเมื่อไม่มีงานอื่นใช้แล้ว
+}
+
+// ไฟล์ที่ส่งให้ processor (แปลงจากไฟล์ต้นฉบับแล้ว)
+type Input struct {
+	DocumentID   int
+	Path         string // PDF, ข้อความ หรือไฟล์เดิมถ้าไม่ต้องแปลง
+	MIME         string // ชนิดของ Path
+	OriginalMIME string // ชนิดไฟล์ที่ผู้ใช้อัป (documents.document_mime)
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"chaladshare_backend/internal/jobs/models"
+)
+
+type JobRepository interface {
+	Enqueue(input models.EnqueueInput) error
+	Lease(owner string, lease time.Duration, maxAttempts int) (*models.Job, error)
+	ExtendLease(jobID int64, owner string, lease time.Duration) error
+	Complete(jobID int64, owner string) error
+	Fail(jobID int64, owner string, msg string) error
+	Defer(jobID int64, owner string, delay time.Duration, msg string) error
+	Retry(jobID int64, owner string, delay time.Duration, msg string) error
+	CountActiveByPath(path string, excludeJobID int64) (int, error)
+
+	// งานล่าสุดของแต่ละประเภทของเอกสาร (หน้าแสดงสถานะ)
+	ListLatestByDocument(documentID int) ([]models.Job, error)
+
+	// startup recovery
+	EnqueueOrphans() (int, error)
+	FailExhausted(maxAttempts int) ([]models.Job, error)
+}
+
+type jobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+const jobColumns = `job_id, document_id, job_type, job_status, attempts, local_path, local_is_temp,
+		lease_owner, leased_until, last_error, created_at, updated_at`
+
+func scanJob(row interface{ Scan(...any) error }) (*models.Job, error) {
+	var j models.Job
+	if err := row.Scan(
+		&j.JobID, &j.DocumentID, &j.JobType, &j.JobStatus, &j.Attempts,
+		&j.LocalPath, &j.LocalIsTemp,
+		&j.LeaseOwner, &j.LeasedUntil, &j.LastError,
+		&j.CreatedAt, &j.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// มีงานที่ยังค้าง (queued/processing) ของเอกสาร+ประเภทเดียวกันอยู่แล้ว → ไม่เพิ่มซ้ำ
+func (r *jobRepository) Enqueue(input models.EnqueueInput) error {
+	var localPath any = nil
+	if input.LocalPath != "" {
+		localPath = input.LocalPath
+	}
+
+	q := `
+		INSERT INTO document_jobs (document_id, job_type, job_status, local_path, local_is_temp)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (document_id, job_type) WHERE job_status IN ('queued', 'processing')
+		DO NOTHING;
+	`
+	_, err := r.db.Exec(q, input.DocumentID, input.JobType, models.JobQueued, localPath, input.LocalIsTemp)
+	return err
+}
+
+// จองงานถัดไป: queued ที่ถึงเวลาแล้ว หรือ processing ที่ lease หมดอายุ (worker เดิมตาย/restart)
+func (r *jobRepository) Lease(owner string, lease time.Duration, maxAttempts int) (*models.Job, error) {
+	q := `
+		UPDATE document_jobs
+		SET job_status   = $1,
+		    attempts     = attempts + 1,
+		    lease_owner  = $2,
+		    leased_until = now() + make_interval(secs => $3),
+		    updated_at   = now()
+		WHERE job_id = (
+			SELECT job_id
+			FROM document_jobs
+			WHERE attempts < $4
+			  AND (
+				(job_status = 'queued' AND run_after <= now())
+				OR (job_status = 'processing' AND leased_until < now())
+			  )
+			ORDER BY run_after, job_id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns + `;
+	`
+	j, err := scanJob(r.db.QueryRow(q, models.JobProcessing, owner, lease.Seconds(), maxAttempts))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lease job: %w", err)
+	}
+	return j, nil
+}
+
+func (r *jobRepository) ExtendLease(jobID int64, owner string, lease time.Duration) error {
+	q := `
+		UPDATE document_jobs
+		SET leased_until = now() + make_interval(secs => $3), updated_at = now()
+		WHERE job_id = $1 AND lease_owner = $2 AND job_status = 'processing';
+	`
+	_, err := r.db.Exec(q, jobID, owner, lease.Seconds())
+	return err
+}
+
+func (r *jobRepository) Complete(jobID int64, owner string) error {
+	q := `
+		UPDATE document_jobs
+		SET job_status = $3, leased_until = NULL, last_error = NULL, updated_at = now()
+		WHERE job_id = $1 AND lease_owner = $2;
+	`
+	_, err := r.db.Exec(q, jobID, owner, models.JobDone)
+	return err
+}
+
+func (r *jobRepository) Fail(jobID int64, owner string, msg string) error {
+	q := `
+		UPDATE document_jobs
+		SET job_status = $3, leased_until = NULL, last_error = $4, updated_at = now()
+		WHERE job_id = $1 AND lease_owner = $2;
+	`
+	_, err := r.db.Exec(q, jobID, owner, models.JobFailed, msg)
+	return err
+}
+
+// คืนงานเข้าคิวโดยไม่นับเป็น attempt
+func (r *jobRepository) Defer(jobID int64, owner string, delay time.Duration, msg string) error {
+	q := `
+		UPDATE document_jobs
+		SET job_status   = $3,
+		    attempts     = GREATEST(attempts - 1, 0),
+		    lease_owner  = NULL,
+		    leased_until = NULL,
+		    run_after    = now() + make_interval(secs => $4),
+		    last_error   = $5,
+		    updated_at   = now()
+		WHERE job_id = $1 AND lease_owner = $2;
+	`
+	_, err := r.db.Exec(q, jobID, owner, models.JobQueued, delay.Seconds(), msg)
+	return err
+}
+
+// คืนงานเข้าคิวหลัง delay โดยนับ attempt ที่ใช้ไปแล้ว (ครบ maxAttempts แล้ว Lease จะไม่หยิบ)
+func (r *jobRepository) Retry(jobID int64, owner string, delay time.Duration, msg string) error {
+	q := `
+		UPDATE document_jobs
+		SET job_status   = $3,
+		    lease_owner  = NULL,
+		    leased_until = NULL,
+		    run_after    = now() + make_interval(secs => $4),
+		    last_error   = $5,
+		    updated_at   = now()
+		WHERE job_id = $1 AND lease_owner = $2;
+	`
+	_, err := r.db.Exec(q, jobID, owner, models.JobQueued, delay.Seconds(), msg)
+	return err
+}
+
+func (r *jobRepository) CountActiveByPath(path string, excludeJobID int64) (int, error) {
+	var n int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM document_jobs
+		WHERE local_path = $1
+		  AND job_id <> $2
+		  AND job_status IN ('queued', 'processing')
+	`, path, excludeJobID).Scan(&n)
+	return n, err
+}
+
+// document_features / summary_status ที่ค้าง queued/processing แต่ไม่มีงานในคิว
+// (อัปโหลดก่อนมี document_jobs หรือ goroutine เดิมตายไปพร้อม server)
+func (r *jobRepository) EnqueueOrphans() (int, error) {
+	q := `
+		WITH orphans AS (
+			SELECT df.document_id, 'extract_features' AS job_type
+			FROM document_features df
+			WHERE df.feature_status IN ('queued', 'processing')
+			UNION ALL
+			SELECT ss.document_id, 'summarize' AS job_type
+			FROM summary_status ss
+			WHERE ss.summary_status IN ('queued', 'processing')
+		)
+		INSERT INTO document_jobs (document_id, job_type, job_status)
+		SELECT o.document_id, o.job_type, 'queued'
+		FROM orphans o
+		WHERE NOT EXISTS (
+			SELECT 1 FROM document_jobs j
+			WHERE j.document_id = o.document_id
+			  AND j.job_type = o.job_type
+			  AND j.job_status IN ('queued', 'processing')
+		)
+		ON CONFLICT (document_id, job_type) WHERE job_status IN ('queued', 'processing')
+		DO NOTHING;
+	`
+	res, err := r.db.Exec(q)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// งานที่ lease หมดอายุและลองครบจำนวนครั้งแล้ว → failed ถาวร
+func (r *jobRepository) FailExhausted(maxAttempts int) ([]models.Job, error) {
+	q := `
+		UPDATE document_jobs
+		SET job_status = 'failed',
+		    leased_until = NULL,
+		    last_error = COALESCE(last_error, 'worker lease expired too many times'),
+		    updated_at = now()
+		WHERE job_status = 'processing'
+		  AND leased_until < now()
+		  AND attempts >= $1
+		RETURNING ` + jobColumns + `;
+	`
+	rows, err := r.db.Query(q, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *j)
+	}
+	return out, rows.Err()
+}
+
+func (r *jobRepository) ListLatestByDocument(documentID int) ([]models.Job, error) {
+	q := `
+		SELECT DISTINCT ON (job_type) ` + jobColumns + `
+		FROM document_jobs
+		WHERE document_id = $1
+		ORDER BY job_type, job_id DESC;
+	`
+	rows, err := r.db.Query(q, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *j)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"chaladshare_backend/internal/convert"
+	filemodels "chaladshare_backend/internal/files/models"
+	filerepo "chaladshare_backend/internal/files/repository"
+	"chaladshare_backend/internal/jobs/models"
+	"chaladshare_backend/internal/jobs/repository"
+	"chaladshare_backend/internal/storage"
+)
+
+// งานแต่ละประเภท (features / summary) implement ตัวนี้
+// คืน error ที่ wrap models.ErrDeferred เพื่อเลื่อนงาน, error อื่น = งาน failed
+type Processor interface {
+	ProcessDocument(in models.Input) error
+	MarkFailed(documentID int, msg string) error
+}
+
+type WorkerConfig struct {
+	Concurrency  int
+	PollInterval time.Duration
+	Lease        time.Duration
+	MaxAttempts  int
+	DeferDelay   time.Duration
+}
+
+type JobService interface {
+	Enqueue(input models.EnqueueInput) error
+	ListByDocument(documentID int) ([]models.Job, error)
+	Register(jobType string, p Processor)
+	Handles(jobType string) bool
+	Recover() error
+	Start(ctx context.Context)
+}
+
+type jobService struct {
+	jobRepo    repository.JobRepository
+	fileRepo   filerepo.FileRepository
+	storage    *storage.Registry
+	converter  *convert.Converter
+	cfg        WorkerConfig
+	owner      string
+	httpClient *http.Client
+
+	mu         sync.RWMutex
+	processors map[string]Processor
+	wake       chan struct{}
+}
+
+func NewJobService(jobRepo repository.JobRepository, fileRepo filerepo.FileRepository, storage *storage.Registry, converter *convert.Converter, cfg WorkerConfig) JobService {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 2
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 15 * time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.DeferDelay <= 0 {
+		cfg.DeferDelay = time.Minute
+	}
+
+	host, _ := os.Hostname()
+	return &jobService{
+		jobRepo:    jobRepo,
+		fileRepo:   fileRepo,
+		storage:    storage,
+		converter:  converter,
+		cfg:        cfg,
+		owner:      fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8]),
+		httpClient: &http.Client{Timeout: 120 * time.Second},
+		processors: map[string]Processor{},
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func (s *jobService) Register(jobType string, p Processor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.processors[jobType] = p
+}
+
+// มี processor ของงานนี้ไหม (เช่น render_cover ลงทะเบียนเฉพาะเมื่อมี renderer)
+func (s *jobService) Handles(jobType string) bool {
+	return s.processor(jobType) != nil
+}
+
+func (s *jobService) processor(jobType string) Processor {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.processors[jobType]
+}
+
+func (s *jobService) Enqueue(input models.EnqueueInput) error {
+	if input.DocumentID <= 0 {
+		return fmt.Errorf("invalid documentID")
+	}
+	// รับเฉพาะงานที่มี processor ลงทะเบียนไว้ (ไม่งั้นค้างในคิวไม่มีใครทำ)
+	if !s.Handles(input.JobType) {
+		return fmt.Errorf("unknown job type: %s", input.JobType)
+	}
+	if err := s.jobRepo.Enqueue(input); err != nil {
+		return err
+	}
+
+	// ปลุก worker ไม่ต้องรอรอบ poll
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// lease_owner เป็นชื่อเครื่องภายใน ไม่ส่งออกไป
+func (s *jobService) ListByDocument(documentID int) ([]models.Job, error) {
+	if documentID <= 0 {
+		return nil, fmt.Errorf("invalid documentID")
+	}
+	jobs, err := s.jobRepo.ListLatestByDocument(documentID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		jobs[i].LeaseOwner = nil
+	}
+	return jobs, nil
+}
+
+// เรียกตอน start server ก่อน Start
+func (s *jobService) Recover() error {
+	failed, err := s.failExhausted()
+	if err != nil {
+		return fmt.Errorf("fail exhausted jobs: %w", err)
+	}
+
+	n, err := s.jobRepo.EnqueueOrphans()
+	if err != nil {
+		return fmt.Errorf("enqueue orphans: %w", err)
+	}
+	log.Printf("[JOBS] recover: requeued=%d failed=%d", n, failed)
+	return nil
+}
+
+// งานที่ lease หมดอายุตอนทำครั้งสุดท้าย Lease จะไม่หยิบอีก → ปิดเป็น failed ให้เอกสารไม่ค้าง processing
+// เรียกทั้งตอน start และทุกรอบ poll (ไม่ต้องรอ restart)
+func (s *jobService) failExhausted() (int, error) {
+	exhausted, err := s.jobRepo.FailExhausted(s.cfg.MaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	for i := range exhausted {
+		j := &exhausted[i]
+		if p := s.processor(j.JobType); p != nil {
+			_ = p.MarkFailed(j.DocumentID, "processing interrupted too many times")
+		}
+		if j.LocalPath != nil && *j.LocalPath != "" {
+			s.cleanup(j, *j.LocalPath, j.LocalIsTemp)
+		}
+	}
+	return len(exhausted), nil
+}
+
+func (s *jobService) Start(ctx context.Context) {
+	log.Printf("[JOBS] start workers=%d owner=%s", s.cfg.Concurrency, s.owner)
+	for i := 0; i < s.cfg.Concurrency; i++ {
+		go s.loop(ctx, i)
+	}
+}
+
+func (s *jobService) loop(ctx context.Context, n int) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// worker เดียวพอ ไม่ต้องยิง query เดียวกันทุกตัว
+		if n == 0 {
+			if failed, err := s.failExhausted(); err != nil {
+				log.Printf("[JOBS] fail exhausted err=%v", err)
+			} else if failed > 0 {
+				log.Printf("[JOBS] failed %d job(s) with expired leases on the last attempt", failed)
+			}
+		}
+
+		// ทำงานจนคิวว่างก่อนค่อยรอรอบถัดไป
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			job, err := s.jobRepo.Lease(s.owner, s.cfg.Lease, s.cfg.MaxAttempts)
+			if err != nil {
+				log.Printf("[JOBS][w%d] lease err=%v", n, err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			s.run(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *jobService) run(ctx context.Context, job *models.Job) {
+	start := time.Now()
+	log.Printf("[JOBS] run job=%d doc=%d type=%s attempt=%d", job.JobID, job.DocumentID, job.JobType, job.Attempts)
+
+	p := s.processor(job.JobType)
+	if p == nil {
+		s.fail(nil, job, "no processor registered")
+		return
+	}
+
+	// ต่ออายุ lease ระหว่างทำงาน (summarize อาจนานหลายนาที)
+	hbCtx, stopHB := context.WithCancel(ctx)
+	defer stopHB()
+	go func() {
+		t := time.NewTicker(s.cfg.Lease / 3)
+		defer t.Stop()
+		for {
+			select {
+			case <-hbCtx.Done():
+				return
+			case <-t.C:
+				if err := s.jobRepo.ExtendLease(job.JobID, s.owner, s.cfg.Lease); err != nil {
+					log.Printf("[JOBS] extend lease job=%d err=%v", job.JobID, err)
+				}
+			}
+		}
+	}()
+
+	doc, err := s.fileRepo.GetDocumentByID(job.DocumentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// เอกสารถูกลบไปแล้ว ไม่มีอะไรให้ทำ
+		s.fail(p, job, "document deleted")
+		return
+	}
+	if err != nil {
+		s.retryOrFail(p, job, fmt.Sprintf("get document: %v", err))
+		return
+	}
+
+	// หลัง restart ต้องโหลดไฟล์จาก storage ใหม่ storage/network สะดุดชั่วคราวไม่ควรทำให้ failed ถาวร
+	path, isTemp, err := s.ensureLocalFile(job, doc)
+	if err != nil {
+		s.retryOrFail(p, job, fmt.Sprintf("download document: %v", err))
+		return
+	}
+
+	// DOCX / PPTX / รูป → PDF หรือข้อความก่อนส่งให้ processor (งานอื่นของไฟล์เดียวกันใช้ผลซ้ำ)
+	conv, err := s.converter.Convert(ctx, path, doc.DocumentMIME)
+	if err != nil {
+		msg := fmt.Sprintf("convert %s: %v", doc.DocumentMIME, err)
+		_ = p.MarkFailed(job.DocumentID, msg)
+		_ = s.jobRepo.Fail(job.JobID, s.owner, msg)
+		s.cleanup(job, path, isTemp)
+		return
+	}
+
+	perr := s.safeProcess(p, job, models.Input{
+		DocumentID:   job.DocumentID,
+		Path:         conv.Path,
+		MIME:         conv.MIME,
+		OriginalMIME: doc.DocumentMIME,
+	})
+	switch {
+	case perr == nil:
+		if err := s.jobRepo.Complete(job.JobID, s.owner); err != nil {
+			log.Printf("[JOBS] complete job=%d err=%v", job.JobID, err)
+		}
+	case errors.Is(perr, models.ErrDeferred):
+		// ไฟล์ temp ตอนอัปโหลดต้องเก็บไว้ให้รอบถัดไป ส่วนไฟล์ที่เพิ่งโหลดมาลบได้เลย
+		log.Printf("[JOBS] defer job=%d doc=%d type=%s: %v", job.JobID, job.DocumentID, job.JobType, perr)
+		if err := s.jobRepo.Defer(job.JobID, s.owner, s.cfg.DeferDelay, perr.Error()); err != nil {
+			log.Printf("[JOBS] defer job=%d err=%v", job.JobID, err)
+		}
+		if isTemp && (job.LocalPath == nil || *job.LocalPath != path) {
+			_ = os.Remove(path)
+			convert.Cleanup(path)
+		}
+		return
+	default:
+		if err := s.jobRepo.Fail(job.JobID, s.owner, perr.Error()); err != nil {
+			log.Printf("[JOBS] fail job=%d err=%v", job.JobID, err)
+		}
+	}
+	s.cleanup(job, path, isTemp)
+
+	log.Printf("[JOBS] done job=%d doc=%d type=%s time=%s", job.JobID, job.DocumentID, job.JobType, time.Since(start))
+}
+
+// error ชั่วคราว (DB / storage) → กลับเข้าคิวแบบ backoff จนครบ MaxAttempts แล้วค่อย failed
+func (s *jobService) retryOrFail(p Processor, job *models.Job, msg string) {
+	if job.Attempts >= s.cfg.MaxAttempts {
+		s.fail(p, job, msg)
+		return
+	}
+	delay := s.cfg.DeferDelay << max(job.Attempts-1, 0)
+	log.Printf("[JOBS] retry job=%d doc=%d type=%s in %s: %s", job.JobID, job.DocumentID, job.JobType, delay, msg)
+	if err := s.jobRepo.Retry(job.JobID, s.owner, delay, msg); err != nil {
+		log.Printf("[JOBS] retry job=%d err=%v", job.JobID, err)
+	}
+}
+
+// failed ถาวร + ลบไฟล์ temp ที่อัปโหลดไว้ (ถ้าไม่มีงานอื่นใช้อยู่)
+func (s *jobService) fail(p Processor, job *models.Job, msg string) {
+	if p != nil {
+		_ = p.MarkFailed(job.DocumentID, msg)
+	}
+	if err := s.jobRepo.Fail(job.JobID, s.owner, msg); err != nil {
+		log.Printf("[JOBS] fail job=%d err=%v", job.JobID, err)
+	}
+	if job.LocalPath != nil && *job.LocalPath != "" {
+		s.cleanup(job, *job.LocalPath, job.LocalIsTemp)
+	}
+}
+
+func (s *jobService) safeProcess(p Processor, job *models.Job, in models.Input) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			msg := fmt.Sprintf("panic: %v", rec)
+			log.Printf("[JOBS] job=%d %s", job.JobID, msg)
+			_ = p.MarkFailed(job.DocumentID, msg)
+			err = errors.New(msg)
+		}
+	}()
+	return p.ProcessDocument(in)
+}
+
+// ใช้ไฟล์ temp เดิมถ้ายังอยู่ ไม่งั้นโหลดจาก storage ใหม่ (เช่นหลัง restart)
+func (s *jobService) ensureLocalFile(job *models.Job, doc *filemodels.Document) (string, bool, error) {
+	if job.LocalPath != nil && *job.LocalPath != "" {
+		if _, err := os.Stat(*job.LocalPath); err == nil {
+			return *job.LocalPath, job.LocalIsTemp, nil
+		}
+	}
+
+	obj := storage.Object{Provider: doc.StorageProvider}
+	if doc.DocumentPath != nil {
+		obj.Path = *doc.DocumentPath
+	}
+	if doc.DocumentBucket != nil {
+		obj.Bucket = *doc.DocumentBucket
+	}
+
+	path, err := s.downloadToTemp(obj, doc.DocumentURL, convert.Ext(doc.DocumentMIME))
+	if err != nil {
+		return "", false, err
+	}
+	return path, true, nil
+}
+
+func (s *jobService) cleanup(job *models.Job, path string, isTemp bool) {
+	// ไฟล์เดียวกันอาจยังมีงานอื่นของเอกสารนี้รออยู่ (extract + summarize ใช้ไฟล์และผลแปลงร่วมกัน)
+	// ลบเมื่องานที่ใช้ไฟล์นี้เสร็จหมดแล้วเท่านั้น
+	if job.LocalPath != nil && *job.LocalPath == path {
+		n, err := s.jobRepo.CountActiveByPath(path, job.JobID)
+		if err != nil || n > 0 {
+			return
+		}
+	}
+	// ไฟล์ของคนอื่น (!isTemp) ไม่ลบ แต่ไฟล์ที่แปลงไว้ข้างๆ เป็นของเรา
+	if isTemp {
+		_ = os.Remove(path)
+	}
+	convert.Cleanup(path)
+}
+
+// อ่านผ่าน storage ตาม path ที่บันทึกไว้ก่อน (local / bucket private) ไม่งั้นค่อย GET ตาม URL
+func (s *jobService) openDocument(obj storage.Object, docURL string) (io.ReadCloser, error) {
+	if s.storage != nil && obj.Path != "" {
+		st, err := s.storage.ForObject(obj.Provider, obj.Bucket)
+		if err != nil {
+			return nil, err
+		}
+		return st.Open(context.Background(), obj.Path)
+	}
+	if strings.TrimSpace(docURL) == "" {
+		return nil, errors.New("document_url is empty")
+	}
+
+	resp, err := s.httpClient.Get(docURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func (s *jobService) downloadToTemp(obj storage.Object, docURL, ext string) (string, error) {
+	body, err := s.openDocument(obj, docURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	baseDir := filepath.Join(os.TempDir(), "chaladshare")
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return "", err
+	}
+
+	abs := filepath.Join(baseDir, uuid.NewString()+ext)
+	f, err := os.Create(abs)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		_ = os.Remove(abs)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(abs)
+		return "", err
+	}
+	return abs, nil
+}
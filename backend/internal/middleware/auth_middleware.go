@@ -9,9 +9,17 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-const CtxUserID = "user_id"
+const (
+	CtxUserID    = "user_id"
+	CtxSessionID = "session_id"
+)
+
+// เช็ค sid ใน token กับตาราง session (logout / logout ทุกเครื่อง / refresh token ถูกขโมย)
+type SessionChecker interface {
+	SessionActive(sessionID string) (bool, error)
+}
 
-func JWT(secret []byte, cookieName string) gin.HandlerFunc {
+func JWT(secret []byte, cookieName string, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var tokenStr string
 		if a := c.GetHeader("Authorization"); strings.HasPrefix(a, "Bearer ") {
@@ -40,7 +48,23 @@ func JWT(secret []byte, cookieName string) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "bad claims"})
 			return
 		}
+		// token รุ่นเก่าที่ไม่มี sid ใช้ไม่ได้แล้ว → login ใหม่
+		sid, _ := claims["sid"].(string)
+		if sid == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "bad claims"})
+			return
+		}
+		active, err := sessions.SessionActive(sid)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "session check failed"})
+			return
+		}
+		if !active {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+			return
+		}
 		c.Set(CtxUserID, int(f))
+		c.Set(CtxSessionID, sid)
 		c.Next()
 	}
 }
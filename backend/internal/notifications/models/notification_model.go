@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// ประเภทแจ้งเตือน
+const (
+	TypeFriendRequest  = "friend_request"
+	TypeFriendAccept   = "friend_accept"
+	TypePostLike       = "post_like"
+	TypePostSave       = "post_save"
+	TypePostComment    = "post_comment"
+	TypeCommentReply   = "comment_reply"
+	TypeNewPost        = "new_post"
+	TypeDocumentReady  = "document_ready"
+	TypeDocumentFailed = "document_failed"
+)
+
+// ลำดับที่แสดงในหน้าตั้งค่า
+var Types = []string{
+	TypeFriendRequest, TypeFriendAccept,
+	TypePostLike, TypePostSave, TypePostComment, TypeCommentReply,
+	TypeNewPost,
+	TypeDocumentReady, TypeDocumentFailed,
+}
+
+func ValidType(t string) bool {
+	for _, v := range Types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// เหตุการณ์ที่โมดูลอื่นส่งเข้ามา
+// UserID = 0 → ผู้รับคือเจ้าของโพสต์ (PostID) หรือเจ้าของเอกสาร (DocumentID)
+type Event struct {
+	Type       string
+	UserID     int
+	ActorID    int
+	PostID     int
+	DocumentID int
+	CommentID  int
+	Message    string
+}
+
+type Notification struct {
+	NotificationID int64      `json:"notification_id"`
+	Type           string     `json:"type"`
+	Message        string     `json:"message,omitempty"`
+	IsRead         bool       `json:"is_read"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReadAt         *time.Time `json:"read_at"`
+
+	ActorID        *int    `json:"actor_id"`
+	ActorName      *string `json:"actor_name"`
+	ActorAvatarURL *string `json:"actor_avatar_url"`
+
+	PostID       *int    `json:"post_id"`
+	PostTitle    *string `json:"post_title"`
+	DocumentID   *int    `json:"document_id"`
+	DocumentName *string `json:"document_name"`
+	CommentID    *int    `json:"comment_id"`
+}
+
+type Preference struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"database/sql"
+
+	"chaladshare_backend/internal/notifications/models"
+)
+
+type NotificationRepository interface {
+	Insert(e models.Event) (int64, error)
+	InsertForFollowers(postID int) (int64, error)
+	List(userID int, afterID int64, limit int, unreadOnly bool) ([]models.Notification, error)
+	UnreadCount(userID int) (int, error)
+	MarkRead(userID int, notificationID int64) (bool, error)
+	MarkAllRead(userID int) (int64, error)
+	GetPreferences(userID int) (map[string]bool, error)
+	SetPreference(userID int, typ string, enabled bool) error
+}
+
+type notificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// 0 → NULL (ไม่มีสิ่งที่เกี่ยวข้อง)
+func nullID(id int) any {
+	if id <= 0 {
+		return nil
+	}
+	return id
+}
+
+// ผู้รับ: ระบุมาเอง หรือเจ้าของโพสต์ / เจ้าของเอกสาร
+// ไม่แจ้งตัวเอง ไม่แจ้งประเภทที่ผู้รับปิดไว้ และไม่ซ้ำกับอันที่ยังไม่ได้อ่าน (กดไลก์-เลิกไลก์รัวๆ)
+func (r *notificationRepository) Insert(e models.Event) (int64, error) {
+	query := `
+		WITH target AS (
+			SELECT COALESCE(
+				$1::int,
+				(SELECT post_author_user_id FROM posts WHERE post_id = $4::int),
+				(SELECT document_user_id FROM documents WHERE document_id = $5::int)
+			) AS user_id
+		)
+		INSERT INTO notifications (notification_user_id, notification_actor_id, notification_type,
+			notification_post_id, notification_document_id, notification_comment_id, notification_message)
+		SELECT t.user_id, $2::int, $3::text, $4::int, $5::int, $6::int, $7::text
+		FROM target t
+		WHERE t.user_id IS NOT NULL
+		  AND t.user_id IS DISTINCT FROM $2::int
+		  AND NOT EXISTS (
+			SELECT 1 FROM notification_preferences np
+			WHERE np.user_id = t.user_id AND np.notification_type = $3 AND NOT np.enabled
+		  )
+		  AND NOT EXISTS (
+			SELECT 1 FROM notifications n
+			WHERE n.notification_user_id = t.user_id
+			  AND n.notification_type = $3
+			  AND n.notification_read_at IS NULL
+			  AND n.notification_actor_id IS NOT DISTINCT FROM $2::int
+			  AND n.notification_post_id IS NOT DISTINCT FROM $4::int
+			  AND n.notification_document_id IS NOT DISTINCT FROM $5::int
+			  AND n.notification_comment_id IS NOT DISTINCT FROM $6::int
+		  )
+		RETURNING notification_id
+	`
+	var id int64
+	err := r.db.QueryRow(query,
+		nullID(e.UserID), nullID(e.ActorID), e.Type,
+		nullID(e.PostID), nullID(e.DocumentID), nullID(e.CommentID), e.Message,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// ผู้ติดตามที่มีสิทธิ์เห็นโพสต์ (public หรือ friends ที่เป็นเพื่อนกัน)
+func (r *notificationRepository) InsertForFollowers(postID int) (int64, error) {
+	query := `
+		INSERT INTO notifications (notification_user_id, notification_actor_id, notification_type, notification_post_id)
+		SELECT f.follower_user_id, p.post_author_user_id, $2::text, p.post_id
+		FROM posts p
+		JOIN follows f ON f.followed_user_id = p.post_author_user_id
+		WHERE p.post_id = $1
+		  AND f.follower_user_id <> p.post_author_user_id
+		  AND ( p.post_visibility = 'public'
+			OR ( p.post_visibility = 'friends'
+				AND EXISTS (
+					SELECT 1 FROM friendships fr
+					WHERE fr.user_id = LEAST(f.follower_user_id, p.post_author_user_id)
+					  AND fr.friend_id = GREATEST(f.follower_user_id, p.post_author_user_id)
+				)
+			)
+		  )
+		  AND NOT EXISTS (
+			SELECT 1 FROM notification_preferences np
+			WHERE np.user_id = f.follower_user_id AND np.notification_type = $2 AND NOT np.enabled
+		  )
+		  AND NOT EXISTS (
+			SELECT 1 FROM notifications n
+			WHERE n.notification_user_id = f.follower_user_id
+			  AND n.notification_type = $2
+			  AND n.notification_post_id = p.post_id
+		  )
+	`
+	res, err := r.db.Exec(query, postID, models.TypeNewPost)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ใหม่ → เก่า ต่อหน้าด้วย notification_id
+func (r *notificationRepository) List(userID int, afterID int64, limit int, unreadOnly bool) ([]models.Notification, error) {
+	query := `
+		SELECT n.notification_id, n.notification_type, n.notification_message,
+			n.notification_created_at, n.notification_read_at,
+			n.notification_actor_id, u.username, up.avatar_url,
+			n.notification_post_id, p.post_title,
+			n.notification_document_id, d.document_name,
+			n.notification_comment_id
+		FROM notifications n
+		LEFT JOIN users u ON u.user_id = n.notification_actor_id
+		LEFT JOIN user_profiles up ON up.profile_user_id = n.notification_actor_id
+		LEFT JOIN posts p ON p.post_id = n.notification_post_id
+		LEFT JOIN documents d ON d.document_id = n.notification_document_id
+		WHERE n.notification_user_id = $1
+		  AND ($2 = 0 OR n.notification_id < $2)
+		  AND (NOT $3 OR n.notification_read_at IS NULL)
+		ORDER BY n.notification_id DESC
+		LIMIT $4
+	`
+	rows, err := r.db.Query(query, userID, afterID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		var readAt sql.NullTime
+		var actorID, postID, documentID, commentID sql.NullInt64
+		var actorName, avatar, postTitle, documentName sql.NullString
+		if err := rows.Scan(
+			&n.NotificationID, &n.Type, &n.Message,
+			&n.CreatedAt, &readAt,
+			&actorID, &actorName, &avatar,
+			&postID, &postTitle,
+			&documentID, &documentName,
+			&commentID,
+		); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+			n.IsRead = true
+		}
+		n.ActorID = intPtr(actorID)
+		n.PostID = intPtr(postID)
+		n.DocumentID = intPtr(documentID)
+		n.CommentID = intPtr(commentID)
+		n.ActorName = strPtr(actorName)
+		n.ActorAvatarURL = strPtr(avatar)
+		n.PostTitle = strPtr(postTitle)
+		n.DocumentName = strPtr(documentName)
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+func (r *notificationRepository) UnreadCount(userID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications
+		WHERE notification_user_id = $1 AND notification_read_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
+
+// false = ไม่พบ (หรือไม่ใช่ของผู้ใช้คนนี้)
+func (r *notificationRepository) MarkRead(userID int, notificationID int64) (bool, error) {
+	res, err := r.db.Exec(`UPDATE notifications
+		SET notification_read_at = COALESCE(notification_read_at, now())
+		WHERE notification_id = $1 AND notification_user_id = $2`, notificationID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *notificationRepository) MarkAllRead(userID int) (int64, error) {
+	res, err := r.db.Exec(`UPDATE notifications SET notification_read_at = now()
+		WHERE notification_user_id = $1 AND notification_read_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *notificationRepository) GetPreferences(userID int) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT notification_type, enabled
+		FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]bool{}
+	for rows.Next() {
+		var typ string
+		var enabled bool
+		if err := rows.Scan(&typ, &enabled); err != nil {
+			return nil, err
+		}
+		out[typ] = enabled
+	}
+	return out, rows.Err()
+}
+
+func (r *notificationRepository) SetPreference(userID int, typ string, enabled bool) error {
+	_, err := r.db.Exec(`
+		INSERT INTO notification_preferences (user_id, notification_type, enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, notification_type) DO UPDATE SET enabled = EXCLUDED.enabled`,
+		userID, typ, enabled)
+	return err
+}
+
+func intPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+func strPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"chaladshare_backend/internal/notifications/models"
+	"chaladshare_backend/internal/notifications/repository"
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrNotFound   = errors.New("notification not found")
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	// ข้อความ error ของเอกสารเก็บไม่เกินนี้
+	maxMessageRunes = 500
+)
+
+// ช่องทางที่โมดูลอื่นใช้ส่งเหตุการณ์เข้ามา
+// แจ้งเตือนเป็นของเสริม: บันทึกไม่สำเร็จแค่ log ไม่ทำให้งานหลักล้ม
+type Notifier interface {
+	Notify(e models.Event)
+	NotifyFollowers(postID int)
+}
+
+type NotificationService interface {
+	Notifier
+
+	List(userID int, cursor string, limit int, unreadOnly bool) ([]models.Notification, string, error)
+	UnreadCount(userID int) (int, error)
+	MarkRead(userID int, notificationID int64) error
+	MarkAllRead(userID int) (int64, error)
+
+	GetPreferences(userID int) ([]models.Preference, error)
+	UpdatePreferences(userID int, prefs map[string]bool) ([]models.Preference, error)
+}
+
+type notificationService struct {
+	repo repository.NotificationRepository
+}
+
+func NewNotificationService(repo repository.NotificationRepository) NotificationService {
+	return &notificationService{repo: repo}
+}
+
+func (s *notificationService) Notify(e models.Event) {
+	if !models.ValidType(e.Type) {
+		log.Printf("[NOTIFY] unknown type %q", e.Type)
+		return
+	}
+	if r := []rune(e.Message); len(r) > maxMessageRunes {
+		e.Message = string(r[:maxMessageRunes])
+	}
+	if _, err := s.repo.Insert(e); err != nil {
+		log.Printf("[NOTIFY] type=%s user=%d post=%d doc=%d err=%v", e.Type, e.UserID, e.PostID, e.DocumentID, err)
+	}
+}
+
+func (s *notificationService) NotifyFollowers(postID int) {
+	if postID <= 0 {
+		return
+	}
+	if _, err := s.repo.InsertForFollowers(postID); err != nil {
+		log.Printf("[NOTIFY] followers post=%d err=%v", postID, err)
+	}
+}
+
+// cursor = notification_id ตัวสุดท้ายของหน้าก่อน
+func (s *notificationService) List(userID int, cursor string, limit int, unreadOnly bool) ([]models.Notification, string, error) {
+	if userID <= 0 {
+		return nil, "", ErrBadRequest
+	}
+	var afterID int64
+	if cursor != "" {
+		n, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, "", ErrBadRequest
+		}
+		afterID = n
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, maxLimit)
+
+	items, err := s.repo.List(userID, afterID, limit+1, unreadOnly)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(items) > limit {
+		items = items[:limit]
+		next = strconv.FormatInt(items[limit-1].NotificationID, 10)
+	}
+	return items, next, nil
+}
+
+func (s *notificationService) UnreadCount(userID int) (int, error) {
+	if userID <= 0 {
+		return 0, ErrBadRequest
+	}
+	return s.repo.UnreadCount(userID)
+}
+
+func (s *notificationService) MarkRead(userID int, notificationID int64) error {
+	if userID <= 0 || notificationID <= 0 {
+		return ErrBadRequest
+	}
+	ok, err := s.repo.MarkRead(userID, notificationID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *notificationService) MarkAllRead(userID int) (int64, error) {
+	if userID <= 0 {
+		return 0, ErrBadRequest
+	}
+	return s.repo.MarkAllRead(userID)
+}
+
+// ทุกประเภท (ที่ไม่เคยตั้ง = เปิด)
+func (s *notificationService) GetPreferences(userID int) ([]models.Preference, error) {
+	if userID <= 0 {
+		return nil, ErrBadRequest
+	}
+	saved, err := s.repo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.Preference, 0, len(models.Types))
+	for _, t := range models.Types {
+		enabled, ok := saved[t]
+		out = append(out, models.Preference{Type: t, Enabled: enabled || !ok})
+	}
+	return out, nil
+}
+
+func (s *notificationService) UpdatePreferences(userID int, prefs map[string]bool) ([]models.Preference, error) {
+	if userID <= 0 || len(prefs) == 0 {
+		return nil, ErrBadRequest
+	}
+	for t := range prefs {
+		if !models.ValidType(t) {
+			return nil, ErrBadRequest
+		}
+	}
+	for t, enabled := range prefs {
+		if err := s.repo.SetPreference(userID, t, enabled); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetPreferences(userID)
+}
@@ -0,0 +1,430 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// กัน zip bomb: stream เดียวคลายได้ไม่เกินนี้
+	maxStreamBytes = 64 << 20
+	// ลึกสุดของ page tree / form xobject
+	maxDepth = 32
+	// ขอบเขตของ PNG predictor ตามสเปค
+	maxPredictorColors = 32
+	maxPredictorBPC    = 16
+)
+
+var (
+	ErrNoPages   = errors.New("pdftext: no pages found")
+	ErrEncrypted = errors.New("pdftext: encrypted pdf")
+
+	objRe     = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	trailerRe = regexp.MustCompile(`trailer\s*<<`)
+)
+
+// ไม่อ่าน xref table: สแกนหา "n g obj" ทั้งไฟล์ ตัวหลังทับตัวก่อน (incremental update)
+// ทนกับไฟล์ที่ xref เพี้ยนได้ดีกว่า และพอสำหรับดึงข้อความ
+type document struct {
+	objs    map[int]any
+	trailer dict
+}
+
+func parse(data []byte) (*document, error) {
+	d := &document{objs: map[int]any{}, trailer: dict{}}
+
+	var objStms []stream
+	end := 0
+	for _, m := range objRe.FindAllSubmatchIndex(data, -1) {
+		if m[0] < end {
+			// อยู่ใน stream ของ object ก่อนหน้า
+			continue
+		}
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		l := &lexer{b: data, pos: m[1]}
+		v, ok := l.next()
+		if !ok {
+			break
+		}
+		if dd, isDict := v.(dict); isDict {
+			if raw, next, ok := streamData(data, l.pos, dd); ok {
+				s := stream{dict: dd, raw: raw}
+				v, l.pos = s, next
+				if dd["Type"] == name("ObjStm") {
+					objStms = append(objStms, s)
+				}
+				if dd["Type"] == name("XRef") {
+					mergeTrailer(d.trailer, dd)
+				}
+			}
+		}
+		d.objs[num] = v
+		end = l.pos
+	}
+
+	for _, loc := range trailerRe.FindAllIndex(data, -1) {
+		l := &lexer{b: data, pos: loc[1] - 2}
+		if v, ok := l.next(); ok {
+			if t, isDict := v.(dict); isDict {
+				mergeTrailer(d.trailer, t)
+			}
+		}
+	}
+
+	for _, s := range objStms {
+		d.loadObjStm(s)
+	}
+
+	if _, ok := d.trailer["Encrypt"]; ok {
+		return nil, ErrEncrypted
+	}
+	return d, nil
+}
+
+// trailer ตัวหลังสุดชนะ (ไฟล์ที่แก้ทีหลังต่อท้าย)
+func mergeTrailer(dst, src dict) {
+	for _, k := range []name{"Root", "Encrypt"} {
+		if v, ok := src[k]; ok {
+			dst[k] = v
+		}
+	}
+}
+
+// หลัง dict ถ้าตามด้วย "stream" → ตัดข้อมูลถึง endstream
+func streamData(data []byte, pos int, d dict) ([]byte, int, bool) {
+	if pos < 0 || pos > len(data) {
+		return nil, pos, false
+	}
+	l := &lexer{b: data, pos: pos}
+	l.skipSpace()
+	if !bytes.HasPrefix(data[l.pos:], []byte("stream")) {
+		return nil, pos, false
+	}
+	start := l.pos + len("stream")
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+
+	// ใช้ /Length ถ้าเป็นตัวเลขตรงๆ และลงท้ายด้วย endstream จริง
+	if n, ok := d["Length"].(float64); ok && n >= 0 && start+int(n) <= len(data) {
+		stop := start + int(n)
+		tail := &lexer{b: data, pos: stop}
+		tail.skipSpace()
+		if bytes.HasPrefix(data[tail.pos:], []byte("endstream")) {
+			return data[start:stop], tail.pos + len("endstream"), true
+		}
+	}
+
+	idx := bytes.Index(data[start:], []byte("endstream"))
+	if idx < 0 {
+		return data[start:], len(data), true
+	}
+	raw := bytes.TrimRight(data[start:start+idx], "\r\n")
+	return raw, start + idx + len("endstream"), true
+}
+
+func (d *document) loadObjStm(s stream) {
+	data, err := d.decode(s)
+	if err != nil {
+		return
+	}
+	n, _ := d.resolve(s.dict["N"]).(float64)
+	first, _ := d.resolve(s.dict["First"]).(float64)
+	// ค่าจากไฟล์เชื่อไม่ได้: เทียบเป็น float ก่อนแปลง (ค่าใหญ่มากแปลงเป็น int แล้วเพี้ยน)
+	if first < 0 || first > float64(len(data)) {
+		return
+	}
+
+	head := &lexer{b: data[:int(first)]}
+	for i := 0; float64(i) < n; i++ {
+		numV, ok1 := head.next()
+		offV, ok2 := head.next()
+		num, isNum := numV.(float64)
+		off, isOff := offV.(float64)
+		if !ok1 || !ok2 || !isNum || !isOff {
+			return
+		}
+		if off < 0 || first+off >= float64(len(data)) {
+			continue
+		}
+		pos := int(first) + int(off)
+		// object ตรงๆ ในไฟล์ (ถ้ามี) มาจาก incremental update → ไม่ทับ
+		if _, exists := d.objs[int(num)]; exists {
+			continue
+		}
+		l := &lexer{b: data, pos: pos}
+		if v, ok := l.next(); ok {
+			d.objs[int(num)] = v
+		}
+	}
+}
+
+func (d *document) resolve(v any) any {
+	for i := 0; i < maxDepth; i++ {
+		r, ok := v.(ref)
+		if !ok {
+			return v
+		}
+		v = d.objs[r.num]
+	}
+	return nil
+}
+
+func (d *document) dictOf(v any) dict {
+	switch t := d.resolve(v).(type) {
+	case dict:
+		return t
+	case stream:
+		return t.dict
+	}
+	return nil
+}
+
+// คลาย filter ของ stream (Flate / ASCIIHex / ASCII85) ตามลำดับ
+func (d *document) decode(s stream) ([]byte, error) {
+	filters := d.resolve(s.dict["Filter"])
+	params := d.resolve(s.dict["DecodeParms"])
+
+	var fs []any
+	var ps []any
+	switch f := filters.(type) {
+	case nil:
+		return s.raw, nil
+	case name:
+		fs, ps = []any{f}, []any{params}
+	case array:
+		fs = f
+		if pa, ok := params.(array); ok {
+			ps = pa
+		}
+	default:
+		return nil, fmt.Errorf("pdftext: bad filter %v", f)
+	}
+
+	data := s.raw
+	for i, f := range fs {
+		var p dict
+		if i < len(ps) {
+			p = d.dictOf(ps[i])
+		}
+		var err error
+		switch d.resolve(f) {
+		case name("FlateDecode"), name("Fl"):
+			data, err = inflate(data)
+			if err == nil {
+				data, err = d.predict(data, p)
+			}
+		case name("ASCIIHexDecode"), name("AHx"):
+			data = (&lexer{b: append(append([]byte{'<'}, data...), '>')}).hex()
+		case name("ASCII85Decode"), name("A85"):
+			data, err = decode85(data)
+		default:
+			return nil, fmt.Errorf("pdftext: unsupported filter %v", f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func inflate(b []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxStreamBytes+1))
+	if len(out) > maxStreamBytes {
+		return nil, fmt.Errorf("pdftext: stream too large")
+	}
+	// stream ที่ตัดท้ายมาไม่ครบ (unexpected EOF) ยังใช้ส่วนที่อ่านได้
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decode85(b []byte) ([]byte, error) {
+	if i := bytes.Index(b, []byte("~>")); i >= 0 {
+		b = b[:i]
+	}
+	b = bytes.TrimPrefix(bytes.TrimSpace(b), []byte("<~"))
+	out := make([]byte, 4*len(b))
+	n, _, err := ascii85.Decode(out, b, true)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// PNG predictor (ใช้กับ object stream ที่บีบด้วย /Predictor >= 10)
+func (d *document) predict(data []byte, p dict) ([]byte, error) {
+	if p == nil {
+		return data, nil
+	}
+	pred, _ := d.resolve(p["Predictor"]).(float64)
+	if pred < 10 {
+		return data, nil
+	}
+	// ค่าจากไฟล์กำหนดขนาด buffer → จำกัดก่อน (สเปค: Colors ≤ 32, BitsPerComponent ≤ 16)
+	cols := 1
+	if c, ok := d.resolve(p["Columns"]).(float64); ok && c > 0 {
+		if c > maxStreamBytes {
+			return nil, fmt.Errorf("pdftext: bad predictor columns %v", c)
+		}
+		cols = int(c)
+	}
+	colors := 1
+	if c, ok := d.resolve(p["Colors"]).(float64); ok && c > 0 {
+		if c > maxPredictorColors {
+			return nil, fmt.Errorf("pdftext: bad predictor colors %v", c)
+		}
+		colors = int(c)
+	}
+	bpc := 8
+	if c, ok := d.resolve(p["BitsPerComponent"]).(float64); ok && c > 0 {
+		if c > maxPredictorBPC {
+			return nil, fmt.Errorf("pdftext: bad predictor bits per component %v", c)
+		}
+		bpc = int(c)
+	}
+	bpp := max(1, colors*bpc/8)
+	rowLen := (cols*colors*bpc + 7) / 8
+	if rowLen >= len(data) {
+		// ไม่มีแถวเต็มสักแถว
+		return data[:0], nil
+	}
+
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowLen)
+	for i := 0; i+1+rowLen <= len(data); i += 1 + rowLen {
+		ft := data[i]
+		row := append([]byte(nil), data[i+1:i+1+rowLen]...)
+		for j := range row {
+			var left, upLeft byte
+			if j >= bpp {
+				left, upLeft = row[j-bpp], prev[j-bpp]
+			}
+			up := prev[j]
+			switch ft {
+			case 1:
+				row[j] += left
+			case 2:
+				row[j] += up
+			case 3:
+				row[j] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[j] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// ไล่ page tree จาก /Root → /Pages เก็บ resources ที่สืบทอดมาด้วย
+type page struct {
+	dict      dict
+	resources dict
+}
+
+func (d *document) pages() ([]page, error) {
+	root := d.dictOf(d.trailer["Root"])
+	if root == nil {
+		// ไม่มี trailer (ไฟล์เสีย) → หา catalog เอง
+		for _, v := range d.objs {
+			if dd, ok := v.(dict); ok && dd["Type"] == name("Catalog") {
+				root = dd
+				break
+			}
+		}
+	}
+	if root == nil {
+		return nil, ErrNoPages
+	}
+
+	var out []page
+	seen := map[int]bool{}
+	var walk func(v any, res dict, depth int)
+	walk = func(v any, res dict, depth int) {
+		if depth > maxDepth {
+			return
+		}
+		if r, ok := v.(ref); ok {
+			if seen[r.num] {
+				return
+			}
+			seen[r.num] = true
+		}
+		node := d.dictOf(v)
+		if node == nil {
+			return
+		}
+		if r := d.dictOf(node["Resources"]); r != nil {
+			res = r
+		}
+		if kids, ok := d.resolve(node["Kids"]).(array); ok {
+			for _, k := range kids {
+				walk(k, res, depth+1)
+			}
+			return
+		}
+		out = append(out, page{dict: node, resources: res})
+	}
+	walk(root["Pages"], nil, 0)
+
+	if len(out) == 0 {
+		return nil, ErrNoPages
+	}
+	return out, nil
+}
+
+// รวม content stream ของหน้า (อาจเป็น array หลาย stream)
+func (d *document) contents(v any) []byte {
+	switch t := d.resolve(v).(type) {
+	case stream:
+		b, err := d.decode(t)
+		if err != nil {
+			return nil
+		}
+		return b
+	case array:
+		var buf bytes.Buffer
+		for _, item := range t {
+			buf.Write(d.contents(item))
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes()
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package pdftext
+
+// ตาราง encoding มาตรฐานของ simple font (ส่วน 0x00-0x7F เป็น ASCII เหมือนกัน)
+var winAnsi, macRoman [256]rune
+
+// WinAnsiEncoding ช่วง 0x80-0x9F (ที่เหลือตรงกับ Latin-1)
+var winAnsiHigh = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+// MacRomanEncoding ช่วง 0x80-0xFF
+var macRomanHigh = [128]rune{
+	0xC4, 0xC5, 0xC7, 0xC9, 0xD1, 0xD6, 0xDC, 0xE1, 0xE0, 0xE2, 0xE4, 0xE3, 0xE5, 0xE7, 0xE9, 0xE8,
+	0xEA, 0xEB, 0xED, 0xEC, 0xEE, 0xEF, 0xF1, 0xF3, 0xF2, 0xF4, 0xF6, 0xF5, 0xFA, 0xF9, 0xFB, 0xFC,
+	0x2020, 0xB0, 0xA2, 0xA3, 0xA7, 0x2022, 0xB6, 0xDF, 0xAE, 0xA9, 0x2122, 0xB4, 0xA8, 0x2260, 0xC6, 0xD8,
+	0x221E, 0xB1, 0x2264, 0x2265, 0xA5, 0xB5, 0x2202, 0x2211, 0x220F, 0x3C0, 0x222B, 0xAA, 0xBA, 0x3A9, 0xE6, 0xF8,
+	0xBF, 0xA1, 0xAC, 0x221A, 0x192, 0x2248, 0x2206, 0xAB, 0xBB, 0x2026, 0xA0, 0xC0, 0xC3, 0xD5, 0x152, 0x153,
+	0x2013, 0x2014, 0x201C, 0x201D, 0x2018, 0x2019, 0xF7, 0x25CA, 0xFF, 0x178, 0x2044, 0x20AC, 0x2039, 0x203A, 0xFB01, 0xFB02,
+	0x2021, 0xB7, 0x201A, 0x201E, 0x2030, 0xC2, 0xCA, 0xC1, 0xCB, 0xC8, 0xCD, 0xCE, 0xCF, 0xCC, 0xD3, 0xD4,
+	0xF8FF, 0xD2, 0xDA, 0xDB, 0xD9, 0x131, 0x2C6, 0x2DC, 0xAF, 0x2D8, 0x2D9, 0x2DA, 0xB8, 0x2DD, 0x2DB, 0x2C7,
+}
+
+// ชื่อ glyph ที่พบบ่อยใน /Differences
+var glyphNames = map[string]rune{
+	"space": ' ', "exclam": '!', "quotedbl": '"', "numbersign": '#', "dollar": '$',
+	"percent": '%', "ampersand": '&', "quotesingle": '\'', "parenleft": '(', "parenright": ')',
+	"asterisk": '*', "plus": '+', "comma": ',', "hyphen": '-', "period": '.', "slash": '/',
+	"zero": '0', "one": '1', "two": '2', "three": '3', "four": '4',
+	"five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+	"colon": ':', "semicolon": ';', "less": '<', "equal": '=', "greater": '>', "question": '?',
+	"at": '@', "bracketleft": '[', "backslash": '\\', "bracketright": ']', "asciicircum": '^',
+	"underscore": '_', "grave": '`', "braceleft": '{', "bar": '|', "braceright": '}', "asciitilde": '~',
+	"quoteleft": '‘', "quoteright": '’', "quotedblleft": '“', "quotedblright": '”',
+	"quotesinglbase": '‚', "quotedblbase": '„', "bullet": '•', "endash": '–',
+	"emdash": '—', "ellipsis": '…', "minus": '−', "fi": 'ﬁ', "fl": 'ﬂ',
+	"degree": '°', "copyright": '©', "registered": '®', "trademark": '™',
+	"multiply": '×', "divide": '÷', "plusminus": '±', "section": '§',
+	"paragraph": '¶', "dagger": '†', "daggerdbl": '‡', "Euro": '€',
+	"nbspace": ' ', "periodcentered": '·', "dotlessi": 'ı',
+}
+
+func init() {
+	for i := 0x20; i < 0x7F; i++ {
+		winAnsi[i], macRoman[i] = rune(i), rune(i)
+	}
+	for _, c := range []int{'\t', '\n', '\r'} {
+		winAnsi[c], macRoman[c] = ' ', ' '
+	}
+	for i, r := range winAnsiHigh {
+		winAnsi[0x80+i] = r
+	}
+	for i := 0xA0; i <= 0xFF; i++ {
+		winAnsi[i] = rune(i)
+	}
+	for i, r := range macRomanHigh {
+		macRoman[0x80+i] = r
+	}
+}
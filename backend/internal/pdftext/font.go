@@ -0,0 +1,260 @@
+package pdftext
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// แปลงรหัสใน string ของ Tj/TJ เป็นข้อความ
+// ใช้ ToUnicode CMap ก่อน ถ้าไม่มีใช้ encoding ของ simple font (WinAnsi + Differences)
+type font struct {
+	cmap *cmap
+	// Type0 (CID font) ไม่มี ToUnicode → ถอดไม่ได้
+	composite bool
+	enc       *[256]rune
+}
+
+type codeRange struct {
+	lo, hi []byte
+}
+
+type cmap struct {
+	ranges []codeRange
+	m      map[string]string
+}
+
+func (d *document) loadFont(v any) *font {
+	fd := d.dictOf(v)
+	if fd == nil {
+		return &font{enc: &winAnsi}
+	}
+	f := &font{composite: fd["Subtype"] == name("Type0")}
+	if s, ok := d.resolve(fd["ToUnicode"]).(stream); ok {
+		if data, err := d.decode(s); err == nil {
+			f.cmap = parseCMap(data)
+		}
+	}
+	if !f.composite {
+		f.enc = d.simpleEncoding(fd)
+	}
+	return f
+}
+
+func (d *document) simpleEncoding(fd dict) *[256]rune {
+	base := winAnsi
+	switch e := d.resolve(fd["Encoding"]).(type) {
+	case name:
+		if e == "MacRomanEncoding" {
+			base = macRoman
+		}
+	case dict:
+		if d.resolve(e["BaseEncoding"]) == name("MacRomanEncoding") {
+			base = macRoman
+		}
+		if diffs, ok := d.resolve(e["Differences"]).(array); ok {
+			code := 0
+			for _, item := range diffs {
+				switch t := d.resolve(item).(type) {
+				case float64:
+					code = int(t)
+				case name:
+					if code >= 0 && code < 256 {
+						if r, ok := glyphRune(string(t)); ok {
+							base[code] = r
+						}
+					}
+					code++
+				}
+			}
+		}
+	}
+	return &base
+}
+
+func (f *font) decode(s []byte) string {
+	var b strings.Builder
+	for len(s) > 0 {
+		n := f.codeLen(s)
+		code := s[:n]
+		s = s[n:]
+
+		if f.cmap != nil {
+			if u, ok := f.cmap.m[string(code)]; ok {
+				b.WriteString(u)
+				continue
+			}
+		}
+		if f.composite {
+			continue
+		}
+		if r := f.enc[code[len(code)-1]]; r != 0 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ความยาวรหัสตาม codespacerange (Type0 ปกติ 2 ไบต์, simple font 1 ไบต์)
+func (f *font) codeLen(s []byte) int {
+	if f.cmap != nil {
+		for _, r := range f.cmap.ranges {
+			n := len(r.lo)
+			if n <= len(s) && bytes.Compare(s[:n], r.lo) >= 0 && bytes.Compare(s[:n], r.hi) <= 0 {
+				return n
+			}
+		}
+	}
+	if f.composite && len(s) >= 2 {
+		return 2
+	}
+	return 1
+}
+
+func parseCMap(data []byte) *cmap {
+	c := &cmap{m: map[string]string{}}
+	l := &lexer{b: data}
+	var ops []any
+	for {
+		v, ok := l.next()
+		if !ok {
+			break
+		}
+		kw, isKw := v.(keyword)
+		if !isKw {
+			ops = append(ops, v)
+			continue
+		}
+		switch kw {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(ops); i += 2 {
+				lo, ok1 := ops[i].(pdfStr)
+				hi, ok2 := ops[i+1].(pdfStr)
+				if ok1 && ok2 && len(lo) == len(hi) && len(lo) > 0 {
+					c.ranges = append(c.ranges, codeRange{lo: lo, hi: hi})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(ops); i += 2 {
+				src, ok1 := ops[i].(pdfStr)
+				dst, ok2 := ops[i+1].(pdfStr)
+				if ok1 && ok2 {
+					c.m[string(src)] = utf16String(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(ops); i += 3 {
+				lo, ok1 := ops[i].(pdfStr)
+				hi, ok2 := ops[i+1].(pdfStr)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 || len(lo) > 4 {
+					continue
+				}
+				c.addRange(lo, hi, ops[i+2])
+			}
+		}
+		if strings.HasPrefix(string(kw), "begin") || strings.HasPrefix(string(kw), "end") {
+			ops = ops[:0]
+		}
+	}
+	return c
+}
+
+// กัน cmap ผิดรูปที่ประกาศช่วงกว้างเกินจริง
+const maxRange = 1 << 16
+
+func (c *cmap) addRange(lo, hi []byte, dst any) {
+	start, end := beUint(lo), beUint(hi)
+	if end < start || end-start > maxRange {
+		return
+	}
+	for code := start; code <= end; code++ {
+		key := string(putBE(code, len(lo)))
+		off := int(code - start)
+		switch t := dst.(type) {
+		case pdfStr:
+			// เพิ่มค่าที่ไบต์ท้ายของปลายทาง
+			if len(t) == 0 {
+				return
+			}
+			u := append([]byte(nil), t...)
+			if len(u) < 2 {
+				u = append([]byte{0}, u...)
+			}
+			v := beUint(u[len(u)-2:]) + uint32(off)
+			copy(u[len(u)-2:], putBE(v&0xffff, 2))
+			c.m[key] = utf16String(u)
+		case array:
+			if off < len(t) {
+				if s, ok := t[off].(pdfStr); ok {
+					c.m[key] = utf16String(s)
+				}
+			}
+		}
+	}
+}
+
+func beUint(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func putBE(v uint32, n int) []byte {
+	out := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		out[i] = byte(v)
+		v >>= 8
+	}
+	return out
+}
+
+func utf16String(b []byte) string {
+	if len(b)%2 == 1 {
+		b = append([]byte{0}, b...)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(u))
+}
+
+// ชื่อ glyph → rune (พอสำหรับ Differences ทั่วไป: uniXXXX, ชื่อ ASCII และสัญลักษณ์ที่พบบ่อย)
+func glyphRune(g string) (rune, bool) {
+	if i := strings.IndexByte(g, '.'); i > 0 {
+		g = g[:i]
+	}
+	if r, ok := glyphNames[g]; ok {
+		return r, true
+	}
+	if len(g) == 7 && strings.HasPrefix(g, "uni") {
+		if v, ok := parseHexRune(g[3:]); ok {
+			return v, true
+		}
+	}
+	if len(g) >= 5 && len(g) <= 7 && g[0] == 'u' {
+		if v, ok := parseHexRune(g[1:]); ok {
+			return v, true
+		}
+	}
+	if utf8.RuneCountInString(g) == 1 {
+		r, _ := utf8.DecodeRuneInString(g)
+		return r, true
+	}
+	return 0, false
+}
+
+func parseHexRune(s string) (rune, bool) {
+	var v rune
+	for i := 0; i < len(s); i++ {
+		h, ok := hexVal(s[i])
+		if !ok {
+			return 0, false
+		}
+		v = v<<4 | rune(h)
+	}
+	return v, utf8.ValidRune(v)
+}
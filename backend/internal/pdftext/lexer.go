@@ -0,0 +1,313 @@
+package pdftext
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// ชนิดของ object ใน PDF (เท่าที่ต้องใช้ดึงข้อความ)
+type (
+	name    string
+	keyword string
+	pdfStr  []byte
+	array   []any
+	dict    map[name]any
+	ref     struct{ num, gen int }
+	stream  struct {
+		dict dict
+		raw  []byte
+	}
+)
+
+type lexer struct {
+	b   []byte
+	pos int
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		if isSpace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.b) && l.b[l.pos] != '\n' && l.b[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// อ่าน object ถัดไป (ตัวเลข / string / name / array / dict / ref / keyword) ok=false เมื่อหมด
+func (l *lexer) next() (any, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.b) {
+		return nil, false
+	}
+	c := l.b[l.pos]
+	switch {
+	case c == '/':
+		return l.name(), true
+	case c == '(':
+		return l.literal(), true
+	case c == '<' && l.pos+1 < len(l.b) && l.b[l.pos+1] == '<':
+		l.pos += 2
+		return l.dict(), true
+	case c == '<':
+		return l.hex(), true
+	case c == '[':
+		l.pos++
+		return l.array(), true
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		// ปิดเกิน / ไม่รองรับ → ข้าม
+		l.pos++
+		if c == '>' && l.pos < len(l.b) && l.b[l.pos] == '>' {
+			l.pos++
+			return keyword(">>"), true
+		}
+		return keyword(string(c)), true
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.number(), true
+	default:
+		start := l.pos
+		for l.pos < len(l.b) && !isSpace(l.b[l.pos]) && !isDelim(l.b[l.pos]) {
+			l.pos++
+		}
+		if l.pos == start {
+			l.pos++
+		}
+		switch kw := string(l.b[start:l.pos]); kw {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		case "null":
+			return nil, true
+		default:
+			return keyword(kw), true
+		}
+	}
+}
+
+func (l *lexer) name() name {
+	l.pos++ // '/'
+	var out []byte
+	for l.pos < len(l.b) && !isSpace(l.b[l.pos]) && !isDelim(l.b[l.pos]) {
+		c := l.b[l.pos]
+		if c == '#' && l.pos+2 < len(l.b) {
+			if v, err := strconv.ParseUint(string(l.b[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		out = append(out, c)
+		l.pos++
+	}
+	return name(out)
+}
+
+func (l *lexer) number() any {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		if (c >= '0' && c <= '9') || c == '.' {
+			l.pos++
+			continue
+		}
+		break
+	}
+	tok := string(l.b[start:l.pos])
+	if n, err := strconv.Atoi(tok); err == nil {
+		// "n g R" = reference
+		save := l.pos
+		l.skipSpace()
+		gStart := l.pos
+		for l.pos < len(l.b) && l.b[l.pos] >= '0' && l.b[l.pos] <= '9' {
+			l.pos++
+		}
+		if l.pos > gStart {
+			g, _ := strconv.Atoi(string(l.b[gStart:l.pos]))
+			l.skipSpace()
+			if l.pos < len(l.b) && l.b[l.pos] == 'R' && (l.pos+1 >= len(l.b) || isSpace(l.b[l.pos+1]) || isDelim(l.b[l.pos+1])) {
+				l.pos++
+				return ref{num: n, gen: g}
+			}
+		}
+		l.pos = save
+		return float64(n)
+	}
+	f, _ := strconv.ParseFloat(tok, 64)
+	return f
+}
+
+func (l *lexer) literal() pdfStr {
+	l.pos++ // '('
+	var out []byte
+	depth := 1
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.b) {
+				return out
+			}
+			e := l.b[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.b) && l.b[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.b) && l.b[l.pos] >= '0' && l.b[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.b[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (l *lexer) hex() pdfStr {
+	l.pos++ // '<'
+	if l.pos > len(l.b) {
+		l.pos = len(l.b)
+	}
+	end := bytes.IndexByte(l.b[l.pos:], '>')
+	if end < 0 {
+		// ไม่มี '>' ปิด → ถึงท้ายข้อมูล (pos ห้ามเกิน len)
+		end = len(l.b) - l.pos
+	}
+	raw := l.b[l.pos : l.pos+end]
+	l.pos = min(l.pos+end+1, len(l.b))
+
+	var out []byte
+	var hi byte
+	half := false
+	for _, c := range raw {
+		v, ok := hexVal(c)
+		if !ok {
+			continue
+		}
+		if !half {
+			hi, half = v, true
+			continue
+		}
+		out = append(out, hi<<4|v)
+		half = false
+	}
+	if half {
+		out = append(out, hi<<4)
+	}
+	return out
+}
+
+func hexVal(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func (l *lexer) array() array {
+	var out array
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.b) {
+			return out
+		}
+		if l.b[l.pos] == ']' {
+			l.pos++
+			return out
+		}
+		v, ok := l.next()
+		if !ok {
+			return out
+		}
+		out = append(out, v)
+	}
+}
+
+func (l *lexer) dict() dict {
+	out := dict{}
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.b) {
+			return out
+		}
+		if l.b[l.pos] == '>' {
+			l.pos++
+			if l.pos < len(l.b) && l.b[l.pos] == '>' {
+				l.pos++
+			}
+			return out
+		}
+		k, ok := l.next()
+		if !ok {
+			return out
+		}
+		key, isName := k.(name)
+		if !isName {
+			continue
+		}
+		v, ok := l.next()
+		if !ok {
+			return out
+		}
+		out[key] = v
+	}
+}
@@ -0,0 +1,162 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ประกอบ PDF จาก object ตามลำดับ (object แรก = 1) trailer ชี้ /Root 1 0 R
+func buildPDF(objs ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	for i, o := range objs {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\n%%%%EOF\n", len(objs)+1)
+	return b.Bytes()
+}
+
+func streamObj(dict string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func deflate(t testing.TB, data []byte) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+const helvetica = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+
+func TestExtractPages(t *testing.T) {
+	pdf := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 7 0 R >>",
+		helvetica,
+		streamObj("", []byte("BT /F1 12 Tf 72 720 Td (Hello World) Tj ET")),
+		streamObj("", []byte("BT /F1 12 Tf 72 720 Td (Second page) Tj ET")),
+	)
+
+	pages, err := Extract(pdf)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	want := []string{"Hello World", "Second page"}
+	if len(pages) != len(want) {
+		t.Fatalf("got %d pages %q, want %d", len(pages), pages, len(want))
+	}
+	for i := range want {
+		if pages[i] != want[i] {
+			t.Errorf("page %d = %q, want %q", i+1, pages[i], want[i])
+		}
+	}
+}
+
+func TestExtractFlateAndObjStm(t *testing.T) {
+	content := deflate(t, []byte("BT /F1 12 Tf 72 720 Td (Compressed text) Tj ET"))
+
+	// object 6 (page) และ 7 (font) อยู่ใน object stream ที่บีบด้วย Flate
+	body := "<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 7 0 R >> >> >> " + helvetica
+	second := strings.Index(body, helvetica)
+	head := fmt.Sprintf("6 0 7 %d ", second)
+	objStm := deflate(t, []byte(head+body))
+
+	pdf := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [6 0 R] /Count 1 >>",
+		streamObj(fmt.Sprintf("/Type /ObjStm /N 2 /First %d /Filter /FlateDecode", len(head)), objStm),
+		streamObj("/Filter /FlateDecode", content),
+	)
+
+	pages, err := Extract(pdf)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(pages) != 1 || pages[0] != "Compressed text" {
+		t.Fatalf("got %q, want [\"Compressed text\"]", pages)
+	}
+}
+
+func TestExtractEncrypted(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R /Encrypt 2 0 R >>\n")
+	if _, err := Extract(pdf); err != ErrEncrypted {
+		t.Fatalf("err = %v, want ErrEncrypted", err)
+	}
+}
+
+// ไฟล์ผิดรูปจากผู้ใช้ต้องคืน error หรือผลว่าง ห้าม panic / จองหน่วยความจำตามค่าในไฟล์
+func TestExtractMalformed(t *testing.T) {
+	hugePredictor := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		streamObj("/Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns 1e15 /Colors 1e9 /BitsPerComponent 1e9 >>",
+			deflate(t, []byte("\x00abc"))),
+	)
+	bigRow := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		streamObj("/Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns 60000000 /Colors 32 /BitsPerComponent 16 >>",
+			deflate(t, []byte("\x00abc"))),
+	)
+
+	cases := map[string][]byte{
+		"negative first": buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			streamObj("/Type /ObjStm /N 1 /First -5", []byte("2 0 << /Type /Pages >>")),
+		),
+		"negative offset": buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			streamObj("/Type /ObjStm /N 1 /First 6", []byte("2 -50 << /Type /Pages >>")),
+		),
+		"huge first":      buildPDF(streamObj("/Type /ObjStm /N 1e30 /First 1e30", []byte("2 0 <<>>"))),
+		"unclosed hex":    []byte("%PDF-0 0 obj<<<"),
+		"unclosed dict":   []byte("%PDF-1 0 obj<< /Length 5 >>"),
+		"huge predictor":  hugePredictor,
+		"big row":         bigRow,
+		"self kids":       buildPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [2 0 R] >>"),
+		"ref loop":        buildPDF("<< /Type /Catalog /Pages 2 0 R >>", "3 0 R", "2 0 R"),
+		"truncated":       []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R\n2 0 obj\n<< /Kids [3 0 R"),
+		"stream no end":   []byte("%PDF-1.7\n1 0 obj\n<< /Length 999 >>\nstream\nabc"),
+		"not a pdf":       []byte("hello"),
+		"empty":           nil,
+		"header only":     []byte("%PDF-"),
+		"objstm no first": buildPDF(streamObj("/Type /ObjStm /N 3", []byte("1 0 2 5"))),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _ = Extract(data)
+		})
+	}
+}
+
+func FuzzExtract(f *testing.F) {
+	f.Add([]byte("%PDF-0 0 obj<<<"))
+	f.Add(buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		streamObj("", []byte("BT /F1 12 Tf (Hello) Tj [(W) -300 (orld)] TJ ET")),
+		helvetica,
+	))
+	f.Add(buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		streamObj("/Type /ObjStm /N 1 /First 4", []byte("2 0 << /Type /Pages /Kids [] >>")),
+	))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = Extract(data)
+	})
+}
@@ -0,0 +1,316 @@
+// Package pdftext ดึงข้อความจาก PDF ด้วย Go ล้วน (ไม่ต้องมี poppler / Colab)
+// ได้ข้อความที่พอใช้ค้นหาและทำ keyword ไม่ได้รักษา layout
+package pdftext
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// TJ ที่ขยับเกินนี้ (1/1000 em) ถือว่าเว้นวรรค
+	tjSpace = -200
+	// กันไฟล์ที่ประกาศหน้ามาเกินจริง
+	maxPages = 5000
+	// กัน operand stack โตไม่จำกัดจาก content ผิดรูป
+	maxOperands = 1024
+)
+
+// ExtractFile อ่าน PDF แล้วคืนข้อความทีละหน้า (index 0 = หน้า 1) หน้าที่ไม่มีข้อความได้ ""
+func ExtractFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Extract(data)
+}
+
+func Extract(data []byte) ([]string, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, fmt.Errorf("pdftext: not a pdf")
+	}
+	d, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := d.pages()
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) > maxPages {
+		pages = pages[:maxPages]
+	}
+
+	e := &extractor{d: d, fonts: map[int]*font{}}
+	out := make([]string, len(pages))
+	for i, p := range pages {
+		e.reset()
+		e.run(d.contents(p.dict["Contents"]), p.resources, 0)
+		out[i] = normalize(e.out.String())
+	}
+	return out, nil
+}
+
+type extractor struct {
+	d     *document
+	fonts map[int]*font
+
+	out   strings.Builder
+	font  *font
+	shown bool
+
+	// ตำแหน่งบรรทัดคร่าวๆ ใช้ตัดสินว่าขึ้นบรรทัดใหม่หรือเว้นวรรค
+	y, scale, leading float64
+	lastY             float64
+	moved, forceNL    bool
+	pendingSpace      bool
+}
+
+func (e *extractor) reset() {
+	e.out.Reset()
+	e.font = nil
+	e.shown = false
+	e.y, e.scale, e.leading, e.lastY = 0, 1, 0, 0
+	e.moved, e.forceNL, e.pendingSpace = false, false, false
+}
+
+func (e *extractor) run(content []byte, res dict, depth int) {
+	if depth > maxDepth || len(content) == 0 {
+		return
+	}
+	l := &lexer{b: content}
+	var ops []any
+	for {
+		v, ok := l.next()
+		if !ok {
+			return
+		}
+		kw, isKw := v.(keyword)
+		if !isKw {
+			if len(ops) < maxOperands {
+				ops = append(ops, v)
+			}
+			continue
+		}
+		e.op(string(kw), ops, res, depth, l)
+		ops = ops[:0]
+	}
+}
+
+func (e *extractor) op(kw string, ops []any, res dict, depth int, l *lexer) {
+	switch kw {
+	case "BT":
+		e.y, e.scale = 0, 1
+	case "Tf":
+		if len(ops) >= 2 {
+			if n, ok := ops[len(ops)-2].(name); ok {
+				e.font = e.lookupFont(res, n)
+			}
+		}
+	case "TL":
+		e.leading = num(ops, 0)
+	case "Td", "TD":
+		ty := num(ops, 1)
+		if kw == "TD" {
+			e.leading = -ty
+		}
+		e.y += ty * e.scale
+		e.moved = true
+	case "Tm":
+		if len(ops) >= 6 {
+			e.scale = math.Abs(num(ops, 3))
+			if e.scale == 0 {
+				e.scale = 1
+			}
+			e.y = num(ops, 5)
+			e.moved = true
+		}
+	case "T*":
+		e.nextLine()
+	case "Tj":
+		if s, ok := last(ops).(pdfStr); ok {
+			e.show(s)
+		}
+	case "'":
+		e.nextLine()
+		if s, ok := last(ops).(pdfStr); ok {
+			e.show(s)
+		}
+	case "\"":
+		e.nextLine()
+		if s, ok := last(ops).(pdfStr); ok {
+			e.show(s)
+		}
+	case "TJ":
+		arr, _ := last(ops).(array)
+		for _, item := range arr {
+			switch t := item.(type) {
+			case pdfStr:
+				e.show(t)
+			case float64:
+				if t < tjSpace {
+					e.pendingSpace = true
+				}
+			}
+		}
+	case "Do":
+		if n, ok := last(ops).(name); ok {
+			e.form(res, n, depth)
+		}
+	case "BI":
+		skipInlineImage(l)
+	}
+}
+
+func (e *extractor) nextLine() {
+	if e.leading != 0 {
+		e.y -= e.leading * e.scale
+	}
+	e.forceNL = true
+}
+
+func (e *extractor) show(s pdfStr) {
+	f := e.font
+	if f == nil {
+		f = &font{enc: &winAnsi}
+	}
+	text := f.decode(s)
+	if text == "" {
+		return
+	}
+
+	if e.shown {
+		switch {
+		case e.forceNL || math.Abs(e.y-e.lastY) > 1:
+			e.out.WriteByte('\n')
+		case e.moved || e.pendingSpace:
+			e.space(text)
+		}
+	}
+	e.out.WriteString(text)
+	e.shown = true
+	e.lastY = e.y
+	e.moved, e.forceNL, e.pendingSpace = false, false, false
+}
+
+// ภาษาไทยไม่เว้นวรรคระหว่างคำ: run ที่ถูกจัดตำแหน่งแยกกันมักเป็นคำเดียวกัน → ไม่เติมช่องว่าง
+func (e *extractor) space(next string) {
+	cur := e.out.String()
+	if cur == "" {
+		return
+	}
+	p, _ := utf8.DecodeLastRuneInString(cur)
+	n, _ := utf8.DecodeRuneInString(next)
+	if unicode.IsSpace(p) || unicode.IsSpace(n) || isThai(p) || isThai(n) {
+		return
+	}
+	e.out.WriteByte(' ')
+}
+
+func isThai(r rune) bool {
+	return r >= 0x0E00 && r <= 0x0E7F
+}
+
+func (e *extractor) lookupFont(res dict, n name) *font {
+	fonts := e.d.dictOf(res["Font"])
+	if fonts == nil {
+		return nil
+	}
+	v := fonts[n]
+	if r, ok := v.(ref); ok {
+		if f, ok := e.fonts[r.num]; ok {
+			return f
+		}
+		f := e.d.loadFont(r)
+		e.fonts[r.num] = f
+		return f
+	}
+	return e.d.loadFont(v)
+}
+
+// Form XObject มี content ของตัวเอง (resources ของ form ก่อน ไม่มีใช้ของหน้า)
+func (e *extractor) form(res dict, n name, depth int) {
+	xobjs := e.d.dictOf(res["XObject"])
+	if xobjs == nil {
+		return
+	}
+	s, ok := e.d.resolve(xobjs[n]).(stream)
+	if !ok || s.dict["Subtype"] != name("Form") {
+		return
+	}
+	data, err := e.d.decode(s)
+	if err != nil {
+		return
+	}
+	formRes := e.d.dictOf(s.dict["Resources"])
+	if formRes == nil {
+		formRes = res
+	}
+	saved := e.font
+	e.moved = true
+	e.run(data, formRes, depth+1)
+	e.font = saved
+}
+
+// BI ... ID <binary> EI: ข้ามข้อมูลรูปทั้งก้อน ไม่ให้ lexer อ่านเป็น operator
+func skipInlineImage(l *lexer) {
+	for {
+		v, ok := l.next()
+		if !ok {
+			return
+		}
+		if v == keyword("ID") {
+			break
+		}
+	}
+	l.pos++
+	for i := l.pos; i+1 < len(l.b); i++ {
+		if l.b[i] == 'E' && l.b[i+1] == 'I' && i > 0 && isSpace(l.b[i-1]) &&
+			(i+2 >= len(l.b) || isSpace(l.b[i+2]) || isDelim(l.b[i+2])) {
+			l.pos = i + 2
+			return
+		}
+	}
+	l.pos = len(l.b)
+}
+
+func num(ops []any, i int) float64 {
+	if i < len(ops) {
+		if f, ok := ops[i].(float64); ok {
+			return f
+		}
+	}
+	return 0
+}
+
+func last(ops []any) any {
+	if len(ops) == 0 {
+		return nil
+	}
+	return ops[len(ops)-1]
+}
+
+// ยุบช่องว่างซ้ำ ตัดบรรทัดว่าง ทิ้งอักขระควบคุม
+func normalize(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.Map(func(r rune) rune {
+			switch {
+			case r == unicode.ReplacementChar || r == 0:
+				return -1
+			case unicode.IsSpace(r) || unicode.IsControl(r):
+				return ' '
+			}
+			return r
+		}, line)
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
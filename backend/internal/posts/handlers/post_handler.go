@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -68,7 +70,7 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"data": gin.H{"post_id": postID}})
 }
 
-// ดึงโพสต์ทั้งหมด (ต้องล็อกอิน)
+// ดึงโพสต์ทั้งหมด (ต้องล็อกอิน) ค้นหา / กรอง / แบ่งหน้าได้ด้วย ?search=&tag=&author=&visibility=&from=&to=&sort=&limit=&cursor=
 func (h *PostHandler) GetAllPosts(c *gin.Context) {
 	uid := c.GetInt("user_id")
 	if uid == 0 {
@@ -76,12 +78,23 @@ func (h *PostHandler) GetAllPosts(c *gin.Context) {
 		return
 	}
 
-	posts, err := h.postService.GetFeedPosts(uid)
+	var param models.PostQueryParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
+		return
+	}
+
+	// ไม่ส่งเงื่อนไขมาเลย = หน้าแรกของ feed (ใหม่สุดก่อน limit ค่า default) ไม่ดึงทั้งหมดแล้ว
+	posts, next, err := h.postService.SearchPosts(uid, param)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": posts})
+	c.JSON(http.StatusOK, gin.H{"data": posts, "next_cursor": next})
 }
 
 // รายละเอียดโพสต์ (ต้องล็อกอิน)
@@ -114,6 +127,12 @@ func (h *PostHandler) GetPostByID(c *gin.Context) {
 		}
 		return
 	}
+	// เจ้าของเปิดดูโพสต์ตัวเองไม่นับวิว / นับไม่สำเร็จไม่ต้องให้ผู้ใช้เห็น error
+	if reason != "owner" {
+		if err := h.postService.RecordView(uid, id); err != nil {
+			log.Printf("[POST] record view post=%d user=%d err=%v", id, uid, err)
+		}
+	}
 
 	post, err := h.postService.GetPostByID(id)
 	if err != nil {
@@ -124,6 +143,7 @@ func (h *PostHandler) GetPostByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
 		return
 	}
+	h.postService.AttachFileURL(post)
 	c.JSON(http.StatusOK, gin.H{"data": post})
 }
 
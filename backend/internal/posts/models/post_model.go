@@ -9,6 +9,15 @@ const (
 	VisibilityFriends = "friends"
 )
 
+// การเรียงผลของ GET /posts?sort=
+const (
+	SortRelevance = "relevance"
+	SortNewest    = "newest"
+	SortMostLiked = "most_liked"
+	SortMostSaved = "most_saved"
+	SortTrending  = "trending"
+)
+
 // post
 type Post struct {
 	PostID       int       `json:"post_id"`
@@ -18,6 +27,9 @@ type Post struct {
 	Visibility   string    `json:"post_visibility"`
 	DocumentID   *int      `json:"post_document_id"`
 	CoverURL     *string   `json:"post_cover_url"`
+	CoverPath    *string   `json:"-"` // ตำแหน่งไฟล์หน้าปกใน storage
+	CoverBucket  *string   `json:"-"`
+	CoverStorage *string   `json:"-"`
 	CreatedAt    time.Time `json:"post_created_at"`
 	UpdatedAt    time.Time `json:"post_updated_at"`
 }
@@ -52,6 +64,7 @@ type PostStats struct {
 	PostID         int       `json:"post_id"`
 	LikeCount      int       `json:"like_count"`
 	SaveCount      int       `json:"save_count"`
+	CommentCount   int       `json:"comment_count"`
 	LastActivityAt time.Time `json:"last_activity_at"`
 }
 
@@ -75,8 +88,26 @@ type PostResponse struct {
 	LikeCount int      `json:"like_count"`
 	SaveCount int      `json:"save_count"`
 
+	CommentCount int `json:"comment_count"`
+
 	IsLiked bool `json:"is_liked"`
 	IsSaved bool `json:"is_saved"`
+
+	// srcset ของรูปที่ผ่าน pipeline (รูปเก่าไม่มี)
+	CoverSrcSet  string `json:"cover_srcset,omitempty"`
+	AvatarSrcSet string `json:"avatar_srcset,omitempty"`
+
+	// เฉพาะผลค้นหา: คะแนนความเกี่ยวข้อง และข้อความรอบคำที่เจอ (คำค้นครอบด้วย <mark>)
+	Score   float64 `json:"score,omitempty"`
+	Snippet string  `json:"snippet,omitempty"`
+
+	// ค่าที่ใช้เรียง (text ตามที่ postgres แสดง) ไว้สร้าง cursor หน้าถัดไป
+	SortKey string `json:"-"`
+
+	// ตำแหน่งไฟล์จริง ใช้สร้าง FileURL แบบ signed (ไม่ส่งให้ client)
+	StorageProvider *string `json:"-"`
+	DocumentPath    *string `json:"-"`
+	DocumentBucket  *string `json:"-"`
 }
 
 type UpdatePostRequest struct {
@@ -91,4 +122,31 @@ type PostQueryParam struct {
 	Tag    []string `form:"tag"`
 	Sort   string   `form:"sort"`
 	Limit  int      `form:"limit"`
+
+	// feed: keyset pagination และตัวกรองเพิ่มเติม (from / to = YYYY-MM-DD หรือ RFC3339)
+	Cursor     string `form:"cursor"`
+	Author     int    `form:"author"`
+	Visibility string `form:"visibility"`
+	From       string `form:"from"`
+	To         string `form:"to"`
+}
+
+// query ที่ผ่านการ normalize แล้ว (service → repository)
+type PostSearch struct {
+	Query string   // ข้อความค้นทั้งก้อน ใช้กับ full-text
+	Terms []string // คำค้นแยกตามช่องว่าง ใช้ ILIKE (ภาษาไทยไม่มีช่องว่างระหว่างคำ)
+	Tags  []string
+	Sort  string
+	Limit int
+
+	Author     int
+	Visibility string
+	From, To   *time.Time // To ไม่รวม
+	After      *PostCursor
+}
+
+// ตำแหน่งหลังรายการสุดท้ายของหน้าก่อน
+type PostCursor struct {
+	Key    string
+	PostID int
 }
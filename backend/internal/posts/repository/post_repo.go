@@ -3,6 +3,8 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/lib/pq"
 
@@ -16,11 +18,14 @@ type PostRepository interface {
 
 	GetAllPosts() ([]models.PostResponse, error)
 	GetPostByID(postID int) (*models.PostResponse, error)
-	GetFeedPosts(viewerID int) ([]models.PostResponse, error)
+	SearchPosts(viewerID int, in models.PostSearch) ([]models.PostResponse, error)
 	GetPostOwnerID(postID int) (int, error)
 	CountByUserID(userID int) (int, error)
 
 	GetSavedPosts(userID int) ([]models.PostResponse, error)
+
+	RecordView(postID, userID int) error
+	RefreshTrending(window time.Duration) (int64, error)
 }
 
 type postRepository struct {
@@ -44,14 +49,22 @@ func (r *postRepository) CreatePost(post *models.Post, tags []string) (int, erro
 	var docArg interface{} = *post.DocumentID
 
 	var coverArg interface{} = nil
-	if post.CoverURL != nil {
+	if post.CoverURL != nil && strings.TrimSpace(*post.CoverURL) != "" {
 		coverArg = *post.CoverURL
 	}
 
+	// ไม่ได้อัปหน้าปก → ใช้หน้าปกที่ render จากหน้าแรกของเอกสาร (ถ้ามีแล้ว)
+	// โพสต์ผูกกับ family ของเอกสาร และชี้เวอร์ชันปัจจุบัน (ส่ง id เวอร์ชันเก่ามาก็ได้)
 	query := `INSERT INTO posts (post_author_user_id, post_title, post_description,
-			  post_visibility, post_document_id, post_cover_url) 
-			  SELECT $1, $2, $3, $4, $5, $6
+			  post_visibility, post_document_id, post_document_family_id, post_cover_url,
+			  post_cover_path, post_cover_bucket, post_cover_storage)
+			  SELECT $1, $2, $3, $4, cur.document_id, cur.document_family_id,
+			         COALESCE($6, cur.document_cover_url),
+			         CASE WHEN $6::text IS NULL THEN cur.document_cover_path ELSE $7 END,
+			         CASE WHEN $6::text IS NULL THEN cur.document_cover_bucket ELSE $8 END,
+			         CASE WHEN $6::text IS NULL THEN cur.document_cover_storage ELSE $9 END
 			  FROM documents d
+			  JOIN documents cur ON cur.document_family_id = d.document_family_id AND cur.document_is_current
 			  WHERE d.document_id = $5 AND d.document_user_id = $1
 			  RETURNING post_id;`
 
@@ -60,6 +73,7 @@ func (r *postRepository) CreatePost(post *models.Post, tags []string) (int, erro
 		query,
 		post.AuthorUserID, post.Title, post.Description,
 		post.Visibility, docArg, coverArg,
+		post.CoverPath, post.CoverBucket, post.CoverStorage,
 	).Scan(&postID); err != nil {
 		if err == sql.ErrNoRows {
 			return 0, fmt.Errorf("invalid document_id or not owned by user")
@@ -165,8 +179,10 @@ func (r *postRepository) GetAllPosts() ([]models.PostResponse, error) {
 		p.post_document_id, p.post_created_at, p.post_updated_at,
 		COALESCE(ps.post_like_count, 0) AS post_like_count,
 		COALESCE(ps.post_save_count, 0) AS post_save_count,
+		COALESCE(ps.post_comment_count, 0) AS post_comment_count,
 		d.document_url AS document_file_url,
 		d.document_name AS document_name,
+		d.storage_provider, d.document_path, d.document_bucket,
 		p.post_cover_url, up.avatar_url,
 		ARRAY_REMOVE(ARRAY_AGG(DISTINCT t.tag_name), NULL) AS tags
 	FROM posts p
@@ -176,7 +192,7 @@ func (r *postRepository) GetAllPosts() ([]models.PostResponse, error) {
 	LEFT JOIN tags t ON t.tag_id = pt.post_tag_tag_id
 	LEFT JOIN documents d ON d.document_id = p.post_document_id
 	LEFT JOIN user_profiles up ON up.profile_user_id = u.user_id
-	GROUP BY p.post_id, u.username, ps.post_like_count, ps.post_save_count, d.document_url, d.document_name, p.post_cover_url, up.avatar_url
+	GROUP BY p.post_id, u.username, ps.post_like_count, ps.post_save_count, ps.post_comment_count, d.document_url, d.document_name, d.storage_provider, d.document_path, d.document_bucket, p.post_cover_url, up.avatar_url
 	ORDER BY p.post_created_at DESC;`
 
 	rows, err := r.db.Query(query)
@@ -188,110 +204,24 @@ func (r *postRepository) GetAllPosts() ([]models.PostResponse, error) {
 	var posts []models.PostResponse
 	for rows.Next() {
 		var (
-			p         models.PostResponse
-			tags      pq.StringArray
-			fileURL   sql.NullString
-			docName   sql.NullString
-			coverURL  sql.NullString
-			avatarURL sql.NullString
-			docID     sql.NullInt64
+			p          models.PostResponse
+			tags       pq.StringArray
+			fileURL    sql.NullString
+			docName    sql.NullString
+			docStorage sql.NullString
+			docPath    sql.NullString
+			docBucket  sql.NullString
+			coverURL   sql.NullString
+			avatarURL  sql.NullString
+			docID      sql.NullInt64
 		)
 
 		if err := rows.Scan(
 			&p.PostID, &p.AuthorID, &p.AuthorName,
 			&p.Title, &p.Description, &p.Visibility,
 			&docID, &p.CreatedAt, &p.UpdatedAt,
-			&p.LikeCount, &p.SaveCount,
-			&fileURL, &docName, &coverURL, &avatarURL, &tags,
-		); err != nil {
-			return nil, err
-		}
-
-		if docID.Valid {
-			v := int(docID.Int64)
-			p.DocumentID = &v
-		}
-		if fileURL.Valid {
-			p.FileURL = &fileURL.String
-		}
-		if docName.Valid {
-			p.DocumentName = &docName.String
-		}
-		if coverURL.Valid {
-			p.CoverURL = &coverURL.String
-		}
-		if avatarURL.Valid {
-			p.AvatarURL = &avatarURL.String
-		}
-
-		p.Tags = []string(tags)
-		posts = append(posts, p)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return posts, nil
-}
-
-func (r *postRepository) GetFeedPosts(viewerID int) ([]models.PostResponse, error) {
-	query := `
-		SELECT p.post_id, p.post_author_user_id, u.username AS author_name,
-			p.post_title, p.post_description, p.post_visibility,
-			p.post_document_id, p.post_created_at, p.post_updated_at,
-			COALESCE(ps.post_like_count, 0) AS post_like_count,
-			COALESCE(ps.post_save_count, 0) AS post_save_count,
-			d.document_url AS document_file_url,
-			d.document_name AS document_name,
-			p.post_cover_url, up.avatar_url,
-			ARRAY_REMOVE(ARRAY_AGG(DISTINCT t.tag_name), NULL) AS tags
-		FROM posts p
-		JOIN users u ON u.user_id = p.post_author_user_id
-		LEFT JOIN post_stats ps ON ps.post_stats_post_id = p.post_id
-		LEFT JOIN post_tags pt ON pt.post_tag_post_id = p.post_id
-		LEFT JOIN tags t ON t.tag_id = pt.post_tag_tag_id
-		LEFT JOIN documents d ON d.document_id = p.post_document_id
-		LEFT JOIN user_profiles up ON up.profile_user_id = u.user_id
-		WHERE
-			p.post_author_user_id = $1
-			OR p.post_visibility = 'public'
-			OR ( p.post_visibility = 'friends'
-				AND EXISTS (
-					SELECT 1
-					FROM friendships f
-					WHERE
-						f.user_id  = LEAST(p.post_author_user_id, $1)
-						AND f.friend_id = GREATEST(p.post_author_user_id, $1)
-				)
-			)
-		GROUP BY p.post_id, u.username, ps.post_like_count, ps.post_save_count,
-				 d.document_url, d.document_name, p.post_cover_url, up.avatar_url
-		ORDER BY p.post_created_at DESC;
-	`
-
-	rows, err := r.db.Query(query, viewerID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var posts []models.PostResponse
-	for rows.Next() {
-		var (
-			p         models.PostResponse
-			tags      pq.StringArray
-			fileURL   sql.NullString
-			docName   sql.NullString
-			coverURL  sql.NullString
-			avatarURL sql.NullString
-			docID     sql.NullInt64
-		)
-		if err := rows.Scan(
-			&p.PostID, &p.AuthorID, &p.AuthorName,
-			&p.Title, &p.Description, &p.Visibility,
-			&docID, &p.CreatedAt, &p.UpdatedAt,
-			&p.LikeCount, &p.SaveCount,
-			&fileURL, &docName, &coverURL, &avatarURL, &tags,
+			&p.LikeCount, &p.SaveCount, &p.CommentCount,
+			&fileURL, &docName, &docStorage, &docPath, &docBucket, &coverURL, &avatarURL, &tags,
 		); err != nil {
 			return nil, err
 		}
@@ -306,6 +236,7 @@ func (r *postRepository) GetFeedPosts(viewerID int) ([]models.PostResponse, erro
 		if docName.Valid {
 			p.DocumentName = &docName.String
 		}
+		setDocumentObject(&p, docStorage, docPath, docBucket)
 		if coverURL.Valid {
 			p.CoverURL = &coverURL.String
 		}
@@ -329,8 +260,10 @@ func (r *postRepository) GetPostByID(postID int) (*models.PostResponse, error) {
 		p.post_created_at, p.post_updated_at,
 		COALESCE(ps.post_like_count, 0)  AS post_like_count,
 		COALESCE(ps.post_save_count, 0)  AS post_save_count,
+		COALESCE(ps.post_comment_count, 0) AS post_comment_count,
 		d.document_url AS document_file_url,
 		d.document_name AS document_name,
+		d.storage_provider, d.document_path, d.document_bucket,
 		p.post_cover_url, up.avatar_url,
 		ARRAY_REMOVE(ARRAY_AGG(DISTINCT t.tag_name), NULL) AS tags
 	FROM posts p
@@ -341,25 +274,28 @@ func (r *postRepository) GetPostByID(postID int) (*models.PostResponse, error) {
 	LEFT JOIN documents d ON d.document_id = p.post_document_id
 	LEFT JOIN user_profiles up ON up.profile_user_id = u.user_id
 	WHERE p.post_id = $1
-	GROUP BY p.post_id, u.username, ps.post_like_count, ps.post_save_count, d.document_url, d.document_name, up.avatar_url;`
+	GROUP BY p.post_id, u.username, ps.post_like_count, ps.post_save_count, ps.post_comment_count, d.document_url, d.document_name, d.storage_provider, d.document_path, d.document_bucket, up.avatar_url;`
 
 	row := r.db.QueryRow(query, postID)
 	var (
-		p         models.PostResponse
-		tags      pq.StringArray
-		fileURL   sql.NullString
-		docName   sql.NullString
-		coverURL  sql.NullString
-		avatarURL sql.NullString
-		docID     sql.NullInt64
+		p          models.PostResponse
+		tags       pq.StringArray
+		fileURL    sql.NullString
+		docName    sql.NullString
+		docStorage sql.NullString
+		docPath    sql.NullString
+		docBucket  sql.NullString
+		coverURL   sql.NullString
+		avatarURL  sql.NullString
+		docID      sql.NullInt64
 	)
 
 	if err := row.Scan(
 		&p.PostID, &p.AuthorID, &p.AuthorName,
 		&p.Title, &p.Description, &p.Visibility,
 		&docID, &p.CreatedAt, &p.UpdatedAt,
-		&p.LikeCount, &p.SaveCount,
-		&fileURL, &docName, &coverURL, &avatarURL, &tags,
+		&p.LikeCount, &p.SaveCount, &p.CommentCount,
+		&fileURL, &docName, &docStorage, &docPath, &docBucket, &coverURL, &avatarURL, &tags,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, sql.ErrNoRows
@@ -376,6 +312,7 @@ func (r *postRepository) GetPostByID(postID int) (*models.PostResponse, error) {
 	if docName.Valid {
 		p.DocumentName = &docName.String
 	}
+	setDocumentObject(&p, docStorage, docPath, docBucket)
 	if coverURL.Valid {
 		p.CoverURL = &coverURL.String
 	}
@@ -402,6 +339,46 @@ func (r *postRepository) CountByUserID(userID int) (int, error) {
 	return cnt, err
 }
 
+// นับวิวไม่ซ้ำคนต่อวัน: insert ได้จริงค่อยบวกยอด (trigger คิด trending ให้)
+func (r *postRepository) RecordView(postID, userID int) error {
+	query := `
+		WITH v AS (
+			INSERT INTO post_views (post_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+			RETURNING post_id
+		)
+		INSERT INTO post_stats (post_stats_post_id, post_view_count)
+		SELECT post_id, 1 FROM v
+		ON CONFLICT (post_stats_post_id)
+		DO UPDATE SET post_view_count = post_stats.post_view_count + 1;
+	`
+	_, err := r.db.Exec(query, postID, userID)
+	return err
+}
+
+// คะแนนลดตามอายุแม้ไม่มีใครกด → คิดใหม่ตามรอบ
+// โพสต์ที่เก่ากว่า window ตั้งเป็น 0 ครั้งเดียวแล้วไม่ต้องแตะอีก
+func (r *postRepository) RefreshTrending(window time.Duration) (int64, error) {
+	query := `
+		UPDATE post_stats ps
+		SET post_trending_score = CASE
+			WHEN p.post_created_at > now() - make_interval(secs => $1)
+			THEN COALESCE(post_trending_score(ps.post_like_count, ps.post_save_count,
+				ps.post_view_count, ps.post_comment_count, p.post_created_at), 0)
+			ELSE 0
+		END
+		FROM posts p
+		WHERE p.post_id = ps.post_stats_post_id
+		  AND (p.post_created_at > now() - make_interval(secs => $1) OR ps.post_trending_score <> 0);
+	`
+	res, err := r.db.Exec(query, window.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (r *postRepository) GetSavedPosts(userID int) ([]models.PostResponse, error) {
 	query := `
         SELECT p.post_id, p.post_author_user_id, u.username AS author_name,
@@ -409,8 +386,10 @@ func (r *postRepository) GetSavedPosts(userID int) ([]models.PostResponse, error
                p.post_document_id, p.post_created_at, p.post_updated_at,
                COALESCE(ps.post_like_count, 0) AS post_like_count,
                COALESCE(ps.post_save_count, 0) AS post_save_count,
+               COALESCE(ps.post_comment_count, 0) AS post_comment_count,
                d.document_url AS document_file_url,
 			   d.document_name AS document_name,
+			   d.storage_provider, d.document_path, d.document_bucket,
                p.post_cover_url, up.avatar_url,
                ARRAY_REMOVE(ARRAY_AGG(DISTINCT t.tag_name), NULL) AS tags
         FROM saved_posts sp
@@ -435,8 +414,8 @@ func (r *postRepository) GetSavedPosts(userID int) ([]models.PostResponse, error
                 )
               )
           )
-        GROUP BY p.post_id, u.username, ps.post_like_count, ps.post_save_count,
-                 d.document_url, d.document_name, p.post_cover_url, up.avatar_url
+        GROUP BY p.post_id, u.username, ps.post_like_count, ps.post_save_count, ps.post_comment_count,
+                 d.document_url, d.document_name, d.storage_provider, d.document_path, d.document_bucket, p.post_cover_url, up.avatar_url
         ORDER BY p.post_created_at DESC;
     `
 	rows, err := r.db.Query(query, userID)
@@ -448,24 +427,290 @@ func (r *postRepository) GetSavedPosts(userID int) ([]models.PostResponse, error
 	var posts []models.PostResponse
 	for rows.Next() {
 		var (
-			p         models.PostResponse
-			tags      pq.StringArray
-			fileURL   sql.NullString
-			docName   sql.NullString
-			coverURL  sql.NullString
-			avatarURL sql.NullString
-			docID     sql.NullInt64
+			p          models.PostResponse
+			tags       pq.StringArray
+			fileURL    sql.NullString
+			docName    sql.NullString
+			docStorage sql.NullString
+			docPath    sql.NullString
+			docBucket  sql.NullString
+			coverURL   sql.NullString
+			avatarURL  sql.NullString
+			docID      sql.NullInt64
 		)
 
 		if err := rows.Scan(
 			&p.PostID, &p.AuthorID, &p.AuthorName,
 			&p.Title, &p.Description, &p.Visibility,
 			&docID, &p.CreatedAt, &p.UpdatedAt,
-			&p.LikeCount, &p.SaveCount,
-			&fileURL, &docName, &coverURL, &avatarURL, &tags,
+			&p.LikeCount, &p.SaveCount, &p.CommentCount,
+			&fileURL, &docName, &docStorage, &docPath, &docBucket, &coverURL, &avatarURL, &tags,
+		); err != nil {
+			return nil, err
+		}
+		if docID.Valid {
+			v := int(docID.Int64)
+			p.DocumentID = &v
+		}
+		if fileURL.Valid {
+			p.FileURL = &fileURL.String
+		}
+		if docName.Valid {
+			p.DocumentName = &docName.String
+		}
+		setDocumentObject(&p, docStorage, docPath, docBucket)
+		if coverURL.Valid {
+			p.CoverURL = &coverURL.String
+		}
+		if avatarURL.Valid {
+			p.AvatarURL = &avatarURL.String
+		}
+
+		p.Tags = []string(tags)
+		posts = append(posts, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// ตำแหน่งไฟล์เอกสารไว้ให้ service สร้าง signed URL หลังตรวจสิทธิ์
+func setDocumentObject(p *models.PostResponse, provider, path, bucket sql.NullString) {
+	if provider.Valid {
+		p.StorageProvider = &provider.String
+	}
+	if path.Valid {
+		p.DocumentPath = &path.String
+	}
+	if bucket.Valid {
+		p.DocumentBucket = &bucket.String
+	}
+}
+
+// expression ที่ใช้เรียงของแต่ละ sort (คู่กับ p.post_id เป็น keyset)
+var sortKeys = map[string]string{
+	models.SortRelevance: "s.score",
+	models.SortNewest:    "p.post_created_at",
+	models.SortMostLiked: "COALESCE(ps.post_like_count, 0)",
+	models.SortMostSaved: "COALESCE(ps.post_save_count, 0)",
+	// คะแนนที่เก็บไว้ใน post_stats (trigger + refresh ตามรอบ ดู migration 015)
+	// ไม่ครอบ COALESCE เพื่อให้ใช้ idx_post_stats_trending ได้ (ทุกโพสต์มีแถว stats ดู migration 020)
+	models.SortTrending: "ps.post_trending_score",
+}
+
+// โพสต์ที่ viewer มีสิทธิ์เห็น (ของตัวเอง / public / friends ที่เป็นเพื่อนกัน) + ตัวกรอง / ค้นหา / keyset pagination
+// ค้นหา: หาโพสต์ที่มีคำที่ยาวสุดหรือตรง full-text ด้วยเงื่อนไขที่ใช้ index ได้ก่อน (matched)
+// แล้วค่อยกรองให้ครบทุกคำและคิดคะแนน
+func (r *postRepository) SearchPosts(viewerID int, in models.PostSearch) ([]models.PostResponse, error) {
+	key, ok := sortKeys[in.Sort]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort: %s", in.Sort)
+	}
+	search := in.Query != "" && len(in.Terms) > 0
+	if in.Sort == models.SortRelevance && !search {
+		return nil, fmt.Errorf("sort=relevance requires search")
+	}
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	viewer := arg(viewerID)
+
+	var with, joins, score, snippet string
+	where := []string{`( p.post_author_user_id = ` + viewer + `
+			OR p.post_visibility = 'public'
+			OR ( p.post_visibility = 'friends'
+				AND EXISTS (
+					SELECT 1
+					FROM friendships f
+					WHERE
+						f.user_id  = LEAST(p.post_author_user_id, ` + viewer + `)
+						AND f.friend_id = GREATEST(p.post_author_user_id, ` + viewer + `)
+				)
+			)
+		)`}
+
+	if search {
+		query, terms := arg(in.Query), arg(pq.Array(in.Terms))
+		// คำที่ยาวสุดมักแคบสุด ใช้หา candidate ผ่าน trigram index
+		pattern := ""
+		for _, t := range in.Terms {
+			if len(t) > len(pattern) {
+				pattern = t
+			}
+		}
+		pat := arg("%" + likeEscaper.Replace(pattern) + "%")
+
+		with = `
+	WITH q AS (
+		SELECT websearch_to_tsquery('simple', ` + query + `) AS tsq
+	),
+	matched AS (
+		SELECT p.post_id FROM posts p
+		WHERE p.post_title ILIKE ` + pat + ` OR p.post_description ILIKE ` + pat + `
+		UNION
+		SELECT pt.post_tag_post_id FROM post_tags pt
+		JOIN tags t ON t.tag_id = pt.post_tag_tag_id
+		WHERE t.tag_name ILIKE ` + pat + `
+		UNION
+		SELECT p.post_id FROM posts p
+		JOIN document_features df ON df.document_id = p.post_document_id
+		WHERE df.content_text ILIKE ` + pat + `
+		UNION
+		SELECT p.post_id FROM posts p, q
+		WHERE to_tsvector('simple', COALESCE(p.post_title, '') || ' ' || COALESCE(p.post_description, '')) @@ q.tsq
+		UNION
+		SELECT p.post_id FROM posts p
+		JOIN document_features df ON df.document_id = p.post_document_id, q
+		WHERE to_tsvector('simple', left(COALESCE(df.content_text, ''), 100000)) @@ q.tsq
+	)`
+		joins = `
+	CROSS JOIN q
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(string_agg(t.tag_name, ' '), '') AS tag_text
+		FROM post_tags pt
+		JOIN tags t ON t.tag_id = pt.post_tag_tag_id
+		WHERE pt.post_tag_post_id = p.post_id
+	) tg
+	CROSS JOIN LATERAL (
+		SELECT MIN(strpos(lower(df.content_text), term)) FILTER (WHERE strpos(lower(df.content_text), term) > 0) AS pos
+		FROM unnest(` + terms + `::text[]) AS term
+	) hit
+	CROSS JOIN LATERAL (
+		SELECT
+			ts_rank(to_tsvector('simple', COALESCE(p.post_title, '') || ' ' || COALESCE(p.post_description, '')), q.tsq)::float8 * 2
+			+ ts_rank(to_tsvector('simple', left(COALESCE(df.content_text, ''), 100000)), q.tsq)::float8
+			+ COALESCE((
+				SELECT SUM(
+					CASE WHEN strpos(lower(COALESCE(p.post_title, '')), term) > 0 THEN 3 ELSE 0 END
+					+ CASE WHEN strpos(tg.tag_text, term) > 0 THEN 2 ELSE 0 END
+					+ CASE WHEN strpos(lower(COALESCE(p.post_description, '')), term) > 0 THEN 1 ELSE 0 END
+					+ CASE WHEN strpos(lower(COALESCE(df.content_text, '')), term) > 0 THEN 0.5 ELSE 0 END
+				)::float8
+				FROM unnest(` + terms + `::text[]) AS term
+			), 0) AS score
+	) s`
+		score = "s.score"
+		snippet = `
+		CASE WHEN hit.pos IS NOT NULL
+			THEN substring(df.content_text FROM GREATEST(1, hit.pos - 80) FOR 240)
+			ELSE left(COALESCE(p.post_description, ''), 240)
+		END,
+		COALESCE(hit.pos > 81, FALSE),
+		CASE WHEN hit.pos IS NOT NULL
+			THEN char_length(df.content_text) >= GREATEST(1, hit.pos - 80) + 240
+			ELSE char_length(COALESCE(p.post_description, '')) > 240
+		END`
+		where = append(where, `p.post_id IN (SELECT post_id FROM matched)
+		AND ( to_tsvector('simple', COALESCE(p.post_title, '') || ' ' || COALESCE(p.post_description, '')) @@ q.tsq
+			OR to_tsvector('simple', left(COALESCE(df.content_text, ''), 100000)) @@ q.tsq
+			OR NOT EXISTS (
+				SELECT 1
+				FROM unnest(`+terms+`::text[]) AS term
+				WHERE strpos(lower(COALESCE(p.post_title, '')), term) = 0
+				  AND strpos(lower(COALESCE(p.post_description, '')), term) = 0
+				  AND strpos(tg.tag_text, term) = 0
+				  AND strpos(lower(COALESCE(df.content_text, '')), term) = 0
+			)
+		)`)
+	} else {
+		score = "0::float8"
+		snippet = "'', FALSE, FALSE"
+	}
+
+	if len(in.Tags) > 0 {
+		where = append(where, `EXISTS (
+			SELECT 1
+			FROM post_tags pt
+			JOIN tags t ON t.tag_id = pt.post_tag_tag_id
+			WHERE pt.post_tag_post_id = p.post_id AND t.tag_name = ANY(`+arg(pq.Array(in.Tags))+`::text[])
+		)`)
+	}
+	if in.Author > 0 {
+		where = append(where, "p.post_author_user_id = "+arg(in.Author))
+	}
+	if in.Visibility != "" {
+		where = append(where, "p.post_visibility = "+arg(in.Visibility))
+	}
+	if in.From != nil {
+		where = append(where, "p.post_created_at >= "+arg(*in.From))
+	}
+	if in.To != nil {
+		where = append(where, "p.post_created_at < "+arg(*in.To))
+	}
+	if in.After != nil {
+		// cursor เก็บค่าเป็น text → postgres แปลงกลับเป็นชนิดเดียวกับ expression เอง
+		where = append(where, fmt.Sprintf("(%s, p.post_id) < (%s, %s)", key, arg(in.After.Key), arg(in.After.PostID)))
+	}
+
+	q := with + `
+	SELECT p.post_id, p.post_author_user_id, u.username AS author_name,
+		p.post_title, p.post_description, p.post_visibility,
+		p.post_document_id, p.post_created_at, p.post_updated_at,
+		COALESCE(ps.post_like_count, 0) AS post_like_count,
+		COALESCE(ps.post_save_count, 0) AS post_save_count,
+		COALESCE(ps.post_comment_count, 0) AS post_comment_count,
+		d.document_url AS document_file_url,
+		d.document_name AS document_name,
+		d.storage_provider, d.document_path, d.document_bucket,
+		p.post_cover_url, up.avatar_url,
+		ARRAY(
+			SELECT t.tag_name
+			FROM post_tags pt
+			JOIN tags t ON t.tag_id = pt.post_tag_tag_id
+			WHERE pt.post_tag_post_id = p.post_id
+			ORDER BY t.tag_name
+		) AS tags,
+		` + score + `, ` + snippet + `,
+		(` + key + `)::text AS sort_key
+	FROM posts p
+	JOIN users u ON u.user_id = p.post_author_user_id
+	JOIN post_stats ps ON ps.post_stats_post_id = p.post_id
+	LEFT JOIN documents d ON d.document_id = p.post_document_id
+	LEFT JOIN document_features df ON df.document_id = p.post_document_id
+	LEFT JOIN user_profiles up ON up.profile_user_id = u.user_id` + joins + `
+	WHERE ` + strings.Join(where, "\n\tAND ") + `
+	ORDER BY ` + key + ` DESC, p.post_id DESC
+	LIMIT ` + arg(in.Limit) + `;`
+
+	rows, err := r.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]models.PostResponse, 0, in.Limit)
+	for rows.Next() {
+		var (
+			p          models.PostResponse
+			tags       pq.StringArray
+			fileURL    sql.NullString
+			docName    sql.NullString
+			docStorage sql.NullString
+			docPath    sql.NullString
+			docBucket  sql.NullString
+			coverURL   sql.NullString
+			avatarURL  sql.NullString
+			docID      sql.NullInt64
+			headCut    bool
+			tailCut    bool
+		)
+		if err := rows.Scan(
+			&p.PostID, &p.AuthorID, &p.AuthorName,
+			&p.Title, &p.Description, &p.Visibility,
+			&docID, &p.CreatedAt, &p.UpdatedAt,
+			&p.LikeCount, &p.SaveCount, &p.CommentCount,
+			&fileURL, &docName, &docStorage, &docPath, &docBucket, &coverURL, &avatarURL, &tags,
+			&p.Score, &p.Snippet, &headCut, &tailCut, &p.SortKey,
 		); err != nil {
 			return nil, err
 		}
+
 		if docID.Valid {
 			v := int(docID.Int64)
 			p.DocumentID = &v
@@ -476,12 +721,19 @@ func (r *postRepository) GetSavedPosts(userID int) ([]models.PostResponse, error
 		if docName.Valid {
 			p.DocumentName = &docName.String
 		}
+		setDocumentObject(&p, docStorage, docPath, docBucket)
 		if coverURL.Valid {
 			p.CoverURL = &coverURL.String
 		}
 		if avatarURL.Valid {
 			p.AvatarURL = &avatarURL.String
 		}
+		if headCut {
+			p.Snippet = "…" + p.Snippet
+		}
+		if tailCut {
+			p.Snippet += "…"
+		}
 
 		p.Tags = []string(tags)
 		posts = append(posts, p)
@@ -492,3 +744,5 @@ func (r *postRepository) GetSavedPosts(userID int) ([]models.PostResponse, error
 	}
 	return posts, nil
 }
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -1,6 +1,11 @@
 package service
 
-import "chaladshare_backend/internal/posts/repository"
+import (
+	"chaladshare_backend/internal/posts/repository"
+
+	notimodels "chaladshare_backend/internal/notifications/models"
+	notiservice "chaladshare_backend/internal/notifications/service"
+)
 
 type LikeService interface {
 	ToggleLike(userID, postID int) (isLiked bool, likeCount int, err error)
@@ -9,10 +14,11 @@ type LikeService interface {
 
 type likeService struct {
 	likeRepo repository.LikeRepository
+	notifier notiservice.Notifier
 }
 
-func NewLikeService(likeRepo repository.LikeRepository) LikeService {
-	return &likeService{likeRepo: likeRepo}
+func NewLikeService(likeRepo repository.LikeRepository, notifier notiservice.Notifier) LikeService {
+	return &likeService{likeRepo: likeRepo, notifier: notifier}
 }
 
 func (s *likeService) ToggleLike(userID, postID int) (bool, int, error) {
@@ -33,6 +39,8 @@ func (s *likeService) ToggleLike(userID, postID int) (bool, int, error) {
 			return false, 0, err
 		}
 		liked = true
+		// แจ้งเจ้าของโพสต์ (ไม่แจ้งถ้ากดโพสต์ตัวเอง)
+		s.notifier.Notify(notimodels.Event{Type: notimodels.TypePostLike, ActorID: userID, PostID: postID})
 	}
 
 	// 3) ดึงจำนวนไลก์ล่าสุด
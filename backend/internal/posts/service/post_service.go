@@ -3,11 +3,15 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
 
 	friendservice "chaladshare_backend/internal/friends/service"
+	"chaladshare_backend/internal/imaging"
+	notiservice "chaladshare_backend/internal/notifications/service"
 	"chaladshare_backend/internal/posts/models"
 	"chaladshare_backend/internal/posts/repository"
+	"chaladshare_backend/internal/storage"
 )
 
 type PostService interface {
@@ -16,26 +20,33 @@ type PostService interface {
 	DeletePost(postID int) error
 
 	GetAllPosts() ([]models.PostResponse, error)
-	GetFeedPosts(viewerID int) ([]models.PostResponse, error)
+	SearchPosts(viewerID int, param models.PostQueryParam) ([]models.PostResponse, string, error)
 	GetPostByID(postID int) (*models.PostResponse, error)
 	CountByUserID(userID int) (int, error)
 
 	IsOwner(postID int, userID int) (bool, error)
 	ViewPost(viewerID, postID int) (bool, string, error)
+	AttachFileURL(post *models.PostResponse)
 	Friends(viewerID, authorID int) (bool, error)
 
 	GetSavedPosts(userID int) ([]models.PostResponse, error)
+
+	RecordView(viewerID, postID int) error
 }
 
 type postService struct {
 	postRepo  repository.PostRepository
 	friendSvc friendservice.FriendService
+	storage   *storage.Registry
+	notifier  notiservice.Notifier
 }
 
-func NewPostService(postRepo repository.PostRepository, friendSvc friendservice.FriendService) PostService {
+func NewPostService(postRepo repository.PostRepository, friendSvc friendservice.FriendService, storage *storage.Registry, notifier notiservice.Notifier) PostService {
 	return &postService{
 		postRepo:  postRepo,
 		friendSvc: friendSvc,
+		storage:   storage,
+		notifier:  notifier,
 	}
 }
 
@@ -65,15 +76,35 @@ func (s *postService) CreatePost(post *models.Post, tags []string) (int, error)
 		return 0, err
 	}
 	post.Visibility = vis
+	s.locateCover(post)
 
 	normTags := normalizeTags(tags)
 	postID, err := s.postRepo.CreatePost(post, normTags)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create post: %w", err)
 	}
+	// แจ้งผู้ติดตามที่มีสิทธิ์เห็นโพสต์
+	s.notifier.NotifyFollowers(postID)
 	return postID, nil
 }
 
+// หน้าปกที่อัปผ่าน /files/cover ของเจ้าของโพสต์ → เก็บ path ไว้เลย
+// URL ภายนอกหรือของคนอื่นไม่เก็บ path (กันลบไฟล์คนอื่น)
+func (s *postService) locateCover(post *models.Post) {
+	if s.storage == nil || post.CoverURL == nil || strings.TrimSpace(*post.CoverURL) == "" {
+		return
+	}
+	obj, ok := s.storage.Locate(*post.CoverURL)
+	if !ok || !strings.HasPrefix(obj.Path, fmt.Sprintf("covers/%d/", post.AuthorUserID)) {
+		return
+	}
+	post.CoverPath = &obj.Path
+	post.CoverStorage = &obj.Provider
+	if obj.Bucket != "" {
+		post.CoverBucket = &obj.Bucket
+	}
+}
+
 func (s *postService) UpdatePost(post *models.Post, tags []string) error {
 	if post.PostID <= 0 {
 		return fmt.Errorf("invalid post_id")
@@ -164,10 +195,6 @@ func (s *postService) GetAllPosts() ([]models.PostResponse, error) {
 	return s.postRepo.GetAllPosts()
 }
 
-func (s *postService) GetFeedPosts(viewerID int) ([]models.PostResponse, error) {
-	return s.postRepo.GetFeedPosts(viewerID)
-}
-
 // each post by ID
 func (s *postService) GetPostByID(postID int) (*models.PostResponse, error) {
 	return s.postRepo.GetPostByID(postID)
@@ -220,6 +247,11 @@ func (s *postService) ViewPost(viewerID, postID int) (bool, string, error) {
 	}
 }
 
+// นับวิว (ไม่ซ้ำคนต่อวัน) ใช้ในคะแนน trending
+func (s *postService) RecordView(viewerID, postID int) error {
+	return s.postRepo.RecordView(postID, viewerID)
+}
+
 func (s *postService) Friends(viewerID, authorID int) (bool, error) {
 	if viewerID <= 0 || authorID <= 0 {
 		return false, fmt.Errorf("invalid user id")
@@ -237,5 +269,56 @@ func (s *postService) Friends(viewerID, authorID int) (bool, error) {
 }
 
 func (s *postService) GetSavedPosts(userID int) ([]models.PostResponse, error) {
-	return s.postRepo.GetSavedPosts(userID)
+	posts, err := s.postRepo.GetSavedPosts(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		s.AttachFileURL(&posts[i])
+	}
+	return posts, nil
+}
+
+// เรียกหลังผ่าน ViewPost แล้วเท่านั้น: เอกสาร private → signed URL ใหม่ทุก request
+// สร้างไม่สำเร็จให้ file_url เป็น null แทนการส่ง URL ที่เปิดไม่ได้
+// เติม srcset ของหน้าปก/avatar ไปด้วย
+func (s *postService) AttachFileURL(post *models.PostResponse) {
+	if post == nil {
+		return
+	}
+	if post.CoverURL != nil {
+		post.CoverSrcSet = imaging.SrcSet(*post.CoverURL, imaging.CoverVariants)
+	}
+	if post.AvatarURL != nil {
+		post.AvatarSrcSet = imaging.SrcSet(*post.AvatarURL, imaging.AvatarVariants)
+	}
+	if s.storage == nil || post.FileURL == nil {
+		return
+	}
+
+	obj := storage.Object{}
+	if post.StorageProvider != nil {
+		obj.Provider = *post.StorageProvider
+	}
+	if post.DocumentPath != nil {
+		obj.Path = *post.DocumentPath
+	}
+	if post.DocumentBucket != nil {
+		obj.Bucket = *post.DocumentBucket
+	}
+
+	// โหมด proxy: ไม่เปิดเผย URL ของ storage เลย
+	if s.storage.ProxyDocuments() && obj.Path != "" && post.DocumentID != nil {
+		u := storage.ContentPath(*post.DocumentID)
+		post.FileURL = &u
+		return
+	}
+
+	u, err := s.storage.DocumentURL(context.Background(), obj, *post.FileURL)
+	if err != nil {
+		log.Printf("[POST] sign file url post=%d err=%v", post.PostID, err)
+		post.FileURL = nil
+		return
+	}
+	post.FileURL = &u
 }
@@ -1,6 +1,11 @@
 package service
 
-import "chaladshare_backend/internal/posts/repository"
+import (
+	"chaladshare_backend/internal/posts/repository"
+
+	notimodels "chaladshare_backend/internal/notifications/models"
+	notiservice "chaladshare_backend/internal/notifications/service"
+)
 
 type SaveService interface {
 	ToggleSave(userID, postID int) (isSaved bool, saveCount int, err error)
@@ -9,10 +14,11 @@ type SaveService interface {
 
 type saveService struct {
 	saveRepo repository.SaveRepository
+	notifier notiservice.Notifier
 }
 
-func NewSaveService(saveRepo repository.SaveRepository) SaveService {
-	return &saveService{saveRepo: saveRepo}
+func NewSaveService(saveRepo repository.SaveRepository, notifier notiservice.Notifier) SaveService {
+	return &saveService{saveRepo: saveRepo, notifier: notifier}
 }
 
 func (s *saveService) ToggleSave(userID, postID int) (bool, int, error) {
@@ -34,6 +40,8 @@ func (s *saveService) ToggleSave(userID, postID int) (bool, int, error) {
 			return false, 0, err
 		}
 		saved = true
+		// แจ้งเจ้าของโพสต์ (ไม่แจ้งถ้ากดโพสต์ตัวเอง)
+		s.notifier.Notify(notimodels.Event{Type: notimodels.TypePostSave, ActorID: userID, PostID: postID})
 	}
 
 	// 3) ดึงจำนวนบันทึกล่าสุด
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"chaladshare_backend/internal/posts/models"
+)
+
+// query string ผิดรูป (sort / visibility / วันที่ / cursor) → handler ตอบ 400
+var ErrInvalidQuery = errors.New("invalid query")
+
+const (
+	maxSearchRunes = 200
+	maxSearchTerms = 8
+	maxSearchLimit = 50
+)
+
+// feed แบบแบ่งหน้า + ตัวกรอง + ค้นหา (title / description / tag / ข้อความในเอกสาร)
+// คืน cursor ของหน้าถัดไป ("" = หมดแล้ว)
+func (s *postService) SearchPosts(viewerID int, param models.PostQueryParam) ([]models.PostResponse, string, error) {
+	in, err := normalizeSearch(param)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// ดึงเกิน 1 แถวไว้ดูว่ามีหน้าถัดไปไหม
+	limit := in.Limit
+	in.Limit++
+	posts, err := s.postRepo.SearchPosts(viewerID, in)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[limit-1]
+		next = encodeCursor(postCursor{Sort: in.Sort, Key: last.SortKey, PostID: last.PostID})
+	}
+	for i := range posts {
+		s.AttachFileURL(&posts[i])
+		posts[i].Snippet = highlight(posts[i].Snippet, in.Terms)
+	}
+	return posts, next, nil
+}
+
+func normalizeSearch(param models.PostQueryParam) (models.PostSearch, error) {
+	q := strings.Join(strings.Fields(param.Search), " ")
+	if utf8.RuneCountInString(q) > maxSearchRunes {
+		q = string([]rune(q)[:maxSearchRunes])
+	}
+
+	var terms []string
+	seen := map[string]bool{}
+	for _, f := range strings.Fields(strings.ToLower(q)) {
+		t := strings.TrimFunc(f, func(r rune) bool { return unicode.IsPunct(r) && r != '#' && r != '+' })
+		t = strings.TrimPrefix(t, "#")
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		terms = append(terms, t)
+		if len(terms) >= maxSearchTerms {
+			break
+		}
+	}
+	if len(terms) == 0 {
+		q = ""
+	}
+
+	// ?tag=a&tag=b หรือ ?tag=a,b
+	var rawTags []string
+	for _, t := range param.Tag {
+		rawTags = append(rawTags, strings.Split(t, ",")...)
+	}
+
+	sort := strings.ToLower(strings.TrimSpace(param.Sort))
+	switch sort {
+	case "":
+		sort = models.SortNewest
+		if q != "" {
+			sort = models.SortRelevance
+		}
+	case models.SortRelevance:
+		if q == "" {
+			return models.PostSearch{}, fmt.Errorf("%w: sort=relevance requires search", ErrInvalidQuery)
+		}
+	case models.SortNewest, models.SortMostLiked, models.SortMostSaved, models.SortTrending:
+	default:
+		return models.PostSearch{}, fmt.Errorf("%w: unsupported sort", ErrInvalidQuery)
+	}
+
+	limit := param.Limit
+	if limit < 0 {
+		return models.PostSearch{}, fmt.Errorf("%w: invalid limit", ErrInvalidQuery)
+	}
+	if limit == 0 {
+		limit = 20
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	vis := strings.ToLower(strings.TrimSpace(param.Visibility))
+	if vis != "" && vis != models.VisibilityPublic && vis != models.VisibilityFriends {
+		return models.PostSearch{}, fmt.Errorf("%w: unsupported visibility", ErrInvalidQuery)
+	}
+	if param.Author < 0 {
+		return models.PostSearch{}, fmt.Errorf("%w: invalid author", ErrInvalidQuery)
+	}
+
+	from, err := parseDate(param.From, false)
+	if err != nil {
+		return models.PostSearch{}, fmt.Errorf("%w: invalid from", ErrInvalidQuery)
+	}
+	to, err := parseDate(param.To, true)
+	if err != nil {
+		return models.PostSearch{}, fmt.Errorf("%w: invalid to", ErrInvalidQuery)
+	}
+
+	out := models.PostSearch{
+		Query:      q,
+		Terms:      terms,
+		Tags:       normalizeTags(rawTags),
+		Sort:       sort,
+		Limit:      limit,
+		Author:     param.Author,
+		Visibility: vis,
+		From:       from,
+		To:         to,
+	}
+
+	if param.Cursor != "" {
+		c, err := decodeCursor(param.Cursor)
+		// cursor ของ sort อื่นใช้ต่อไม่ได้ (ค่าที่เก็บไว้คนละชนิด)
+		if err != nil || c.Sort != sort || c.PostID <= 0 {
+			return models.PostSearch{}, fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+		}
+		out.After = &models.PostCursor{Key: c.Key, PostID: c.PostID}
+	}
+	return out, nil
+}
+
+// YYYY-MM-DD (ถ้าเป็นปลายช่วงนับถึงสิ้นวัน) หรือ RFC3339
+func parseDate(v string, end bool) (*time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// cursor เป็น base64 ของ json ให้ client ถือไว้เฉยๆ ไม่ต้องรู้โครงสร้าง
+type postCursor struct {
+	Sort   string `json:"s"`
+	Key    string `json:"k"`
+	PostID int    `json:"id"`
+}
+
+func encodeCursor(c postCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (postCursor, error) {
+	var c postCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
+
+// escape HTML แล้วครอบคำค้นทุกตำแหน่งด้วย <mark> (ไม่สนตัวพิมพ์เล็ก/ใหญ่)
+func highlight(text string, terms []string) string {
+	if text == "" {
+		return ""
+	}
+	src := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(src) {
+		// ตัวพิมพ์เล็กยาวไม่เท่าเดิม เทียบตำแหน่งไม่ได้ → ไม่ไฮไลต์
+		return html.EscapeString(text)
+	}
+
+	marked := make([]bool, len(src))
+	for _, t := range terms {
+		tr := []rune(t)
+		if len(tr) == 0 {
+			continue
+		}
+		for i := 0; i+len(tr) <= len(lower); i++ {
+			if string(lower[i:i+len(tr)]) == t {
+				for j := i; j < i+len(tr); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(src); {
+		j := i
+		for j < len(src) && marked[j] == marked[i] {
+			j++
+		}
+		seg := html.EscapeString(string(src[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + seg + "</mark>")
+		} else {
+			b.WriteString(seg)
+		}
+		i = j
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"chaladshare_backend/internal/posts/repository"
+)
+
+// คะแนน trending อัปเดตทันทีเมื่อยอด like / save / view / comment เปลี่ยน (trigger ใน DB)
+// แต่การลดตามอายุต้องคิดใหม่เป็นรอบ ไม่งั้นโพสต์ที่เงียบไปจะค้างอยู่บนสุด
+type TrendingService interface {
+	Start(ctx context.Context)
+}
+
+type trendingService struct {
+	postRepo repository.PostRepository
+	interval time.Duration
+	window   time.Duration
+}
+
+func NewTrendingService(postRepo repository.PostRepository, interval, window time.Duration) TrendingService {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	if window <= 0 {
+		window = 14 * 24 * time.Hour
+	}
+	return &trendingService{postRepo: postRepo, interval: interval, window: window}
+}
+
+func (s *trendingService) Start(ctx context.Context) {
+	go func() {
+		t := time.NewTicker(s.interval)
+		defer t.Stop()
+		for {
+			s.refresh()
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func (s *trendingService) refresh() {
+	n, err := s.postRepo.RefreshTrending(s.window)
+	if err != nil {
+		log.Printf("[TRENDING] refresh err=%v", err)
+		return
+	}
+	log.Printf("[TRENDING] refreshed %d post(s)", n)
+}
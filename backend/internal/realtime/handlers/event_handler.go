@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	postservice "chaladshare_backend/internal/posts/service"
+	"chaladshare_backend/internal/realtime/models"
+	"chaladshare_backend/internal/realtime/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ติดตามยอดของโพสต์ได้ไม่เกินนี้ต่อ connection
+	maxWatchedPosts = 50
+	// comment กัน proxy ตัด connection ที่เงียบ
+	heartbeatInterval = 25 * time.Second
+)
+
+type EventHandler struct {
+	hub         service.Hub
+	postService postservice.PostService
+}
+
+func NewEventHandler(hub service.Hub, postService postservice.PostService) *EventHandler {
+	return &EventHandler{hub: hub, postService: postService}
+}
+
+// GET /events?posts=1,2,3 (Server-Sent Events)
+// ได้เหตุการณ์ของตัวเองเสมอ + ยอดของโพสต์ใน posts ที่มีสิทธิ์เห็น
+func (h *EventHandler) Stream(c *gin.Context) {
+	uid := c.GetInt("user_id")
+	if uid == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	sub := h.hub.Subscribe(uid, h.watchablePosts(uid, c.Query("posts")))
+	defer h.hub.Unsubscribe(sub)
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\nevent: ready\ndata: {}\n\n")
+	// ไม่มี event id/replay: ต่อใหม่ทุกครั้งอาจพลาดเหตุการณ์ช่วงหลุด → ให้ client ดึงข้อมูลใหม่
+	if b, err := json.Marshal(models.Event{Type: models.EventResync}); err == nil {
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", models.EventResync, b)
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e := <-sub.C:
+			b, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, b)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		w.Flush()
+	}
+}
+
+// โพสต์ที่ไม่มีสิทธิ์เห็นหรือ id ผิดข้ามไปเฉยๆ
+func (h *EventHandler) watchablePosts(uid int, raw string) []int {
+	var out []int
+	seen := map[int]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if ok, _, err := h.postService.ViewPost(uid, id); err == nil && ok {
+			out = append(out, id)
+		}
+		if len(seen) >= maxWatchedPosts {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+package models
+
+import "encoding/json"
+
+// ช่อง LISTEN/NOTIFY (ต้องตรงกับ trigger ใน migration 018)
+const Channel = "app_events"
+
+// ประเภทเหตุการณ์
+const (
+	EventNotification  = "notification"
+	EventFeatureStatus = "feature_status"
+	EventPostStats     = "post_stats"
+	// เชื่อมต่อ DB ใหม่ อาจพลาดเหตุการณ์ระหว่างหลุด → ให้ client ดึงข้อมูลใหม่
+	EventResync = "resync"
+)
+
+// UserID > 0 → ส่งให้ผู้ใช้คนนั้น / PostID > 0 → ส่งให้คนที่เปิดโพสต์นั้นอยู่
+type Event struct {
+	Type   string          `json:"type"`
+	UserID int             `json:"user_id,omitempty"`
+	PostID int             `json:"post_id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"sync"
+
+	"chaladshare_backend/internal/realtime/models"
+)
+
+// คิวต่อ connection: client ช้าจนเต็มจะถูกข้ามเหตุการณ์ (ไม่ให้ hub ค้าง)
+const subscriberBuffer = 32
+
+type Subscription struct {
+	UserID int
+	C      chan models.Event
+
+	posts map[int]bool
+}
+
+// กระจายเหตุการณ์ให้ connection ในเครื่องนี้ (ข้าม instance ใช้ Listener)
+type Hub interface {
+	Subscribe(userID int, postIDs []int) *Subscription
+	Unsubscribe(sub *Subscription)
+	Dispatch(e models.Event)
+}
+
+type hub struct {
+	mu     sync.RWMutex
+	byUser map[int]map[*Subscription]struct{}
+	byPost map[int]map[*Subscription]struct{}
+}
+
+func NewHub() Hub {
+	return &hub{
+		byUser: map[int]map[*Subscription]struct{}{},
+		byPost: map[int]map[*Subscription]struct{}{},
+	}
+}
+
+func (h *hub) Subscribe(userID int, postIDs []int) *Subscription {
+	sub := &Subscription{UserID: userID, C: make(chan models.Event, subscriberBuffer), posts: map[int]bool{}}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	add(h.byUser, userID, sub)
+	for _, id := range postIDs {
+		sub.posts[id] = true
+		add(h.byPost, id, sub)
+	}
+	return sub
+}
+
+func (h *hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	remove(h.byUser, sub.UserID, sub)
+	for id := range sub.posts {
+		remove(h.byPost, id, sub)
+	}
+}
+
+func (h *hub) Dispatch(e models.Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if e.Type == models.EventResync {
+		for _, subs := range h.byUser {
+			send(subs, e)
+		}
+		return
+	}
+	if e.UserID > 0 {
+		send(h.byUser[e.UserID], e)
+	}
+	if e.PostID > 0 {
+		send(h.byPost[e.PostID], e)
+	}
+}
+
+func send(subs map[*Subscription]struct{}, e models.Event) {
+	for sub := range subs {
+		select {
+		case sub.C <- e:
+		default:
+		}
+	}
+}
+
+func add(m map[int]map[*Subscription]struct{}, key int, sub *Subscription) {
+	if m[key] == nil {
+		m[key] = map[*Subscription]struct{}{}
+	}
+	m[key][sub] = struct{}{}
+}
+
+func remove(m map[int]map[*Subscription]struct{}, key int, sub *Subscription) {
+	delete(m[key], sub)
+	if len(m[key]) == 0 {
+		delete(m, key)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+
+	"chaladshare_backend/internal/realtime/models"
+)
+
+// ฟัง NOTIFY จาก Postgres (trigger ใน migration 018) แล้วส่งเข้า hub
+// ทุก instance ฟังช่องเดียวกัน → เหตุการณ์จากเครื่องไหนก็ถึง client ทุกเครื่อง
+type Listener struct {
+	connStr string
+	hub     Hub
+}
+
+func NewListener(connStr string, hub Hub) *Listener {
+	return &Listener{connStr: connStr, hub: hub}
+}
+
+func (l *Listener) Start(ctx context.Context) {
+	pl := pq.NewListener(l.connStr, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("[REALTIME] listener event=%d err=%v", ev, err)
+		}
+	})
+	if err := pl.Listen(models.Channel); err != nil {
+		log.Printf("[REALTIME] listen %s err=%v", models.Channel, err)
+	}
+
+	go func() {
+		defer pl.Close()
+		ping := time.NewTicker(90 * time.Second)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-pl.Notify:
+				// nil = เพิ่งต่อใหม่ เหตุการณ์ช่วงหลุดหายไปแล้ว
+				if n == nil {
+					l.hub.Dispatch(models.Event{Type: models.EventResync})
+					continue
+				}
+				var e models.Event
+				if err := json.Unmarshal([]byte(n.Extra), &e); err != nil {
+					log.Printf("[REALTIME] bad payload err=%v", err)
+					continue
+				}
+				l.hub.Dispatch(e)
+			case <-ping.C:
+				if err := pl.Ping(); err != nil {
+					log.Printf("[REALTIME] ping err=%v", err)
+				}
+			}
+		}
+	}()
+}
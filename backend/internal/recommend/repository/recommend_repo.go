@@ -153,7 +153,9 @@ const qFallback = `
 			)
 			)
 		)
-		ORDER BY COALESCE(ps.post_like_count, 0) DESC, p.post_created_at DESC
+		-- คะแนน trending (engagement ลดตามอายุ) แทนยอดไลก์ดิบ → โพสต์ใหม่ที่กำลังมาได้ขึ้นก่อน
+		ORDER BY COALESCE(ps.post_trending_score, 0) DESC,
+			COALESCE(ps.post_like_count, 0) DESC, p.post_created_at DESC
 		LIMIT $2;
 		`
 
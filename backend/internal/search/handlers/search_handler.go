@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	searchservice "chaladshare_backend/internal/search/service"
+)
+
+// คำค้นยาวกว่านี้ไม่ช่วยให้ผลดีขึ้น แค่เปลือง embed
+const maxQueryRunes = 500
+
+type SearchHandler struct {
+	svc searchservice.SearchService
+}
+
+func NewSearchHandler(svc searchservice.SearchService) *SearchHandler {
+	return &SearchHandler{svc: svc}
+}
+
+// GET /api/v1/search/semantic?q=...&limit=20
+func (h *SearchHandler) Semantic(c *gin.Context) {
+	uid := c.GetInt("user_id")
+	if uid <= 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	if utf8.RuneCountInString(q) > maxQueryRunes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is too long"})
+		return
+	}
+
+	limit := 20
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	results, err := h.svc.Semantic(c.Request.Context(), uid, q, limit)
+	if err != nil {
+		if errors.Is(err, searchservice.ErrSemanticUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": results,
+	})
+}
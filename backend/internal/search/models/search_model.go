@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// โพสต์ที่เอกสารใกล้เคียงคำค้น (score = cosine similarity ยิ่งมากยิ่งใกล้)
+type SemanticResult struct {
+	PostID       int       `json:"post_id"`
+	AuthorID     int       `json:"author_id"`
+	AuthorName   string    `json:"author_name"`
+	Title        string    `json:"post_title"`
+	Description  string    `json:"post_description"`
+	Visibility   string    `json:"post_visibility"`
+	DocumentID   int       `json:"post_document_id"`
+	DocumentName string    `json:"document_name"`
+	CreatedAt    time.Time `json:"post_created_at"`
+
+	CoverURL  *string  `json:"cover_url"`
+	AvatarURL *string  `json:"avatar_url"`
+	Tags      []string `json:"tags"`
+	LikeCount int      `json:"like_count"`
+	IsLiked   bool     `json:"is_liked"`
+	IsSaved   bool     `json:"is_saved"`
+
+	Score float64 `json:"score"`
+}
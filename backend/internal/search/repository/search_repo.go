@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+
+	"chaladshare_backend/internal/search/models"
+)
+
+type SearchRepo interface {
+	Semantic(viewerID int, query []float32, limit int) ([]models.SemanticResult, error)
+}
+
+type searchRepo struct{ db *sql.DB }
+
+func NewSearchRepo(db *sql.DB) SearchRepo { return &searchRepo{db: db} }
+
+// ดึงเอกสารที่ใกล้สุดจาก ANN index มาก่อน (candidate) แล้วค่อยกรองสิทธิ์แบบเดียวกับ SearchPosts (ของตัวเอง / public / friends)
+// กรองหลังดึงทำให้ได้น้อยกว่า limit ได้ → ดึง candidate เผื่อไว้หลายเท่า
+const qSemantic = `
+	WITH nearest AS (
+		SELECT df.document_id, df.content_embedding <=> $2 AS distance
+		FROM document_features df
+		WHERE df.content_embedding IS NOT NULL
+		  AND vector_dims(df.content_embedding) = $3
+		ORDER BY df.content_embedding <=> $2
+		LIMIT $4
+	)
+	SELECT p.post_id, p.post_author_user_id, u.username,
+		p.post_title, p.post_description, p.post_visibility,
+		d.document_id, d.document_name, p.post_created_at,
+		p.post_cover_url, up.avatar_url,
+		ARRAY(
+			SELECT t.tag_name
+			FROM post_tags pt
+			JOIN tags t ON t.tag_id = pt.post_tag_tag_id
+			WHERE pt.post_tag_post_id = p.post_id
+			ORDER BY t.tag_name
+		) AS tags,
+		COALESCE(ps.post_like_count, 0),
+		EXISTS (
+			SELECT 1 FROM likes l
+			WHERE l.like_user_id = $1 AND l.like_post_id = p.post_id
+		) AS is_liked,
+		EXISTS (
+			SELECT 1 FROM saved_posts sp
+			WHERE sp.save_user_id = $1 AND sp.save_post_id = p.post_id
+		) AS is_saved,
+		1 - n.distance AS score
+	FROM nearest n
+	JOIN documents d ON d.document_id = n.document_id
+	JOIN posts p ON p.post_document_id = d.document_id
+	JOIN users u ON u.user_id = p.post_author_user_id
+	LEFT JOIN user_profiles up ON up.profile_user_id = u.user_id
+	LEFT JOIN post_stats ps ON ps.post_stats_post_id = p.post_id
+	WHERE
+		p.post_author_user_id = $1
+		OR p.post_visibility = 'public'
+		OR ( p.post_visibility = 'friends'
+			AND EXISTS (
+				SELECT 1
+				FROM friendships f
+				WHERE
+					f.user_id  = LEAST(p.post_author_user_id, $1)
+					AND f.friend_id = GREATEST(p.post_author_user_id, $1)
+			)
+		)
+	ORDER BY n.distance, p.post_created_at DESC
+	LIMIT $5;
+`
+
+func (r *searchRepo) Semantic(viewerID int, query []float32, limit int) ([]models.SemanticResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// ให้ index คืน candidate มากพอหลังกรองสิทธิ์ (ค่า default ของ pgvector น้อยไป)
+	if _, err := tx.Exec(`SET LOCAL hnsw.ef_search = 200`); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`SET LOCAL ivfflat.probes = 10`); err != nil {
+		return nil, err
+	}
+
+	candidates := max(limit*10, 100)
+	rows, err := tx.Query(qSemantic, viewerID, pgvector.NewVector(query), len(query), candidates, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.SemanticResult, 0, limit)
+	for rows.Next() {
+		var (
+			p         models.SemanticResult
+			desc      sql.NullString
+			coverURL  sql.NullString
+			avatarURL sql.NullString
+			tags      pq.StringArray
+		)
+		if err := rows.Scan(
+			&p.PostID, &p.AuthorID, &p.AuthorName,
+			&p.Title, &desc, &p.Visibility,
+			&p.DocumentID, &p.DocumentName, &p.CreatedAt,
+			&coverURL, &avatarURL, &tags,
+			&p.LikeCount, &p.IsLiked, &p.IsSaved, &p.Score,
+		); err != nil {
+			return nil, err
+		}
+		p.Description = desc.String
+		if coverURL.Valid {
+			p.CoverURL = &coverURL.String
+		}
+		if avatarURL.Valid {
+			p.AvatarURL = &avatarURL.String
+		}
+		p.Tags = []string(tags)
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, tx.Commit()
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"chaladshare_backend/internal/connect"
+	"chaladshare_backend/internal/search/models"
+	"chaladshare_backend/internal/search/repository"
+)
+
+// ไม่มี AI client หรือ colab ล่ม → embed คำค้นไม่ได้
+var ErrSemanticUnavailable = errors.New("semantic search unavailable")
+
+type SearchService interface {
+	Semantic(ctx context.Context, viewerID int, q string, limit int) ([]models.SemanticResult, error)
+}
+
+type searchService struct {
+	repo     repository.SearchRepo
+	aiClient *connect.Client
+}
+
+func NewSearchService(repo repository.SearchRepo, aiClient *connect.Client) SearchService {
+	return &searchService{repo: repo, aiClient: aiClient}
+}
+
+func (s *searchService) Semantic(ctx context.Context, viewerID int, q string, limit int) ([]models.SemanticResult, error) {
+	if viewerID <= 0 {
+		return nil, errors.New("invalid userid")
+	}
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, errors.New("q is required")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if s.aiClient == nil {
+		return nil, ErrSemanticUnavailable
+	}
+
+	vec, err := s.aiClient.Embed(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSemanticUnavailable, err)
+	}
+
+	query := make([]float32, len(vec))
+	for i, v := range vec {
+		query[i] = float32(v)
+	}
+	return s.repo.Semantic(viewerID, query, limit)
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// เก็บไฟล์ลงดิสก์ใต้ UPLOAD_DIR แล้วเสิร์ฟผ่าน route /uploads
+type LocalStorage struct {
+	dir     string
+	baseURL string
+
+	// object ที่ขึ้นต้นด้วย signedPrefix ต้องมี ?expires=&sig= ถึงจะเสิร์ฟ
+	signedPrefix string
+	signKey      []byte
+}
+
+// baseURL ว่าง = "/uploads" (relative กับ backend)
+func NewLocalStorage(dir, baseURL string) (*LocalStorage, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, errors.New("local storage dir is empty")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return nil, fmt.Errorf("create upload dir: %w", err)
+	}
+
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	return &LocalStorage{dir: abs, baseURL: baseURL}, nil
+}
+
+func (s *LocalStorage) Provider() string { return ProviderLocal }
+
+func (s *LocalStorage) RequireSignature(prefix string, key []byte) {
+	s.signedPrefix = strings.TrimPrefix(prefix, "/")
+	s.signKey = key
+}
+
+// local ไม่มี bucket
+func (s *LocalStorage) Bucket() string { return "" }
+
+func (s *LocalStorage) WithBucket(bucket string) Client { return s }
+
+// กัน object path แบบ ../ หลุดออกนอก dir
+func (s *LocalStorage) fullPath(objectPath string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(objectPath))
+	if clean == string(filepath.Separator) {
+		return "", errors.New("object path is empty")
+	}
+	return filepath.Join(s.dir, clean), nil
+}
+
+func (s *LocalStorage) UploadLocalFile(ctx context.Context, objectPath string, localPath string) (string, error) {
+	dst, err := s.fullPath(objectPath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", fmt.Errorf("mkdir: %w", err)
+	}
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer src.Close()
+
+	// เขียนลงไฟล์ชั่วคราวก่อนแล้วค่อย rename กันคนอ่านเจอไฟล์ครึ่งๆ
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("create file: %w", err)
+	}
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("copy file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("rename file: %w", err)
+	}
+
+	return s.baseURL + "/" + escapeObjectPath(filepath.ToSlash(strings.TrimPrefix(objectPath, "/"))), nil
+}
+
+func (s *LocalStorage) Open(ctx context.Context, objectPath string) (io.ReadCloser, error) {
+	p, err := s.fullPath(objectPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// ไฟล์บนดิสก์ seek ได้อยู่แล้ว ใช้ตอบ Range ตรงๆ
+func (s *LocalStorage) openFile(objectPath string) (*os.File, ObjectInfo, error) {
+	p, err := s.fullPath(objectPath)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		f.Close()
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+	return f, ObjectInfo{Size: fi.Size(), ETag: localETag(fi.Size(), fi.ModTime()), ModTime: fi.ModTime()}, nil
+}
+
+func (s *LocalStorage) sign(objectPath string, expires int64) string {
+	m := hmac.New(sha256.New, s.signKey)
+	m.Write([]byte(objectPath + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func (s *LocalStorage) SignedURL(ctx context.Context, objectPath string, ttl time.Duration) (string, error) {
+	objectPath = strings.TrimPrefix(objectPath, "/")
+	u := s.baseURL + "/" + escapeObjectPath(objectPath)
+	if len(s.signKey) == 0 {
+		return u, nil
+	}
+	exp := time.Now().Add(ttl).Unix()
+	return fmt.Sprintf("%s?expires=%d&sig=%s", u, exp, s.sign(objectPath, exp)), nil
+}
+
+func (s *LocalStorage) verify(objectPath, expires, sig string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(s.sign(objectPath, exp)), []byte(sig))
+}
+
+// ใช้แทน static route เมื่อมีเอกสาร private: objectPath = ส่วนหลัง /uploads/
+// clean ก่อนเช็ค prefix: //documents/.. หรือ ./documents/.. ต้องโดนเช็คลายเซ็นเหมือนกัน
+func (s *LocalStorage) ServeObject(w http.ResponseWriter, r *http.Request, objectPath string) {
+	if strings.Contains(objectPath, "..") {
+		http.NotFound(w, r)
+		return
+	}
+	objectPath = strings.TrimPrefix(path.Clean("/"+objectPath), "/")
+	if objectPath == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if s.signedPrefix != "" && strings.HasPrefix(objectPath, s.signedPrefix) {
+		q := r.URL.Query()
+		if !s.verify(objectPath, q.Get("expires"), q.Get("sig")) {
+			http.Error(w, "link expired or invalid", http.StatusForbidden)
+			return
+		}
+	}
+
+	p, err := s.fullPath(objectPath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	fi, err := os.Stat(p)
+	if err != nil || fi.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, p)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, objectPath string) error {
+	p, err := s.fullPath(objectPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) ObjectPathFromPublicURL(publicURL string) (string, bool) {
+	if p, ok := trimURLPrefix(publicURL, s.baseURL+"/"); ok {
+		return p, true
+	}
+	// ข้อมูลเก่าเก็บเป็น /uploads/... ตรงๆ
+	return trimURLPrefix(publicURL, "/uploads/")
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newSignedLocal(t *testing.T) *LocalStorage {
+	t.Helper()
+	dir := t.TempDir()
+	s, err := NewLocalStorage(dir, "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.RequireSignature("documents/", []byte("test-key"))
+	for _, p := range []string{"documents/1/secret.pdf", "covers/1/cover.jpg"} {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func serve(s *LocalStorage, objectPath, rawQuery string) int {
+	r := httptest.NewRequest(http.MethodGet, "/uploads/x", nil)
+	r.URL.RawQuery = rawQuery
+	w := httptest.NewRecorder()
+	s.ServeObject(w, r, objectPath)
+	return w.Code
+}
+
+func TestServeObjectRequiresSignatureForPrivatePaths(t *testing.T) {
+	s := newSignedLocal(t)
+
+	for _, p := range []string{
+		"/documents/1/secret.pdf",
+		"//documents/1/secret.pdf",
+		"/./documents/1/secret.pdf",
+		"/documents//1/secret.pdf",
+		"/covers/../documents/1/secret.pdf",
+	} {
+		if code := serve(s, p, ""); code == http.StatusOK {
+			t.Errorf("%q served without signature", p)
+		}
+	}
+}
+
+func TestServeObjectSignedAndPublic(t *testing.T) {
+	s := newSignedLocal(t)
+
+	signed, err := s.SignedURL(context.Background(), "documents/1/secret.pdf", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(s, "/documents/1/secret.pdf", u.RawQuery); code != http.StatusOK {
+		t.Errorf("signed url: got %d", code)
+	}
+	if code := serve(s, "//documents/1/secret.pdf", u.RawQuery); code != http.StatusOK {
+		t.Errorf("signed url with extra slash: got %d", code)
+	}
+	if code := serve(s, "/covers/1/cover.jpg", ""); code != http.StatusOK {
+		t.Errorf("public object: got %d", code)
+	}
+}
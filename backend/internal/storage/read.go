@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// client สำหรับ stream เนื้อไฟล์ให้ client (/files/:id/content): ไม่มี Timeout รวม
+// ไม่งั้นดาวน์โหลดที่นานเกินถูกตัดกลางไฟล์ จบเมื่อ request context ถูกยกเลิกแทน
+// รอ header เกิน 60 วิถือว่า storage ไม่ตอบ
+func newStreamClient() *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = 60 * time.Second
+	return &http.Client{Transport: t}
+}
+
+// ข้อมูล object สำหรับตอบ HTTP (Content-Length / ETag / Last-Modified)
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+}
+
+// backend ที่อ่านเป็นช่วงได้ ใช้ทำ Range request โดยไม่ต้องโหลดทั้งไฟล์
+type RangeReader interface {
+	Stat(ctx context.Context, objectPath string) (ObjectInfo, error)
+	OpenRange(ctx context.Context, objectPath string, offset int64) (io.ReadCloser, error)
+}
+
+// เปิด object แบบ seek ได้ (ส่งเข้า http.ServeContent ได้ตรงๆ)
+// backend ที่ไม่รองรับ Range จะโหลดลงไฟล์ชั่วคราวก่อน
+func OpenSeekable(ctx context.Context, c Client, objectPath string) (io.ReadSeekCloser, ObjectInfo, error) {
+	if l, ok := c.(*LocalStorage); ok {
+		f, info, err := l.openFile(objectPath)
+		if err != nil {
+			return nil, ObjectInfo{}, err
+		}
+		return f, info, nil
+	}
+	if rr, ok := c.(RangeReader); ok {
+		info, err := rr.Stat(ctx, objectPath)
+		if err != nil {
+			return nil, ObjectInfo{}, err
+		}
+		return &rangeObject{ctx: ctx, rr: rr, path: objectPath, size: info.Size}, info, nil
+	}
+
+	rc, err := c.Open(ctx, objectPath)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	defer rc.Close()
+
+	tmp, err := os.CreateTemp("", "chaladshare-obj-*")
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	n, err := io.Copy(tmp, rc)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return nil, ObjectInfo{}, fmt.Errorf("buffer object: %w", err)
+	}
+	return &tempObject{File: tmp}, ObjectInfo{Size: n}, nil
+}
+
+// ไฟล์ชั่วคราวที่ลบตัวเองตอน Close
+type tempObject struct {
+	*os.File
+}
+
+func (t *tempObject) Close() error {
+	err := t.File.Close()
+	_ = os.Remove(t.File.Name())
+	return err
+}
+
+// เปิด GET ใหม่ทุกครั้งที่ seek ไปตำแหน่งอื่น (ServeContent seek ไปท้ายไฟล์เพื่อหาขนาดก่อน)
+type rangeObject struct {
+	ctx  context.Context
+	rr   RangeReader
+	path string
+	size int64
+	off  int64
+	body io.ReadCloser
+}
+
+func (o *rangeObject) Read(p []byte) (int, error) {
+	if o.off >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		body, err := o.rr.OpenRange(o.ctx, o.path, o.off)
+		if err != nil {
+			return 0, err
+		}
+		o.body = body
+	}
+	n, err := o.body.Read(p)
+	o.off += int64(n)
+	return n, err
+}
+
+func (o *rangeObject) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = o.off + offset
+	case io.SeekEnd:
+		next = o.size + offset
+	default:
+		return 0, errors.New("seek: invalid whence")
+	}
+	if next < 0 {
+		return 0, errors.New("seek: negative position")
+	}
+	if next != o.off && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.off = next
+	return next, nil
+}
+
+func (o *rangeObject) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
+
+// อ่าน header ของ HEAD response (supabase / s3 ใช้ร่วมกัน)
+func infoFromHeader(h http.Header) ObjectInfo {
+	info := ObjectInfo{
+		ContentType: h.Get("Content-Type"),
+		ETag:        h.Get("ETag"),
+	}
+	info.Size, _ = strconv.ParseInt(h.Get("Content-Length"), 10, 64)
+	if t, err := http.ParseTime(h.Get("Last-Modified")); err == nil {
+		info.ModTime = t
+	}
+	return info
+}
+
+// GET แบบมี offset: server ที่ไม่สน Range (ตอบ 200) ต้องทิ้งส่วนหน้าเอง
+func rangeBody(resp *http.Response, offset int64) (io.ReadCloser, error) {
+	if offset > 0 && resp.StatusCode == http.StatusOK {
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("skip to offset: %w", err)
+		}
+	}
+	return resp.Body, nil
+}
+
+func rangeHeader(offset int64) string {
+	return "bytes=" + strconv.FormatInt(offset, 10) + "-"
+}
+
+// ETag ของไฟล์ local: ขนาด + เวลาแก้ไข (ไม่ต้องอ่านทั้งไฟล์)
+func localETag(size int64, mod time.Time) string {
+	return `"` + strings.Join([]string{
+		strconv.FormatInt(size, 16),
+		strconv.FormatInt(mod.UnixNano(), 16),
+	}, "-") + `"`
+}
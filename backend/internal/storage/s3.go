@@ -0,0 +1,374 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ใช้ได้ทั้ง AWS S3 และ MinIO (S3_FORCE_PATH_STYLE=true)
+// เซ็น request เองด้วย SigV4 ไม่ต้องพึ่ง aws sdk
+type S3Storage struct {
+	endpoint   *url.URL
+	region     string
+	bucket     string
+	accessKey  string
+	secretKey  string
+	pathStyle  bool
+	publicBase string
+	httpClient *http.Client
+	// OpenRange ใช้ stream ให้ client: ไม่มี Timeout รวม (ดู newStreamClient)
+	streamClient *http.Client
+}
+
+type S3Config struct {
+	Endpoint       string // เช่น https://s3.ap-southeast-1.amazonaws.com หรือ http://localhost:9000
+	Region         string
+	Bucket         string
+	AccessKey      string
+	SecretKey      string
+	ForcePathStyle bool
+	PublicBaseURL  string // ว่าง = ใช้ URL ของ object ตรงๆ
+}
+
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("missing env: S3_BUCKET, S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+
+	ep, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || ep.Scheme == "" || ep.Host == "" {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT: %q", cfg.Endpoint)
+	}
+
+	return &S3Storage{
+		endpoint:   ep,
+		region:     cfg.Region,
+		bucket:     cfg.Bucket,
+		accessKey:  cfg.AccessKey,
+		secretKey:  cfg.SecretKey,
+		pathStyle:  cfg.ForcePathStyle,
+		publicBase: strings.TrimRight(cfg.PublicBaseURL, "/"),
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		// OpenRange: ไม่มี Timeout รวม
+		streamClient: newStreamClient(),
+	}, nil
+}
+
+func NewS3StorageFromEnv() (*S3Storage, error) {
+	return NewS3Storage(S3Config{
+		Endpoint:       strings.TrimSpace(os.Getenv("S3_ENDPOINT")),
+		Region:         strings.TrimSpace(os.Getenv("S3_REGION")),
+		Bucket:         strings.TrimSpace(os.Getenv("S3_BUCKET")),
+		AccessKey:      strings.TrimSpace(os.Getenv("S3_ACCESS_KEY_ID")),
+		SecretKey:      strings.TrimSpace(os.Getenv("S3_SECRET_ACCESS_KEY")),
+		ForcePathStyle: strings.EqualFold(os.Getenv("S3_FORCE_PATH_STYLE"), "true"),
+		PublicBaseURL:  strings.TrimSpace(os.Getenv("S3_PUBLIC_URL")),
+	})
+}
+
+func (s *S3Storage) Provider() string { return ProviderS3 }
+
+func (s *S3Storage) Bucket() string { return s.bucket }
+
+func (s *S3Storage) WithBucket(bucket string) Client {
+	cp := *s
+	cp.bucket = bucket
+	return &cp
+}
+
+// path-style: {endpoint}/{bucket}/{key}, virtual-host: {bucket}.{host}/{key}
+func (s *S3Storage) objectURL(objectPath string) string {
+	key := awsURIEscape(strings.TrimPrefix(objectPath, "/"), false)
+	if s.pathStyle {
+		return fmt.Sprintf("%s://%s%s/%s/%s", s.endpoint.Scheme, s.endpoint.Host, s.endpoint.Path, awsURIEscape(s.bucket, true), key)
+	}
+	return fmt.Sprintf("%s://%s.%s%s/%s", s.endpoint.Scheme, s.bucket, s.endpoint.Host, s.endpoint.Path, key)
+}
+
+func (s *S3Storage) publicURL(objectPath string) string {
+	if s.publicBase != "" {
+		return s.publicBase + "/" + escapeObjectPath(strings.TrimPrefix(objectPath, "/"))
+	}
+	return s.objectURL(objectPath)
+}
+
+func (s *S3Storage) UploadLocalFile(ctx context.Context, objectPath string, localPath string) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	// SigV4 ต้องใช้ hash ของ body → อ่านรอบแรกเพื่อ hash แล้ว seek กลับ
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", fmt.Errorf("hash file: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(objectPath), f)
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	req.ContentLength = size
+
+	ct := mime.TypeByExtension(strings.ToLower(filepath.Ext(localPath)))
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", ct)
+	s.sign(req, hex.EncodeToString(h.Sum(nil)), time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return "", fmt.Errorf("s3 upload failed: status=%d body=%s", resp.StatusCode, string(body))
+	}
+	return s.publicURL(objectPath), nil
+}
+
+// worker โหลดทั้งไฟล์ (context ไม่มีกำหนดเวลา) → ใช้ client ที่มี Timeout
+func (s *S3Storage) Open(ctx context.Context, objectPath string) (io.ReadCloser, error) {
+	return s.openRange(ctx, s.httpClient, objectPath, 0)
+}
+
+func (s *S3Storage) OpenRange(ctx context.Context, objectPath string, offset int64) (io.ReadCloser, error) {
+	return s.openRange(ctx, s.streamClient, objectPath, offset)
+}
+
+func (s *S3Storage) openRange(ctx context.Context, client *http.Client, objectPath string, offset int64) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(objectPath), nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", rangeHeader(offset))
+	}
+	s.sign(req, emptyPayloadHash, time.Now())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download request: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 download failed: %s - %s", resp.Status, string(b))
+	}
+	return rangeBody(resp, offset)
+}
+
+func (s *S3Storage) Stat(ctx context.Context, objectPath string) (ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(objectPath), nil)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("new request: %w", err)
+	}
+	s.sign(req, emptyPayloadHash, time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("head request: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return ObjectInfo{}, fmt.Errorf("s3 head failed: %s", resp.Status)
+	}
+	return infoFromHeader(resp.Header), nil
+}
+
+// presigned GET (query string SigV4) อายุสูงสุด 7 วันตามข้อจำกัดของ S3
+func (s *S3Storage) SignedURL(ctx context.Context, objectPath string, ttl time.Duration) (string, error) {
+	secs := int(ttl.Seconds())
+	if secs <= 0 {
+		secs = 60
+	}
+	if secs > 604800 {
+		secs = 604800
+	}
+
+	return s.presign(objectPath, secs, time.Now())
+}
+
+func (s *S3Storage) presign(objectPath string, secs int, now time.Time) (string, error) {
+	u, err := url.Parse(s.objectURL(objectPath))
+	if err != nil {
+		return "", err
+	}
+
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+
+	params := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    s.accessKey + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       strconv.Itoa(secs),
+		"X-Amz-SignedHeaders": "host",
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, awsURIEscape(k, true)+"="+awsURIEscape(params[k], true))
+	}
+	query := strings.Join(pairs, "&")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		query,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	signature := s.signature(now, scope, amzDate, canonicalRequest)
+	u.RawQuery = query + "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, objectPath string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(objectPath), nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	s.sign(req, emptyPayloadHash, time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// S3 ตอบ 204 แม้ไม่มี object อยู่แล้ว
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("s3 delete failed: %s - %s", resp.Status, string(b))
+	}
+	return nil
+}
+
+func (s *S3Storage) ObjectPathFromPublicURL(publicURL string) (string, bool) {
+	if s.publicBase != "" {
+		if p, ok := trimURLPrefix(publicURL, s.publicBase+"/"); ok {
+			return p, true
+		}
+	}
+	return trimURLPrefix(publicURL, s.objectURL(""))
+}
+
+// ---- AWS Signature Version 4 ----
+
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func (s *S3Storage) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + s.region + "/s3/aws4_request"
+	signature := s.signature(now, scope, amzDate, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func (s *S3Storage) signature(now time.Time, scope, amzDate, canonicalRequest string) string {
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// URI encode ตามสเปก SigV4 (เข้ารหัสทุกตัวยกเว้น unreserved)
+func awsURIEscape(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
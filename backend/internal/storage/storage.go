@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderLocal    = "local"
+	ProviderSupabase = "supabase"
+	ProviderS3       = "s3"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// ที่เก็บไฟล์ (เอกสาร / หน้าปก / avatar) ทุก backend ต้องทำตามนี้
+type Client interface {
+	Provider() string
+	Bucket() string
+	// client เดิมแต่ชี้ไป bucket อื่น (ไฟล์เก่าที่อัปตอน bucket ยังเป็นชื่อเดิม)
+	WithBucket(bucket string) Client
+	UploadLocalFile(ctx context.Context, objectPath string, localPath string) (publicURL string, err error)
+	Open(ctx context.Context, objectPath string) (io.ReadCloser, error)
+	// URL ชั่วคราวสำหรับ object ใน bucket private
+	SignedURL(ctx context.Context, objectPath string, ttl time.Duration) (string, error)
+	Delete(ctx context.Context, objectPath string) error
+	ObjectPathFromPublicURL(publicURL string) (objectPath string, ok bool)
+}
+
+// ตำแหน่งไฟล์ที่เก็บลง DB (document_path / post_cover_path / avatar_path)
+type Object struct {
+	Provider string
+	Bucket   string
+	Path     string
+}
+
+// เลือก backend ตาม storage_provider ของแต่ละเอกสาร
+// ไฟล์ใหม่ใช้ default ส่วนไฟล์เก่ายังลบ/โหลดผ่าน provider เดิมได้
+type Registry struct {
+	def     string
+	clients map[string]Client
+
+	// เอกสาร PDF แยก bucket ได้ (เช่น bucket private) ส่วนหน้าปก/avatar อยู่ bucket หลัก
+	documentBucket   string
+	privateDocuments bool
+	signedURLTTL     time.Duration
+	proxyDocuments   bool
+}
+
+func NewRegistry(defaultProvider string) *Registry {
+	return &Registry{
+		def:          normalize(defaultProvider),
+		clients:      map[string]Client{},
+		signedURLTTL: 15 * time.Minute,
+	}
+}
+
+type Config struct {
+	DefaultProvider string
+	UploadDir       string // local
+	LocalBaseURL    string // local
+	LocalSignKey    string // local: key สำหรับเซ็น URL ของเอกสาร private
+
+	DocumentBucket   string // ว่าง = bucket เดียวกับรูป
+	PrivateDocuments bool
+	SignedURLTTL     time.Duration
+	ProxyDocuments   bool // ส่งลิงก์ผ่าน backend (ContentPath) แทน URL ของ storage
+}
+
+// local ใช้ได้เสมอ ส่วน supabase / s3 ลงทะเบียนเมื่อมี env ครบ
+func NewRegistryFromEnv(cfg Config) (*Registry, error) {
+	r := NewRegistry(cfg.DefaultProvider)
+	if r.def == "" {
+		r.def = ProviderSupabase
+	}
+	r.documentBucket = strings.TrimSpace(cfg.DocumentBucket)
+	r.privateDocuments = cfg.PrivateDocuments
+	r.proxyDocuments = cfg.ProxyDocuments
+	if cfg.SignedURLTTL > 0 {
+		r.signedURLTTL = cfg.SignedURLTTL
+	}
+
+	local, err := NewLocalStorage(cfg.UploadDir, cfg.LocalBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PrivateDocuments {
+		if strings.TrimSpace(cfg.LocalSignKey) == "" {
+			return nil, errors.New("local storage: sign key is required for private documents")
+		}
+		local.RequireSignature("documents/", []byte(cfg.LocalSignKey))
+	}
+	r.Register(local)
+
+	if sb, err := NewSupabaseStorageFromEnv(); err == nil {
+		r.Register(sb)
+	} else if r.def == ProviderSupabase {
+		log.Printf("[STORAGE] supabase disabled: %v", err)
+	}
+
+	if s3, err := NewS3StorageFromEnv(); err == nil {
+		r.Register(s3)
+	} else if r.def == ProviderS3 {
+		log.Printf("[STORAGE] s3 disabled: %v", err)
+	}
+
+	return r, nil
+}
+
+func (r *Registry) Register(c Client) {
+	r.clients[normalize(c.Provider())] = c
+}
+
+func (r *Registry) DefaultProvider() string {
+	return r.def
+}
+
+func (r *Registry) Default() (Client, error) {
+	return r.Get(r.def)
+}
+
+// provider ว่าง = default
+func (r *Registry) Get(provider string) (Client, error) {
+	p := normalize(provider)
+	if p == "" {
+		p = r.def
+	}
+	c, ok := r.clients[p]
+	if !ok {
+		return nil, fmt.Errorf("storage provider %q not configured", p)
+	}
+	return c, nil
+}
+
+// bucket ว่าง = bucket ตาม config ปัจจุบัน
+func (r *Registry) ForObject(provider, bucket string) (Client, error) {
+	c, err := r.Get(provider)
+	if err != nil {
+		return nil, err
+	}
+	if bucket != "" && bucket != c.Bucket() {
+		return c.WithBucket(bucket), nil
+	}
+	return c, nil
+}
+
+// แปลง public URL → Object ครั้งเดียวตอนบันทึก (ลอง default ก่อน)
+func (r *Registry) Locate(publicURL string) (Object, bool) {
+	order := append([]string{r.def}, r.Providers()...)
+	for _, p := range order {
+		c, ok := r.clients[p]
+		if !ok {
+			continue
+		}
+		if path, ok := c.ObjectPathFromPublicURL(publicURL); ok {
+			return Object{Provider: c.Provider(), Bucket: c.Bucket(), Path: path}, true
+		}
+	}
+	return Object{}, false
+}
+
+// client สำหรับอัปเอกสารใหม่ (provider ว่าง = default)
+func (r *Registry) DocumentClient(provider string) (Client, error) {
+	c, err := r.Get(provider)
+	if err != nil {
+		return nil, err
+	}
+	if r.documentBucket != "" && c.Bucket() != "" && c.Bucket() != r.documentBucket {
+		return c.WithBucket(r.documentBucket), nil
+	}
+	return c, nil
+}
+
+func (r *Registry) PrivateDocuments() bool {
+	return r.privateDocuments
+}
+
+func (r *Registry) SignedURLTTL() time.Duration {
+	return r.signedURLTTL
+}
+
+func (r *Registry) ProxyDocuments() bool {
+	return r.proxyDocuments
+}
+
+// route ดาวน์โหลดเอกสารผ่าน backend (เช็คสิทธิ์ก่อนส่งไฟล์)
+func ContentPath(documentID int) string {
+	return fmt.Sprintf("/api/v1/files/%d/content", documentID)
+}
+
+// URL ที่เก็บเป็น route /content (เอกสารที่ใช้ object ของคนอื่น) ไม่ใช่ URL ของ storage
+func IsContentPath(u string) bool {
+	return strings.HasPrefix(u, "/api/v1/files/") && strings.HasSuffix(u, "/content")
+}
+
+// URL ของเอกสารที่ส่งให้ client: private → signed URL อายุ SignedURLTTL ไม่งั้นใช้ public URL เดิม
+// ไม่มี path (ลิงก์ภายนอก/ข้อมูลเก่า) ก็คืน publicURL
+// เก็บเป็น route /content อยู่แล้วคืนตามเดิม (signed URL จะเผย path ของเจ้าของ object)
+func (r *Registry) DocumentURL(ctx context.Context, obj Object, publicURL string) (string, error) {
+	if !r.privateDocuments || obj.Path == "" || IsContentPath(publicURL) {
+		return publicURL, nil
+	}
+	c, err := r.ForObject(obj.Provider, obj.Bucket)
+	if err != nil {
+		return "", err
+	}
+	return c.SignedURL(ctx, obj.Path, r.signedURLTTL)
+}
+
+func (r *Registry) Local() (*LocalStorage, bool) {
+	c, ok := r.clients[ProviderLocal]
+	if !ok {
+		return nil, false
+	}
+	l, ok := c.(*LocalStorage)
+	return l, ok
+}
+
+func (r *Registry) Providers() []string {
+	out := make([]string, 0, len(r.clients))
+	for p := range r.clients {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func normalize(p string) string {
+	return strings.ToLower(strings.TrimSpace(p))
+}
+
+func escapeObjectPath(p string) string {
+	parts := strings.Split(p, "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return strings.Join(parts, "/")
+}
+
+// ตัด prefix ของ public URL ออกให้เหลือ object path
+func trimURLPrefix(publicURL, prefix string) (string, bool) {
+	if !strings.HasPrefix(publicURL, prefix) {
+		return "", false
+	}
+	suffix := strings.TrimPrefix(publicURL, prefix)
+
+	decoded, err := url.PathUnescape(suffix)
+	if err == nil && decoded != "" {
+		suffix = decoded
+	}
+	return suffix, suffix != ""
+}
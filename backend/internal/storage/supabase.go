@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type SupabaseStorage struct {
+	baseURL    string
+	serviceKey string
+	bucket     string
+	httpClient *http.Client
+	// OpenRange ใช้ stream ให้ client: ไม่มี Timeout รวม (ดู newStreamClient)
+	streamClient *http.Client
+}
+
+func NewSupabaseStorageFromEnv() (*SupabaseStorage, error) {
+	baseURL := strings.TrimRight(os.Getenv("SUPABASE_URL"), "/")
+	key := strings.TrimSpace(os.Getenv("SUPABASE_SERVICE_ROLE_KEY"))
+	if key == "" {
+		key = strings.TrimSpace(os.Getenv("SUPABASE_ANON_KEY"))
+	}
+	bucket := strings.TrimSpace(os.Getenv("SUPABASE_STORAGE_BUCKET"))
+
+	if baseURL == "" || key == "" || bucket == "" {
+		return nil, errors.New("missing env: SUPABASE_URL, SUPABASE_SERVICE_ROLE_KEY(or SUPABASE_ANON_KEY), SUPABASE_STORAGE_BUCKET")
+	}
+
+	return &SupabaseStorage{
+		baseURL:    baseURL,
+		serviceKey: key,
+		bucket:     bucket,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		// OpenRange: ไม่มี Timeout รวม
+		streamClient: newStreamClient(),
+	}, nil
+}
+
+func (s *SupabaseStorage) Provider() string { return ProviderSupabase }
+
+func (s *SupabaseStorage) Bucket() string { return s.bucket }
+
+func (s *SupabaseStorage) WithBucket(bucket string) Client {
+	cp := *s
+	cp.bucket = bucket
+	return &cp
+}
+
+func (s *SupabaseStorage) UploadLocalFile(ctx context.Context, objectPath string, localPath string) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	// PUT
+	u := fmt.Sprintf("%s/storage/v1/object/%s/%s?upsert=true",
+		s.baseURL,
+		url.PathEscape(s.bucket),
+		escapeObjectPath(objectPath),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, f)
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+
+	ct := mime.TypeByExtension(strings.ToLower(filepath.Ext(localPath)))
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+
+	req.Header.Set("Content-Type", ct)
+	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
+	req.Header.Set("apikey", s.serviceKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("supabase upload failed: status=%d body=%s",
+			resp.StatusCode, string(body))
+	}
+
+	publicURL := fmt.Sprintf("%s/storage/v1/object/public/%s/%s",
+		s.baseURL,
+		s.bucket,
+		escapeObjectPath(objectPath),
+	)
+
+	return publicURL, nil
+}
+
+// worker โหลดทั้งไฟล์ (context ไม่มีกำหนดเวลา) → ใช้ client ที่มี Timeout
+func (s *SupabaseStorage) Open(ctx context.Context, objectPath string) (io.ReadCloser, error) {
+	return s.openRange(ctx, s.httpClient, objectPath, 0)
+}
+
+func (s *SupabaseStorage) objectRequest(ctx context.Context, method, objectPath string) (*http.Request, error) {
+	u := fmt.Sprintf("%s/storage/v1/object/%s/%s",
+		s.baseURL,
+		url.PathEscape(s.bucket),
+		escapeObjectPath(objectPath),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
+	req.Header.Set("apikey", s.serviceKey)
+	return req, nil
+}
+
+func (s *SupabaseStorage) OpenRange(ctx context.Context, objectPath string, offset int64) (io.ReadCloser, error) {
+	return s.openRange(ctx, s.streamClient, objectPath, offset)
+}
+
+func (s *SupabaseStorage) openRange(ctx context.Context, client *http.Client, objectPath string, offset int64) (io.ReadCloser, error) {
+	req, err := s.objectRequest(ctx, http.MethodGet, objectPath)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", rangeHeader(offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download request: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		// supabase ตอบ 400 "Object not found" ได้ด้วย
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		resp.Body.Close()
+		return nil, fmt.Errorf("supabase download failed: %s - %s", resp.Status, string(b))
+	}
+	return rangeBody(resp, offset)
+}
+
+func (s *SupabaseStorage) Stat(ctx context.Context, objectPath string) (ObjectInfo, error) {
+	req, err := s.objectRequest(ctx, http.MethodHead, objectPath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("head request: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return ObjectInfo{}, fmt.Errorf("supabase head failed: %s", resp.Status)
+	}
+	return infoFromHeader(resp.Header), nil
+}
+
+// bucket private: ขอ signed URL จาก /storage/v1/object/sign
+func (s *SupabaseStorage) SignedURL(ctx context.Context, objectPath string, ttl time.Duration) (string, error) {
+	u := fmt.Sprintf("%s/storage/v1/object/sign/%s/%s",
+		s.baseURL,
+		url.PathEscape(s.bucket),
+		escapeObjectPath(objectPath),
+	)
+
+	secs := int(ttl.Seconds())
+	if secs <= 0 {
+		secs = 60
+	}
+	body, _ := json.Marshal(map[string]int{"expiresIn": secs})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
+	req.Header.Set("apikey", s.serviceKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sign request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return "", fmt.Errorf("supabase sign failed: %s - %s", resp.Status, string(b))
+	}
+
+	var out struct {
+		SignedURL string `json:"signedURL"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode sign response: %w", err)
+	}
+	if out.SignedURL == "" {
+		return "", errors.New("supabase sign: empty signedURL")
+	}
+	// ได้กลับมาเป็น path แบบ /object/sign/{bucket}/{path}?token=...
+	if strings.HasPrefix(out.SignedURL, "http") {
+		return out.SignedURL, nil
+	}
+	return s.baseURL + "/storage/v1" + out.SignedURL, nil
+}
+
+func (s *SupabaseStorage) Delete(ctx context.Context, objectPath string) error {
+	u := fmt.Sprintf("%s/storage/v1/object/%s/%s",
+		s.baseURL,
+		url.PathEscape(s.bucket),
+		escapeObjectPath(objectPath),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
+	req.Header.Set("apikey", s.serviceKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("supabase delete failed: %s - %s", resp.Status, string(b))
+	}
+	return nil
+}
+
+func (s *SupabaseStorage) ObjectPathFromPublicURL(publicURL string) (string, bool) {
+	prefix := fmt.Sprintf("%s/storage/v1/object/public/%s/", strings.TrimRight(s.baseURL, "/"), s.bucket)
+	return trimURLPrefix(publicURL, prefix)
+}
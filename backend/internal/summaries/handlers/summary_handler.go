@@ -1 +1,97 @@
 package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	fileservice "chaladshare_backend/internal/files/service"
+	"chaladshare_backend/internal/middleware"
+	"chaladshare_backend/internal/summaries/models"
+	"chaladshare_backend/internal/summaries/service"
+)
+
+type SummaryHandler struct {
+	summaryService service.SummaryService
+	fileService    fileservice.FileService
+}
+
+func NewSummaryHandler(summaryService service.SummaryService, fileService fileservice.FileService) *SummaryHandler {
+	return &SummaryHandler{summaryService: summaryService, fileService: fileService}
+}
+
+// เช็ค document_id + เจ้าของไฟล์ ใช้ร่วมกันทั้ง GET/POST
+func (h *SummaryHandler) ownedDocID(c *gin.Context) (int, bool) {
+	authUID := c.GetInt(middleware.CtxUserID)
+	if authUID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, false
+	}
+
+	docID, err := strconv.Atoi(c.Param("document_id"))
+	if err != nil || docID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document_id"})
+		return 0, false
+	}
+
+	ok, err := h.fileService.IsOwner(docID, authUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบไฟล์นี้"})
+			return 0, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return 0, false
+	}
+	return docID, true
+}
+
+// GET /api/v1/files/:document_id/summary
+func (h *SummaryHandler) GetSummary(c *gin.Context) {
+	docID, ok := h.ownedDocID(c)
+	if !ok {
+		return
+	}
+
+	view, err := h.summaryService.GetSummary(docID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสรุปของไฟล์นี้"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// POST /api/v1/files/:document_id/summary
+func (h *SummaryHandler) GenerateSummary(c *gin.Context) {
+	docID, ok := h.ownedDocID(c)
+	if !ok {
+		return
+	}
+
+	st, err := h.summaryService.Regenerate(docID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrSummaryInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, models.ErrAIUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบไฟล์นี้"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, st)
+}
@@ -1 +1,38 @@
 package models
+
+import (
+	"errors"
+	"time"
+
+	filemodels "chaladshare_backend/internal/files/models"
+)
+
+const (
+	SummaryQueued     = "queued"
+	SummaryProcessing = "processing"
+	SummaryDone       = "done"
+	SummaryFailed     = "failed"
+)
+
+var (
+	ErrSummaryInProgress = errors.New("summary is already being generated")
+	ErrAIUnavailable     = errors.New("ai client is not configured")
+)
+
+// สถานะการสรุปของเอกสาร (1 แถวต่อ document)
+type SummaryStatus struct {
+	DocumentID    int       `json:"document_id"`
+	SummaryStatus string    `json:"summary_status"`
+	ErrorMessage  *string   `json:"error_message,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// response ของ GET /files/:document_id/summary
+type SummaryView struct {
+	DocumentID    int                 `json:"document_id"`
+	SummaryStatus string              `json:"summary_status"`
+	ErrorMessage  *string             `json:"error_message,omitempty"`
+	UpdatedAt     *time.Time          `json:"updated_at,omitempty"`
+	Summary       *filemodels.Summary `json:"summary,omitempty"`
+}
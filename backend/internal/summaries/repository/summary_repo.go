@@ -1 +1,113 @@
 package repository
+
+import (
+	"database/sql"
+
+	"chaladshare_backend/internal/summaries/models"
+)
+
+type SummaryRepository interface {
+	CreateQueued(documentID int) error
+	Requeue(documentID int) error
+	MarkProcessing(documentID int) error
+	MarkDeferred(documentID int, reason string) error
+	MarkDone(documentID int) error
+	MarkFailed(documentID int, msg string) error
+	GetStatus(documentID int) (*models.SummaryStatus, error)
+}
+
+type summaryRepository struct {
+	db *sql.DB
+}
+
+func NewSummaryRepository(db *sql.DB) SummaryRepository {
+	return &summaryRepository{db: db}
+}
+
+func (r *summaryRepository) CreateQueued(documentID int) error {
+	q := `
+		INSERT INTO summary_status (document_id, summary_status)
+		VALUES ($1, $2)
+		ON CONFLICT (document_id) DO NOTHING;
+	`
+	_, err := r.db.Exec(q, documentID, models.SummaryQueued)
+	return err
+}
+
+// สั่งสรุปใหม่: มีแถวอยู่แล้วก็รีเซ็ตกลับเป็น queued
+func (r *summaryRepository) Requeue(documentID int) error {
+	q := `
+		INSERT INTO summary_status (document_id, summary_status)
+		VALUES ($1, $2)
+		ON CONFLICT (document_id) DO UPDATE
+		SET summary_status = EXCLUDED.summary_status,
+		    error_message  = NULL,
+		    updated_at     = now();
+	`
+	_, err := r.db.Exec(q, documentID, models.SummaryQueued)
+	return err
+}
+
+func (r *summaryRepository) MarkProcessing(documentID int) error {
+	q := `
+		UPDATE summary_status
+		SET summary_status = $2, error_message = NULL, updated_at = now()
+		WHERE document_id = $1;
+	`
+	_, err := r.db.Exec(q, documentID, models.SummaryProcessing)
+	return err
+}
+
+func (r *summaryRepository) MarkDeferred(documentID int, reason string) error {
+	q := `
+		UPDATE summary_status
+		SET summary_status = $2, error_message = $3, updated_at = now()
+		WHERE document_id = $1;
+	`
+	_, err := r.db.Exec(q, documentID, models.SummaryQueued, reason)
+	return err
+}
+
+func (r *summaryRepository) MarkDone(documentID int) error {
+	q := `
+		UPDATE summary_status
+		SET summary_status = $2, error_message = NULL, updated_at = now()
+		WHERE document_id = $1;
+	`
+	_, err := r.db.Exec(q, documentID, models.SummaryDone)
+	return err
+}
+
+func (r *summaryRepository) MarkFailed(documentID int, msg string) error {
+	q := `
+		UPDATE summary_status
+		SET summary_status = $2, error_message = $3, updated_at = now()
+		WHERE document_id = $1;
+	`
+	_, err := r.db.Exec(q, documentID, models.SummaryFailed, msg)
+	return err
+}
+
+func (r *summaryRepository) GetStatus(documentID int) (*models.SummaryStatus, error) {
+	q := `
+		SELECT document_id, summary_status, error_message, created_at, updated_at
+		FROM summary_status
+		WHERE document_id = $1;
+	`
+
+	var out models.SummaryStatus
+	err := r.db.QueryRow(q, documentID).Scan(
+		&out.DocumentID,
+		&out.SummaryStatus,
+		&out.ErrorMessage,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
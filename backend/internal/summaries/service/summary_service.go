@@ -1 +1,205 @@
 package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"chaladshare_backend/internal/connect"
+	filemodels "chaladshare_backend/internal/files/models"
+	filerepo "chaladshare_backend/internal/files/repository"
+	jobmodels "chaladshare_backend/internal/jobs/models"
+	jobservice "chaladshare_backend/internal/jobs/service"
+	"chaladshare_backend/internal/summaries/models"
+	"chaladshare_backend/internal/summaries/repository"
+)
+
+type SummaryService interface {
+	CreateQueued(documentID int) error
+	GetSummary(documentID int) (*models.SummaryView, error)
+	GetStatus(documentID int) (*models.SummaryStatus, error)
+	ProcessDocument(in jobmodels.Input) error
+	MarkFailed(documentID int, msg string) error
+	Regenerate(documentID int) (*models.SummaryStatus, error)
+}
+
+type summaryService struct {
+	summaryRepo repository.SummaryRepository
+	fileRepo    filerepo.FileRepository
+	aiClient    *connect.Client
+	jobSvc      jobservice.JobService
+}
+
+func NewSummaryService(summaryRepo repository.SummaryRepository, fileRepo filerepo.FileRepository, aiClient *connect.Client, jobSvc jobservice.JobService) SummaryService {
+	return &summaryService{
+		summaryRepo: summaryRepo,
+		fileRepo:    fileRepo,
+		aiClient:    aiClient,
+		jobSvc:      jobSvc,
+	}
+}
+
+func (s *summaryService) CreateQueued(documentID int) error {
+	if documentID <= 0 {
+		return fmt.Errorf("invalid documentID")
+	}
+	return s.summaryRepo.CreateQueued(documentID)
+}
+
+// ยังไม่มีแถว → nil
+func (s *summaryService) GetStatus(documentID int) (*models.SummaryStatus, error) {
+	if documentID <= 0 {
+		return nil, fmt.Errorf("invalid documentID")
+	}
+	return s.summaryRepo.GetStatus(documentID)
+}
+
+func (s *summaryService) GetSummary(documentID int) (*models.SummaryView, error) {
+	if documentID <= 0 {
+		return nil, fmt.Errorf("invalid documentID")
+	}
+
+	st, err := s.summaryRepo.GetStatus(documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := s.fileRepo.GetSummaryByDocID(documentID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		sum = nil
+	}
+
+	if st == nil && sum == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	out := &models.SummaryView{DocumentID: documentID, Summary: sum}
+	if st != nil {
+		out.SummaryStatus = st.SummaryStatus
+		out.ErrorMessage = st.ErrorMessage
+		out.UpdatedAt = &st.UpdatedAt
+	} else {
+		// เอกสารเก่าที่มี summary ก่อนมีตาราง summary_status
+		out.SummaryStatus = models.SummaryDone
+	}
+	return out, nil
+}
+
+func (s *summaryService) MarkFailed(documentID int, msg string) error {
+	if documentID <= 0 {
+		return fmt.Errorf("invalid documentID")
+	}
+	if msg == "" {
+		msg = "unknown error"
+	}
+	return s.summaryRepo.MarkFailed(documentID, msg)
+}
+
+func (s *summaryService) markFailed(documentID int, msg string) {
+	if err := s.MarkFailed(documentID, msg); err != nil {
+		log.Printf("[SUMMARY] mark failed doc=%d err=%v", documentID, err)
+	}
+}
+
+func (s *summaryService) deferDocument(documentID int, cause error) error {
+	reason := "ai backend unavailable, deferred: " + cause.Error()
+	if err := s.summaryRepo.MarkDeferred(documentID, reason); err != nil {
+		log.Printf("[SUMMARY] mark deferred doc=%d err=%v", documentID, err)
+	}
+	return fmt.Errorf("%w: %v", jobmodels.ErrDeferred, cause)
+}
+
+// error ที่คืนใช้บอก job queue เท่านั้น สถานะใน summary_status ถูกบันทึกแล้ว
+func (s *summaryService) ProcessDocument(in jobmodels.Input) error {
+	documentID := in.DocumentID
+
+	if s.aiClient == nil {
+		s.markFailed(documentID, "ai client is nil")
+		return errors.New("ai client is nil")
+	}
+
+	if in.Path == "" {
+		s.markFailed(documentID, "input path is empty")
+		return errors.New("input path is empty")
+	}
+
+	if !s.aiClient.Available() {
+		return s.deferDocument(documentID, connect.ErrCircuitOpen)
+	}
+
+	if err := s.summaryRepo.MarkProcessing(documentID); err != nil {
+		s.markFailed(documentID, err.Error())
+		return err
+	}
+
+	resp, err := s.aiClient.Summarize(documentID, connect.Upload{Path: in.Path, MIME: in.MIME, OriginalMIME: in.OriginalMIME})
+	if err != nil {
+		if errors.Is(err, connect.ErrCircuitOpen) {
+			return s.deferDocument(documentID, err)
+		}
+		s.markFailed(documentID, err.Error())
+		return err
+	}
+
+	if strings.TrimSpace(resp.SummaryText) == "" {
+		s.markFailed(documentID, "empty summary_text from ai")
+		return errors.New("empty summary_text from ai")
+	}
+
+	// เก็บแค่ summary ล่าสุดต่อเอกสาร
+	if _, err := s.fileRepo.ReplaceSummary(&filemodels.Summary{
+		DocumentID:    documentID,
+		SummaryText:   resp.SummaryText,
+		SummaryHTML:   resp.SummaryHTML,
+		SummaryPDFURL: resp.SummaryPDFURL,
+	}); err != nil {
+		s.markFailed(documentID, err.Error())
+		return err
+	}
+
+	if err := s.summaryRepo.MarkDone(documentID); err != nil {
+		log.Printf("[SUMMARY] mark done doc=%d err=%v", documentID, err)
+	}
+	return nil
+}
+
+// สั่งสรุปใหม่ (on demand) ไฟล์ temp ตอนอัปโหลดถูกลบไปแล้ว worker จะโหลดจาก storage ใหม่
+func (s *summaryService) Regenerate(documentID int) (*models.SummaryStatus, error) {
+	if documentID <= 0 {
+		return nil, fmt.Errorf("invalid documentID")
+	}
+	if s.aiClient == nil {
+		return nil, models.ErrAIUnavailable
+	}
+
+	st, err := s.summaryRepo.GetStatus(documentID)
+	if err != nil {
+		return nil, err
+	}
+	if st != nil && st.SummaryStatus == models.SummaryProcessing {
+		return nil, models.ErrSummaryInProgress
+	}
+
+	if _, err := s.fileRepo.GetDocumentByID(documentID); err != nil {
+		return nil, err
+	}
+
+	if err := s.summaryRepo.Requeue(documentID); err != nil {
+		return nil, err
+	}
+
+	if err := s.jobSvc.Enqueue(jobmodels.EnqueueInput{
+		DocumentID: documentID,
+		JobType:    jobmodels.JobSummarize,
+	}); err != nil {
+		s.markFailed(documentID, err.Error())
+		return nil, err
+	}
+
+	return s.summaryRepo.GetStatus(documentID)
+}
@@ -1,5 +1,7 @@
 package models
 
+import "chaladshare_backend/internal/imaging"
+
 type User struct {
 	UserID       int    `json:"user_id"`
 	Username     string `json:"username"`
@@ -39,6 +41,7 @@ func (j UserProfile) ToOwnProfileResponse() OwnProfileResponse {
 	}
 	if j.AvatarURL != nil {
 		r.AvatarURL = *j.AvatarURL
+		r.AvatarSrcSet = imaging.SrcSet(r.AvatarURL, imaging.AvatarVariants)
 	}
 	if j.AvatarStore != nil {
 		r.AvatarStore = *j.AvatarStore
@@ -57,6 +60,7 @@ func (j UserProfile) ToViewedUserProfileResponse() ViewedUserProfileResponse {
 	}
 	if j.AvatarURL != nil {
 		r.AvatarURL = *j.AvatarURL
+		r.AvatarSrcSet = imaging.SrcSet(r.AvatarURL, imaging.AvatarVariants)
 	}
 	if j.AvatarStore != nil {
 		r.AvatarStore = *j.AvatarStore
@@ -72,25 +76,38 @@ type UpdateOwnProfileRequest struct {
 	AvatarURL   *string `json:"avatar_url"`
 	AvatarStore *string `json:"avatar_storage"`
 	Bio         *string `json:"bio"`
+
+	// service เติมเองจาก AvatarURL ไม่รับจาก client
+	AvatarPath   *string `json:"-"`
+	AvatarBucket *string `json:"-"`
+}
+
+// object ของ avatar ก่อนอัปเดต (ว่าง = ไม่ได้อัปผ่าน storage ของเรา)
+type AvatarObject struct {
+	Path     string
+	Bucket   string
+	Provider string
 }
 
 type OwnProfileResponse struct {
-	UserID      int64  `json:"user_id"`
-	Email       string `json:"email"`
-	Username    string `json:"username"`
-	AvatarURL   string `json:"avatar_url"`
-	AvatarStore string `json:"avatar_storage"`
-	Bio         string `json:"bio"`
-	Status      string `json:"user_status"`
-	CreatedAt   string `json:"user_created_at"`
+	UserID       int64  `json:"user_id"`
+	Email        string `json:"email"`
+	Username     string `json:"username"`
+	AvatarURL    string `json:"avatar_url"`
+	AvatarStore  string `json:"avatar_storage"`
+	AvatarSrcSet string `json:"avatar_srcset,omitempty"`
+	Bio          string `json:"bio"`
+	Status       string `json:"user_status"`
+	CreatedAt    string `json:"user_created_at"`
 }
 
 type ViewedUserProfileResponse struct {
-	UserID      int64  `json:"user_id"`
-	Username    string `json:"username"`
-	AvatarURL   string `json:"avatar_url"`
-	AvatarStore string `json:"avatar_storage"`
-	Bio         string `json:"bio"`
+	UserID       int64  `json:"user_id"`
+	Username     string `json:"username"`
+	AvatarURL    string `json:"avatar_url"`
+	AvatarStore  string `json:"avatar_storage"`
+	AvatarSrcSet string `json:"avatar_srcset,omitempty"`
+	Bio          string `json:"bio"`
 }
 
 // change password
@@ -11,7 +11,8 @@ import (
 type UserRepository interface {
 	GetOwnProfile(ctx context.Context, userID int) (*models.OwnProfileResponse, error)
 	GetViewedUserProfile(ctx context.Context, userID int) (*models.ViewedUserProfileResponse, error)
-	UpdateOwnProfile(ctx context.Context, userID int, req *models.UpdateOwnProfileRequest) error
+	// คืน avatar เดิมเมื่อมีการเปลี่ยน avatar (ไว้ลบ object เก่า)
+	UpdateOwnProfile(ctx context.Context, userID int, req *models.UpdateOwnProfileRequest) (*models.AvatarObject, error)
 }
 
 type userRepo struct {
@@ -77,10 +78,10 @@ func (r *userRepo) GetViewedUserProfile(ctx context.Context, userID int) (*model
 	return &resp, nil
 }
 
-func (r *userRepo) UpdateOwnProfile(ctx context.Context, userID int, req *models.UpdateOwnProfileRequest) (err error) {
+func (r *userRepo) UpdateOwnProfile(ctx context.Context, userID int, req *models.UpdateOwnProfileRequest) (prev *models.AvatarObject, err error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() {
 		if err != nil {
@@ -95,7 +96,7 @@ func (r *userRepo) UpdateOwnProfile(ctx context.Context, userID int, req *models
 			`UPDATE users SET username = $1 WHERE user_id = $2`,
 			*req.Username, userID,
 		); err != nil {
-			return err // ถ้าชื่อซ้ำ/ติด unique constraint จะเด้งจากตรงนี้
+			return nil, err // ถ้าชื่อซ้ำ/ติด unique constraint จะเด้งจากตรงนี้
 		}
 	}
 
@@ -104,7 +105,22 @@ func (r *userRepo) UpdateOwnProfile(ctx context.Context, userID int, req *models
 		VALUES ($1)
 		ON CONFLICT (profile_user_id) DO NOTHING
 	`, userID); err != nil {
-		return err
+		return nil, err
+	}
+
+	if req.AvatarURL != nil {
+		var old models.AvatarObject
+		if err = tx.QueryRowContext(ctx, `
+			SELECT COALESCE(avatar_path, ''), COALESCE(avatar_bucket, ''), COALESCE(avatar_storage, '')
+			FROM user_profiles
+			WHERE profile_user_id = $1
+			FOR UPDATE
+		`, userID).Scan(&old.Path, &old.Bucket, &old.Provider); err != nil {
+			return nil, err
+		}
+		if old.Path != "" {
+			prev = &old
+		}
 	}
 
 	if req.AvatarURL != nil || req.AvatarStore != nil || req.Bio != nil {
@@ -114,12 +130,14 @@ func (r *userRepo) UpdateOwnProfile(ctx context.Context, userID int, req *models
 				avatar_url     = COALESCE($1, avatar_url),
 				avatar_storage = COALESCE($2, avatar_storage),
 				bio            = COALESCE($3, bio),
+				avatar_path    = CASE WHEN $1::text IS NULL THEN avatar_path ELSE $5 END,
+				avatar_bucket  = CASE WHEN $1::text IS NULL THEN avatar_bucket ELSE $6 END,
 				updated_at     = now()
 			WHERE profile_user_id = $4
-		`, req.AvatarURL, req.AvatarStore, req.Bio, userID); err != nil {
-			return err
+		`, req.AvatarURL, req.AvatarStore, req.Bio, userID, req.AvatarPath, req.AvatarBucket); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return prev, nil
 }
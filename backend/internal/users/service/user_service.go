@@ -4,8 +4,14 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
 	"unicode/utf8"
 
+	"chaladshare_backend/internal/imaging"
+	"chaladshare_backend/internal/storage"
 	"chaladshare_backend/internal/users/models"
 	"chaladshare_backend/internal/users/repository"
 )
@@ -17,11 +23,12 @@ type UserService interface {
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo    repository.UserRepository
+	storage *storage.Registry
 }
 
-func NewUserService(r repository.UserRepository) UserService {
-	return &userService{repo: r}
+func NewUserService(r repository.UserRepository, storage *storage.Registry) UserService {
+	return &userService{repo: r, storage: storage}
 }
 
 func (s *userService) GetOwnProfile(ctx context.Context, userID int) (*models.OwnProfileResponse, error) {
@@ -48,5 +55,50 @@ func (s *userService) UpdateOwnProfile(ctx context.Context, userID int, req *mod
 			return errors.New("bio must be at most 150 characters")
 		}
 	}
-	return s.repo.UpdateOwnProfile(ctx, userID, req)
+
+	// avatar ที่อัปผ่าน /files/avatar ของตัวเอง → เก็บ path ไว้ลบ/ย้าย storage ทีหลัง
+	req.AvatarPath, req.AvatarBucket = nil, nil
+	if req.AvatarURL != nil && s.storage != nil {
+		if obj, ok := s.storage.Locate(*req.AvatarURL); ok && strings.HasPrefix(obj.Path, fmt.Sprintf("avatars/%d/", userID)) {
+			req.AvatarPath = &obj.Path
+			req.AvatarStore = &obj.Provider
+			if obj.Bucket != "" {
+				req.AvatarBucket = &obj.Bucket
+			}
+		}
+	}
+	prev, err := s.repo.UpdateOwnProfile(ctx, userID, req)
+	if err != nil {
+		return err
+	}
+	s.deleteOldAvatar(userID, prev, req.AvatarPath)
+	return nil
+}
+
+// ลบรูปทุกขนาดของ avatar เดิมหลังเปลี่ยนรูปแล้ว (ลบไม่สำเร็จแค่ log ไม่ให้การอัปเดตล้ม)
+func (s *userService) deleteOldAvatar(userID int, prev *models.AvatarObject, newPath *string) {
+	if prev == nil || s.storage == nil || !strings.HasPrefix(prev.Path, fmt.Sprintf("avatars/%d/", userID)) {
+		return
+	}
+	dir := path.Dir(prev.Path)
+	if newPath != nil && path.Dir(*newPath) == dir {
+		return
+	}
+	st, err := s.storage.ForObject(prev.Provider, prev.Bucket)
+	if err != nil {
+		log.Printf("[PROFILE] delete old avatar uid=%d err=%v", userID, err)
+		return
+	}
+	// ของเก่าก่อนมี variant เป็นไฟล์เดียว (original.webp) → ลบ path ที่เก็บไว้ด้วย
+	paths := []string{prev.Path}
+	for _, v := range imaging.AvatarVariants {
+		if p := imaging.ObjectPath(dir, v.Name); p != prev.Path {
+			paths = append(paths, p)
+		}
+	}
+	for _, p := range paths {
+		if err := st.Delete(context.Background(), p); err != nil {
+			log.Printf("[PROFILE] delete old avatar uid=%d path=%s err=%v", userID, p, err)
+		}
+	}
 }
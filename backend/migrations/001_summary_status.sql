@@ -0,0 +1,15 @@
+-- สถานะการสรุปเอกสารด้วย AI (แยกจาก summaries ที่เก็บผลลัพธ์)
+CREATE TABLE IF NOT EXISTS summary_status (
+    document_id    INTEGER PRIMARY KEY REFERENCES documents(document_id) ON DELETE CASCADE,
+    summary_status TEXT        NOT NULL DEFAULT 'queued'
+                   CHECK (summary_status IN ('queued', 'processing', 'done', 'failed')),
+    error_message  TEXT,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- เอกสารเก่าที่มี summary อยู่แล้ว
+INSERT INTO summary_status (document_id, summary_status)
+SELECT DISTINCT s.document_id, 'done'
+FROM summaries s
+ON CONFLICT (document_id) DO NOTHING;
@@ -0,0 +1,26 @@
+-- คิวงานประมวลผลเอกสารแบบถาวร (worker จองงานด้วย FOR UPDATE SKIP LOCKED)
+CREATE TABLE IF NOT EXISTS document_jobs (
+    job_id        BIGSERIAL PRIMARY KEY,
+    document_id   INTEGER     NOT NULL REFERENCES documents(document_id) ON DELETE CASCADE,
+    job_type      TEXT        NOT NULL CHECK (job_type IN ('extract_features', 'summarize')),
+    job_status    TEXT        NOT NULL DEFAULT 'queued'
+                  CHECK (job_status IN ('queued', 'processing', 'done', 'failed')),
+    attempts      INTEGER     NOT NULL DEFAULT 0,
+    local_path    TEXT,
+    local_is_temp BOOLEAN     NOT NULL DEFAULT FALSE,
+    lease_owner   TEXT,
+    leased_until  TIMESTAMPTZ,
+    run_after     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error    TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- งานที่ยังไม่จบมีได้แค่ 1 งานต่อ (document, job_type)
+CREATE UNIQUE INDEX IF NOT EXISTS document_jobs_active_uniq
+    ON document_jobs (document_id, job_type)
+    WHERE job_status IN ('queued', 'processing');
+
+CREATE INDEX IF NOT EXISTS document_jobs_pick_idx
+    ON document_jobs (run_after, job_id)
+    WHERE job_status IN ('queued', 'processing');
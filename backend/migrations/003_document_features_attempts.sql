@@ -0,0 +1,5 @@
+-- จำนวนครั้งที่เรียก colab (รวม retry) และ error ล่าสุดของ extract_features
+ALTER TABLE document_features
+    ADD COLUMN IF NOT EXISTS attempt_count   INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_error      TEXT,
+    ADD COLUMN IF NOT EXISTS last_attempt_at TIMESTAMPTZ;
@@ -0,0 +1,61 @@
+-- เก็บตำแหน่งไฟล์จริง (provider / bucket / path) แทนการแกะจาก public URL ทุกครั้ง
+-- ใช้ลบ / sign URL ใหม่ ส่วนการย้ายไฟล์ข้าม provider (copy object แล้วชี้แถวใหม่) ยังไม่มีในรอบนี้
+-- แค่เก็บข้อมูลที่ต้องใช้ไว้ก่อน
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS document_path   TEXT,
+    ADD COLUMN IF NOT EXISTS document_bucket TEXT;
+
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS post_cover_path    TEXT,
+    ADD COLUMN IF NOT EXISTS post_cover_bucket  TEXT,
+    ADD COLUMN IF NOT EXISTS post_cover_storage TEXT;
+
+ALTER TABLE user_profiles
+    ADD COLUMN IF NOT EXISTS avatar_path   TEXT,
+    ADD COLUMN IF NOT EXISTS avatar_bucket TEXT;
+
+-- แกะ URL เดิมครั้งเดียว
+-- supabase: {SUPABASE_URL}/storage/v1/object/public/{bucket}/{path}
+-- local:    /uploads/{path}
+UPDATE documents
+SET document_bucket = substring(document_url from '/storage/v1/object/public/([^/?]+)/'),
+    document_path   = substring(document_url from '/storage/v1/object/public/[^/?]+/([^?]+)')
+WHERE document_path IS NULL
+  AND storage_provider = 'supabase'
+  AND document_url ~ '/storage/v1/object/public/[^/?]+/.+';
+
+UPDATE documents
+SET document_path = substring(document_url from '/uploads/([^?]+)')
+WHERE document_path IS NULL
+  AND storage_provider = 'local'
+  AND document_url ~ '/uploads/.+';
+
+UPDATE posts
+SET post_cover_storage = 'supabase',
+    post_cover_bucket  = substring(post_cover_url from '/storage/v1/object/public/([^/?]+)/'),
+    post_cover_path    = substring(post_cover_url from '/storage/v1/object/public/[^/?]+/([^?]+)')
+WHERE post_cover_path IS NULL
+  AND post_cover_url ~ '/storage/v1/object/public/[^/?]+/.+';
+
+UPDATE user_profiles
+SET avatar_storage = COALESCE(avatar_storage, 'supabase'),
+    avatar_bucket  = substring(avatar_url from '/storage/v1/object/public/([^/?]+)/'),
+    avatar_path    = substring(avatar_url from '/storage/v1/object/public/[^/?]+/([^?]+)')
+WHERE avatar_path IS NULL
+  AND avatar_url ~ '/storage/v1/object/public/[^/?]+/.+';
+
+UPDATE posts
+SET post_cover_storage = 'local',
+    post_cover_bucket  = NULL,
+    post_cover_path    = substring(post_cover_url from '/uploads/([^?]+)')
+WHERE post_cover_path IS NULL
+  AND post_cover_url ~ '/uploads/.+'
+  AND post_cover_url !~ '/storage/v1/object/public/';
+
+UPDATE user_profiles
+SET avatar_storage = 'local',
+    avatar_bucket  = NULL,
+    avatar_path    = substring(avatar_url from '/uploads/([^?]+)')
+WHERE avatar_path IS NULL
+  AND avatar_url ~ '/uploads/.+'
+  AND avatar_url !~ '/storage/v1/object/public/';
@@ -0,0 +1,4 @@
+-- ขนาดไฟล์ (byte) และจำนวนหน้า บันทึกตอนตรวจไฟล์ที่อัปโหลด (แถวเก่าเป็น NULL)
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS document_size  BIGINT,
+    ADD COLUMN IF NOT EXISTS document_pages INT;
@@ -0,0 +1,11 @@
+-- หน้าปกที่ render จากหน้าแรกของ PDF (ใช้แทนเมื่อผู้เขียนไม่ได้อัปหน้าปกเอง)
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS document_cover_url     TEXT,
+    ADD COLUMN IF NOT EXISTS document_cover_path    TEXT,
+    ADD COLUMN IF NOT EXISTS document_cover_bucket  TEXT,
+    ADD COLUMN IF NOT EXISTS document_cover_storage TEXT;
+
+-- งานใหม่ render_cover ในคิว document_jobs
+ALTER TABLE document_jobs DROP CONSTRAINT IF EXISTS document_jobs_job_type_check;
+ALTER TABLE document_jobs ADD CONSTRAINT document_jobs_job_type_check
+    CHECK (job_type IN ('extract_features', 'summarize', 'render_cover'));
@@ -0,0 +1,16 @@
+-- อัปโหลดแบบแบ่งก้อน (ต่อได้เมื่อเน็ตหลุด) ตัวไฟล์อยู่ใน temp dir ส่วนสถานะเก็บที่นี่
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    upload_id    UUID        PRIMARY KEY,
+    user_id      INTEGER     NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    file_name    TEXT        NOT NULL,
+    total_size   BIGINT      NOT NULL CHECK (total_size > 0),
+    received     BIGINT      NOT NULL DEFAULT 0,
+    document_id  INTEGER     REFERENCES documents(document_id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS upload_sessions_expire_idx
+    ON upload_sessions (expires_at);
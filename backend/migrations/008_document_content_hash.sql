@@ -0,0 +1,12 @@
+-- SHA-256 ของไฟล์ (hex) ใช้หาไฟล์ซ้ำ: ไฟล์เดียวกันใช้ object ใน storage และผลวิเคราะห์ร่วมกัน
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS content_hash CHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_documents_content_hash
+    ON documents (content_hash)
+    WHERE content_hash IS NOT NULL;
+
+-- นับจำนวนเอกสารที่ชี้ object เดียวกันตอนลบ
+CREATE INDEX IF NOT EXISTS idx_documents_object
+    ON documents (storage_provider, document_path)
+    WHERE document_path IS NOT NULL;
@@ -0,0 +1,43 @@
+-- เวอร์ชันของเอกสาร: เอกสารชุดเดียวกัน (family) ใช้ document_id ของเวอร์ชันแรกเป็น document_family_id
+-- แต่ละ family มีเวอร์ชันปัจจุบัน (document_is_current) ได้อันเดียว
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS document_family_id  INT,
+    ADD COLUMN IF NOT EXISTS document_version    INT     NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS document_is_current BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE documents SET document_family_id = document_id WHERE document_family_id IS NULL;
+ALTER TABLE documents ALTER COLUMN document_family_id SET NOT NULL;
+
+-- insert ที่ไม่ระบุ family = เวอร์ชันแรกของ family ใหม่
+CREATE OR REPLACE FUNCTION documents_default_family() RETURNS trigger AS $$
+BEGIN
+    IF NEW.document_family_id IS NULL THEN
+        NEW.document_family_id := NEW.document_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_documents_default_family ON documents;
+CREATE TRIGGER trg_documents_default_family
+    BEFORE INSERT ON documents
+    FOR EACH ROW EXECUTE FUNCTION documents_default_family();
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_documents_family_version
+    ON documents (document_family_id, document_version);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_documents_family_current
+    ON documents (document_family_id)
+    WHERE document_is_current;
+
+-- โพสต์ผูกกับ family ส่วน post_document_id ชี้เวอร์ชันปัจจุบันเสมอ (อัปเดตตอนอัปเวอร์ชันใหม่ / restore)
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS post_document_family_id INT;
+
+UPDATE posts p
+SET post_document_family_id = d.document_family_id
+FROM documents d
+WHERE d.document_id = p.post_document_id
+  AND p.post_document_family_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_posts_document_family
+    ON posts (post_document_family_id);
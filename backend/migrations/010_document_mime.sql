@@ -0,0 +1,3 @@
+-- ชนิดไฟล์ต้นฉบับ (ตรวจจาก magic byte ตอนอัปโหลด) แถวเก่าเป็น PDF ทั้งหมด
+ALTER TABLE documents
+    ADD COLUMN IF NOT EXISTS document_mime TEXT NOT NULL DEFAULT 'application/pdf';
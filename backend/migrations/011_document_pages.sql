@@ -0,0 +1,12 @@
+-- ข้อความแต่ละหน้าที่ดึงเองใน backend (pdftext) ใช้ค้นหาได้แม้ Colab ไม่ทำงาน
+CREATE TABLE IF NOT EXISTS document_pages (
+    document_id INTEGER NOT NULL REFERENCES documents(document_id) ON DELETE CASCADE,
+    page_no     INTEGER NOT NULL,
+    page_text   TEXT    NOT NULL,
+    PRIMARY KEY (document_id, page_no)
+);
+
+-- งานใหม่ extract_text ในคิว document_jobs
+ALTER TABLE document_jobs DROP CONSTRAINT IF EXISTS document_jobs_job_type_check;
+ALTER TABLE document_jobs ADD CONSTRAINT document_jobs_job_type_check
+    CHECK (job_type IN ('extract_features', 'summarize', 'render_cover', 'extract_text'));
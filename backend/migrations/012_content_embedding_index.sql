@@ -0,0 +1,27 @@
+-- ANN index สำหรับค้นหาเชิงความหมาย (cosine) บน content_embedding
+-- index ของ pgvector ต้องรู้จำนวนมิติ → สร้างเฉพาะเมื่อคอลัมน์ประกาศเป็น vector(n)
+-- pgvector >= 0.5 ใช้ HNSW ไม่งั้นใช้ IVFFlat
+DO $$
+DECLARE
+    dims INTEGER;
+BEGIN
+    SELECT a.atttypmod INTO dims
+    FROM pg_attribute a
+    WHERE a.attrelid = 'document_features'::regclass
+      AND a.attname = 'content_embedding'
+      AND NOT a.attisdropped;
+
+    IF dims IS NULL OR dims <= 0 THEN
+        RAISE NOTICE 'content_embedding has no fixed dimension, skip ANN index';
+        RETURN;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM pg_am WHERE amname = 'hnsw') THEN
+        EXECUTE 'CREATE INDEX IF NOT EXISTS idx_document_features_embedding_hnsw
+                 ON document_features USING hnsw (content_embedding vector_cosine_ops)';
+    ELSE
+        EXECUTE 'CREATE INDEX IF NOT EXISTS idx_document_features_embedding_ivfflat
+                 ON document_features USING ivfflat (content_embedding vector_cosine_ops)
+                 WITH (lists = 100)';
+    END IF;
+END $$;
@@ -0,0 +1,22 @@
+-- ค้นหาโพสต์: full-text ('simple' ตัดคำตามช่องว่าง ใช้ได้กับภาษาอังกฤษ)
+-- + trigram สำหรับภาษาไทยที่ไม่มีช่องว่างระหว่างคำ (ILIKE '%คำ%' ใช้ index นี้ได้)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_posts_title_trgm
+    ON posts USING gin (post_title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_posts_description_trgm
+    ON posts USING gin (post_description gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_tags_name_trgm
+    ON tags USING gin (tag_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_document_features_content_trgm
+    ON document_features USING gin (content_text gin_trgm_ops);
+
+-- expression ของสอง index ด้านล่างต้องตรงกับ CTE matched ใน SearchPosts (internal/posts/repository/post_repo.go)
+-- ตัวอักษรต่อตัวอักษร ไม่งั้น postgres ไม่ใช้ index:
+--   to_tsvector('simple', COALESCE(p.post_title, '') || ' ' || COALESCE(p.post_description, ''))
+--   to_tsvector('simple', left(COALESCE(df.content_text, ''), 100000))
+CREATE INDEX IF NOT EXISTS idx_posts_fts
+    ON posts USING gin (to_tsvector('simple', COALESCE(post_title, '') || ' ' || COALESCE(post_description, '')));
+-- tsvector ใหญ่ได้ไม่เกิน 1MB → ใช้แค่ต้นเอกสาร
+CREATE INDEX IF NOT EXISTS idx_document_features_fts
+    ON document_features USING gin (to_tsvector('simple', left(COALESCE(content_text, ''), 100000)));
@@ -0,0 +1,5 @@
+-- feed แบบ keyset (ORDER BY post_created_at DESC, post_id DESC) และกรองตามผู้เขียน
+CREATE INDEX IF NOT EXISTS idx_posts_created_id
+    ON posts (post_created_at DESC, post_id DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_author_created
+    ON posts (post_author_user_id, post_created_at DESC);
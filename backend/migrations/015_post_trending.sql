@@ -0,0 +1,57 @@
+-- คะแนน trending: engagement หารด้วยอายุโพสต์ (ชั่วโมง + 2) ยกกำลัง 1.5 แบบ Hacker News
+ALTER TABLE post_stats
+    ADD COLUMN IF NOT EXISTS post_view_count     INT              NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS post_comment_count  INT              NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS post_trending_score DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- ผู้ชมไม่ซ้ำต่อวัน (รีเฟรชหน้าซ้ำไม่ดันยอดวิว)
+CREATE TABLE IF NOT EXISTS post_views (
+    post_id   INT  NOT NULL REFERENCES posts(post_id) ON DELETE CASCADE,
+    user_id   INT  NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    viewed_on DATE NOT NULL DEFAULT CURRENT_DATE,
+    PRIMARY KEY (post_id, user_id, viewed_on)
+);
+
+-- สูตรเดียวใช้ทั้ง trigger และงาน refresh ตามรอบ
+CREATE OR REPLACE FUNCTION post_trending_score(
+    likes INT, saves INT, views INT, comments INT, created_at TIMESTAMPTZ
+) RETURNS DOUBLE PRECISION AS $$
+    SELECT (COALESCE(likes, 0) + 2.0 * COALESCE(saves, 0)
+            + 1.5 * COALESCE(comments, 0) + 0.1 * COALESCE(views, 0))
+         / power(GREATEST(EXTRACT(EPOCH FROM (now() - created_at)), 0) / 3600.0 + 2, 1.5);
+$$ LANGUAGE sql STABLE;
+
+-- ตัวนับเปลี่ยน → คิดคะแนนใหม่ทันที (incremental)
+CREATE OR REPLACE FUNCTION post_stats_trending() RETURNS trigger AS $$
+BEGIN
+    NEW.post_trending_score := COALESCE(post_trending_score(
+        NEW.post_like_count, NEW.post_save_count, NEW.post_view_count, NEW.post_comment_count,
+        (SELECT p.post_created_at FROM posts p WHERE p.post_id = NEW.post_stats_post_id)
+    ), 0);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_post_stats_trending ON post_stats;
+CREATE TRIGGER trg_post_stats_trending
+    BEFORE INSERT OR UPDATE OF post_like_count, post_save_count, post_view_count, post_comment_count
+    ON post_stats
+    FOR EACH ROW EXECUTE FUNCTION post_stats_trending();
+
+-- โพสต์เก่าที่ยังไม่มีแถว stats
+INSERT INTO post_stats (post_stats_post_id, post_like_count, post_save_count)
+SELECT p.post_id,
+       (SELECT COUNT(*) FROM likes l WHERE l.like_post_id = p.post_id),
+       (SELECT COUNT(*) FROM saved_posts sp WHERE sp.save_post_id = p.post_id)
+FROM posts p
+ON CONFLICT (post_stats_post_id) DO NOTHING;
+
+UPDATE post_stats ps
+SET post_trending_score = COALESCE(post_trending_score(
+        ps.post_like_count, ps.post_save_count, ps.post_view_count, ps.post_comment_count,
+        p.post_created_at), 0)
+FROM posts p
+WHERE p.post_id = ps.post_stats_post_id;
+
+CREATE INDEX IF NOT EXISTS idx_post_stats_trending
+    ON post_stats (post_trending_score DESC, post_stats_post_id DESC);
@@ -0,0 +1,24 @@
+-- คอมเมนต์ใต้โพสต์ ตอบกลับเป็นเธรดได้
+-- root = คอมเมนต์ระดับบนสุดของเธรด (NULL = ตัวมันเองเป็นระดับบนสุด) ใช้ดึงทั้งเธรดทีเดียว
+-- parent = คอมเมนต์ที่ตอบโดยตรง (ไว้แสดงว่าตอบใคร)
+CREATE TABLE IF NOT EXISTS comments (
+    comment_id         SERIAL      PRIMARY KEY,
+    comment_post_id    INTEGER     NOT NULL REFERENCES posts(post_id) ON DELETE CASCADE,
+    comment_user_id    INTEGER     NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    comment_parent_id  INTEGER     REFERENCES comments(comment_id) ON DELETE CASCADE,
+    comment_root_id    INTEGER     REFERENCES comments(comment_id) ON DELETE CASCADE,
+    comment_content    TEXT        NOT NULL,
+    comment_created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    comment_edited_at  TIMESTAMPTZ,
+    -- ลบแบบ soft: เธรดที่มีคนตอบแล้วยังอ่านต่อได้ (แสดงเป็น "ถูกลบ")
+    comment_deleted_at TIMESTAMPTZ,
+    CHECK ((comment_parent_id IS NULL) = (comment_root_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS comments_post_top_idx
+    ON comments (comment_post_id, comment_id)
+    WHERE comment_root_id IS NULL;
+CREATE INDEX IF NOT EXISTS comments_root_idx
+    ON comments (comment_root_id, comment_id);
+CREATE INDEX IF NOT EXISTS comments_parent_idx
+    ON comments (comment_parent_id);
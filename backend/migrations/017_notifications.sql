@@ -0,0 +1,27 @@
+-- แจ้งเตือนในแอป: ผู้รับ (user) / ผู้ทำ (actor) / สิ่งที่เกี่ยวข้อง (post / document / comment)
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id          BIGSERIAL   PRIMARY KEY,
+    notification_user_id     INTEGER     NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    notification_actor_id    INTEGER     REFERENCES users(user_id) ON DELETE CASCADE,
+    notification_type        TEXT        NOT NULL,
+    notification_post_id     INTEGER     REFERENCES posts(post_id) ON DELETE CASCADE,
+    notification_document_id INTEGER     REFERENCES documents(document_id) ON DELETE CASCADE,
+    notification_comment_id  INTEGER     REFERENCES comments(comment_id) ON DELETE CASCADE,
+    notification_message     TEXT        NOT NULL DEFAULT '',
+    notification_created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    notification_read_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_idx
+    ON notifications (notification_user_id, notification_id DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx
+    ON notifications (notification_user_id)
+    WHERE notification_read_at IS NULL;
+
+-- ปิดแจ้งเตือนรายประเภท (ไม่มีแถว = เปิด)
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id           INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    notification_type TEXT    NOT NULL,
+    enabled           BOOLEAN NOT NULL DEFAULT TRUE,
+    PRIMARY KEY (user_id, notification_type)
+);
@@ -0,0 +1,73 @@
+-- ส่งเหตุการณ์ผ่าน LISTEN/NOTIFY ช่อง app_events ให้ทุก backend instance กระจายต่อทาง SSE
+-- payload: {"type", "user_id" (ผู้รับ), "post_id" (ผู้ที่ดูโพสต์นี้อยู่), "data"} เก็บให้สั้น (NOTIFY จำกัด 8000 ไบต์)
+
+-- แจ้งเตือนใหม่ → ผู้รับ
+CREATE OR REPLACE FUNCTION notifications_publish() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('app_events', json_build_object(
+        'type', 'notification',
+        'user_id', NEW.notification_user_id,
+        'data', json_build_object(
+            'notification_id', NEW.notification_id,
+            'notification_type', NEW.notification_type
+        )
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_notifications_publish ON notifications;
+CREATE TRIGGER trg_notifications_publish
+    AFTER INSERT ON notifications
+    FOR EACH ROW EXECUTE FUNCTION notifications_publish();
+
+-- สถานะ document_features เปลี่ยน → เจ้าของเอกสาร
+CREATE OR REPLACE FUNCTION document_features_publish() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.feature_status IS NOT DISTINCT FROM OLD.feature_status THEN
+        RETURN NULL;
+    END IF;
+    PERFORM pg_notify('app_events', json_build_object(
+        'type', 'feature_status',
+        'user_id', (SELECT d.document_user_id FROM documents d WHERE d.document_id = NEW.document_id),
+        'data', json_build_object(
+            'document_id', NEW.document_id,
+            'status', NEW.feature_status::text,
+            'error_message', left(NEW.error_message, 500)
+        )
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_document_features_publish ON document_features;
+CREATE TRIGGER trg_document_features_publish
+    AFTER INSERT OR UPDATE OF feature_status ON document_features
+    FOR EACH ROW EXECUTE FUNCTION document_features_publish();
+
+-- ยอด like / save / comment เปลี่ยน → คนที่เปิดโพสต์นั้นอยู่ (ไม่ส่งตอน refresh คะแนน trending)
+CREATE OR REPLACE FUNCTION post_stats_publish() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW.post_like_count IS NOT DISTINCT FROM OLD.post_like_count
+        AND NEW.post_save_count IS NOT DISTINCT FROM OLD.post_save_count
+        AND NEW.post_comment_count IS NOT DISTINCT FROM OLD.post_comment_count THEN
+        RETURN NULL;
+    END IF;
+    PERFORM pg_notify('app_events', json_build_object(
+        'type', 'post_stats',
+        'post_id', NEW.post_stats_post_id,
+        'data', json_build_object(
+            'like_count', COALESCE(NEW.post_like_count, 0),
+            'save_count', COALESCE(NEW.post_save_count, 0),
+            'comment_count', NEW.post_comment_count
+        )
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_post_stats_publish ON post_stats;
+CREATE TRIGGER trg_post_stats_publish
+    AFTER INSERT OR UPDATE OF post_like_count, post_save_count, post_comment_count ON post_stats
+    FOR EACH ROW EXECUTE FUNCTION post_stats_publish();
@@ -0,0 +1,22 @@
+-- session ต่อการ login หนึ่งครั้ง (ต่ออุปกรณ์) เก็บ refresh token แบบ hash (sha256) เท่านั้น
+-- access token มี sid ชี้มาที่นี่ → revoke แล้วใช้ต่อไม่ได้ทันทีแม้ยังไม่ถึง exp
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    session_id          UUID        PRIMARY KEY,
+    user_id             INTEGER     NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    refresh_token_hash  TEXT        NOT NULL UNIQUE,
+    -- token ก่อนหมุนล่าสุด: ถูกใช้ซ้ำ = ถูกขโมย → revoke ทั้ง session
+    previous_token_hash TEXT,
+    user_agent          TEXT        NOT NULL DEFAULT '',
+    ip_address          TEXT        NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    rotated_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at          TIMESTAMPTZ NOT NULL,
+    revoked_at          TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS auth_sessions_user_idx
+    ON auth_sessions (user_id)
+    WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS auth_sessions_previous_idx
+    ON auth_sessions (previous_token_hash)
+    WHERE previous_token_hash IS NOT NULL;
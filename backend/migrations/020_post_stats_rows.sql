@@ -0,0 +1,25 @@
+-- ทุกโพสต์ต้องมีแถว post_stats: SearchPosts ใช้ JOIN ธรรมดาแล้วเรียงด้วย post_trending_score ตรง ๆ
+-- ให้ใช้ idx_post_stats_trending ได้ (COALESCE บน LEFT JOIN ใช้ index ไม่ได้ → sort ทุกโพสต์ทุกหน้า)
+-- CreatePost สร้างให้อยู่แล้ว trigger นี้กันโพสต์ที่ insert จากทางอื่น
+CREATE OR REPLACE FUNCTION posts_init_stats() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO post_stats (post_stats_post_id, post_like_count, post_save_count)
+    VALUES (NEW.post_id, 0, 0)
+    ON CONFLICT (post_stats_post_id) DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_posts_init_stats ON posts;
+CREATE TRIGGER trg_posts_init_stats
+    AFTER INSERT ON posts
+    FOR EACH ROW EXECUTE FUNCTION posts_init_stats();
+
+-- โพสต์ที่ยังไม่มีแถว stats (นับจากตารางจริง trigger ใน post_stats คิด trending ให้)
+INSERT INTO post_stats (post_stats_post_id, post_like_count, post_save_count, post_comment_count)
+SELECT p.post_id,
+       (SELECT COUNT(*) FROM likes l WHERE l.like_post_id = p.post_id),
+       (SELECT COUNT(*) FROM saved_posts sp WHERE sp.save_post_id = p.post_id),
+       (SELECT COUNT(*) FROM comments c WHERE c.comment_post_id = p.post_id AND c.comment_deleted_at IS NULL)
+FROM posts p
+ON CONFLICT (post_stats_post_id) DO NOTHING;